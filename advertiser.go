@@ -5,33 +5,164 @@ package ra
 
 import (
 	"context"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
 	"net/netip"
 	"reflect"
 	"slices"
 	"sync"
 	"time"
 
+	"github.com/creasty/defaults"
 	"github.com/mdlayher/ndp"
+	"golang.org/x/net/ipv6"
 	"golang.org/x/sys/unix"
 )
 
+// The minimum link MTU for IPv6 (RFC8200)
+const ipv6MinMTU = 1280
+
+// ND option type of the padding option (RFC4727 experimental)
+const paddingOptionType = 253
+
+// Interval between the repeated solicited RAs
+const solicitedRARepeatInterval = 50 * time.Millisecond
+
+// Interval of the DNS health check. It's independent from the RA interval,
+// so that the change is reflected to both unsolicited and solicited RAs
+// without waiting for the next unsolicited RA.
+const dnsHealthCheckInterval = time.Second
+
+// ErrSelfTest is returned by NewDaemon and Daemon.Reload when the RA message
+// built from the configuration cannot be marshaled or doesn't fit into the MTU.
+var ErrSelfTest = errors.New("self-test failed")
+
 type advertiser struct {
 	logger *slog.Logger
 
-	initialConfig *InterfaceConfig
+	// Handler of the logger to override the log level per interface
+	logHandler *levelHandler
+
+	initialConfig     *InterfaceConfig
+	initialGeneration int
 
 	// We use mutex-based synchronization instead of channels because
 	// ifaceStatus must be reported even when the main loop is hanging.
 	ifaceStatus     *InterfaceStatus
 	ifaceStatusLock sync.RWMutex
 
-	reloadCh      chan *InterfaceConfig
+	reloadCh      chan *reloadMsg
 	stopCh        chan any
 	socketCtor    socketCtor
 	deviceWatcher deviceWatcher
+
+	// Duration to wait for the device to come back up before tearing
+	// down the socket. Zero means tearing down immediately.
+	flapGrace time.Duration
+
+	// Called for each received RS. Optional.
+	rsHandler RSHandler
+
+	// Called before each unsolicited RA to check the health of the DNS
+	// resolvers. Optional.
+	dnsHealthCheck DNSHealthCheck
+
+	// Called when the advertised prefixes change. Optional.
+	prefixChangeHandler PrefixChangeHandler
+
+	// How to handle the RS which cannot be parsed
+	malformedRSPolicy MalformedRSPolicy
+
+	// The configured RA interval below this is raised to this. Zero
+	// means no clamp. minIntervalWarned is set once the clamp is
+	// logged. Used only by the main loop.
+	minInterval       time.Duration
+	minIntervalWarned bool
+
+	// Access to the kernel parameters to check accept_ra
+	sysctl sysctl
+
+	// Advertise zero lifetimes until this time. poisonCh notifies the
+	// main loop about the update.
+	poisonUntil     time.Time
+	poisonUntilLock sync.Mutex
+	poisonCh        chan any
+
+	// Stop advertising while set. pauseCh notifies the main loop about
+	// the update.
+	paused     bool
+	pausedLock sync.Mutex
+	pauseCh    chan any
+
+	// Name of the alias to advertise. Empty means the interface
+	// configuration itself. aliasCh notifies the main loop about the
+	// update. aliases are the ones of the latest configuration passed
+	// to the advertiser to validate the alias to activate.
+	alias     string
+	aliases   map[string]*AliasConfig
+	aliasLock sync.Mutex
+	aliasCh   chan any
+
+	// Source of the prefixes and the prefixes loaded from it. The poller
+	// goroutine updates the prefixes and sourcedPrefixesCh notifies the
+	// main loop about the update. prefixSourceCh notifies the poller
+	// about the source change.
+	prefixSource             *PrefixSourceConfig
+	sourcedPrefixes          []string
+	withdrawnSourcedPrefixes []string
+	prefixSourceLock         sync.Mutex
+	prefixSourceCh           chan any
+	sourcedPrefixesCh        chan any
+
+	// Interfaces to derive the advertised MTU from and their MTUs. The
+	// watcher goroutine updates the MTUs and underlyingMTUCh notifies the
+	// main loop about the update. mtuInterfacesCh notifies the watcher
+	// about the interface change.
+	mtuInterfaces     []string
+	mtuNetnsPath      string
+	underlyingMTUs    map[string]int
+	underlyingMTULock sync.Mutex
+	mtuInterfacesCh   chan any
+	underlyingMTUCh   chan any
+
+	// HTTP client to poll the prefix source
+	httpClient *http.Client
+
+	// Delay of the unsolicited RA from the tick to spread the sends of
+	// the interfaces. Assigned by the daemon.
+	sendDelay     time.Duration
+	sendDelayLock sync.Mutex
+
+	// Schedule of the unsolicited RA. The RA is sent every interval from
+	// the start. Zero start means no RA is scheduled. Protected by
+	// ifaceStatusLock.
+	scheduleStart    time.Time
+	scheduleInterval time.Duration
+
+	// State of the rate limiting of the multicast RAs. Protected by
+	// ifaceStatusLock.
+	rlState RLState
+
+	// Called when the state of the interface status changes. Optional.
+	notifyStatus func()
+
+	// Random source of the RA interval jitter. Used only by the main
+	// loop.
+	rng *rand.Rand
+
+	// Times the prefixes with DecrementLifetimes are loaded. Used only by
+	// the main loop.
+	prefixLoadedAt map[prefixLoadKey]time.Time
+
+	// Clock and the start time of the prefix rotation
+	clock         clock
+	rotationStart time.Time
 }
 
 // An internal structure to represent RS
@@ -40,20 +171,133 @@ type rsMsg struct {
 	from netip.Addr
 }
 
-func newAdvertiser(initialConfig *InterfaceConfig, ctor socketCtor, devWatcher deviceWatcher, logger *slog.Logger) *advertiser {
+// An internal structure to represent RA sent by the other routers
+type raMsg struct {
+	ra   *ndp.RouterAdvertisement
+	from netip.Addr
+}
+
+// An internal structure to represent the other router on the link learned
+// from its RA
+type foreignRouter struct {
+	preference ndp.Preference
+	expiry     time.Time
+}
+
+// An internal structure to represent solicited RA waiting for the repeat
+type solicitedRepeat struct {
+	to   netip.Addr
+	msg  *ndp.RouterAdvertisement
+	left int
+}
+
+// An internal structure to represent the prefix removed by reload which is
+// still advertised with zero lifetimes
+type removedPrefix struct {
+	prefix *PrefixConfig
+	left   int
+}
+
+// An internal structure to represent reload request
+type reloadMsg struct {
+	config     *InterfaceConfig
+	generation int
+}
+
+// advertiserOptions holds the daemon-wide settings shared by the advertisers
+type advertiserOptions struct {
+	socketCtor          socketCtor
+	deviceWatcher       deviceWatcher
+	flapGrace           time.Duration
+	rsHandler           RSHandler
+	dnsHealthCheck      DNSHealthCheck
+	prefixChangeHandler PrefixChangeHandler
+	malformedRSPolicy   MalformedRSPolicy
+	minInterval         time.Duration
+	sysctl              sysctl
+	httpClient          *http.Client
+	notifyStatus        func()
+	clock               clock
+	logger              *slog.Logger
+}
+
+func newAdvertiser(initialConfig *InterfaceConfig, initialGeneration int, opts *advertiserOptions) *advertiser {
+	logHandler := newLevelHandler(opts.logger.With(slog.String("interface", initialConfig.Name)).Handler())
+	logHandler.setLevel(initialConfig.LogLevel)
 	return &advertiser{
-		logger:        logger.With(slog.String("interface", initialConfig.Name)),
-		initialConfig: initialConfig,
-		ifaceStatus:   &InterfaceStatus{Name: initialConfig.Name, State: "Unknown"},
-		reloadCh:      make(chan *InterfaceConfig),
-		stopCh:        make(chan any),
-		socketCtor:    ctor,
-		deviceWatcher: devWatcher,
+		logger:              slog.New(logHandler),
+		logHandler:          logHandler,
+		initialConfig:       initialConfig,
+		initialGeneration:   initialGeneration,
+		ifaceStatus:         &InterfaceStatus{Name: initialConfig.Name, State: "Unknown"},
+		reloadCh:            make(chan *reloadMsg),
+		stopCh:              make(chan any),
+		poisonCh:            make(chan any, 1),
+		pauseCh:             make(chan any, 1),
+		aliases:             initialConfig.Aliases,
+		aliasCh:             make(chan any, 1),
+		prefixSourceCh:      make(chan any, 1),
+		sourcedPrefixesCh:   make(chan any, 1),
+		mtuInterfacesCh:     make(chan any, 1),
+		underlyingMTUCh:     make(chan any, 1),
+		socketCtor:          opts.socketCtor,
+		deviceWatcher:       opts.deviceWatcher,
+		flapGrace:           opts.flapGrace,
+		rsHandler:           opts.rsHandler,
+		dnsHealthCheck:      opts.dnsHealthCheck,
+		prefixChangeHandler: opts.prefixChangeHandler,
+		malformedRSPolicy:   opts.malformedRSPolicy,
+		minInterval:         opts.minInterval,
+		sysctl:              opts.sysctl,
+		httpClient:          opts.httpClient,
+		notifyStatus:        opts.notifyStatus,
+		rng:                 rand.New(rand.NewSource(time.Now().UnixNano())),
+		clock:               opts.clock,
+		rotationStart:       opts.clock.Now(),
 	}
 }
 
+// checkAcceptRA warns when the interface accepts RAs as the router may
+// configure itself from its own or the other routers' RAs. accept_ra is set to
+// 0 when DisableAcceptRA is set.
+func (s *advertiser) checkAcceptRA(config *InterfaceConfig) {
+	if s.sysctl == nil {
+		return
+	}
+
+	acceptRA, err := getAcceptRA(s.sysctl, config.NetnsPath, config.Name)
+	if err != nil {
+		s.logger.Debug("Cannot read accept_ra", "error", err.Error())
+		return
+	}
+
+	if acceptRA == 0 {
+		return
+	}
+
+	if !config.DisableAcceptRA {
+		s.logger.Warn("accept_ra is enabled on the interface. The router may configure itself from the RAs.", "acceptRA", acceptRA)
+		return
+	}
+
+	if err := setAcceptRA(s.sysctl, config.NetnsPath, config.Name, 0); err != nil {
+		s.logger.Warn("Failed to disable accept_ra", "error", err.Error())
+		return
+	}
+
+	s.logger.Info("Disabled accept_ra on the interface", "acceptRA", acceptRA)
+}
+
 func (s *advertiser) createRAMsg(config *InterfaceConfig, deviceState *deviceState) *ndp.RouterAdvertisement {
-	return &ndp.RouterAdvertisement{
+	return s.createRAMsgs(config, deviceState)[0]
+}
+
+// createRAMsgs creates the RA messages from the configuration. There's only
+// one message unless the DNS options are split with SplitDNSOptions. The
+// first one is the regular RA and the rest only carries the DNS options that
+// don't fit into it.
+func (s *advertiser) createRAMsgs(config *InterfaceConfig, deviceState *deviceState) []*ndp.RouterAdvertisement {
+	msg := &ndp.RouterAdvertisement{
 		CurrentHopLimit:           uint8(config.CurrentHopLimit),
 		ManagedConfiguration:      config.Managed,
 		OtherConfiguration:        config.Other,
@@ -63,78 +307,735 @@ func (s *advertiser) createRAMsg(config *InterfaceConfig, deviceState *deviceSta
 		RetransmitTimer:           time.Duration(config.RetransmitTimeMilliseconds) * time.Millisecond,
 		Options:                   s.createOptions(config, deviceState),
 	}
-}
 
-func (s *advertiser) createOptions(config *InterfaceConfig, deviceState *deviceState) []ndp.Option {
-	options := []ndp.Option{
-		&ndp.LinkLayerAddress{
-			Direction: ndp.Source,
-			Addr:      deviceState.addr,
-		},
+	if config.PadToBytes > 0 {
+		// Keep the SEND options last. The RSA Signature option must
+		// be the last one.
+		n := len(msg.Options)
+		if !slices.Contains(config.DisabledOptions, "send") {
+			n -= len(config.SENDOptions)
+		}
+		msg.Options = slices.Concat(msg.Options[:n], paddingOptions(msg, config.PadToBytes), msg.Options[n:])
+	}
+
+	if config.SplitDNSOptions {
+		return splitDNSOptions(msg, linkMTU(config))
 	}
 
+	return []*ndp.RouterAdvertisement{msg}
+}
+
+// linkMTU returns the MTU the RA must fit into
+func linkMTU(config *InterfaceConfig) int {
 	if config.MTU > 0 {
-		options = append(options, &ndp.MTU{
-			MTU: uint32(config.MTU),
+		return config.MTU
+	}
+	return ipv6MinMTU
+}
+
+// splitDNSOptions splits the RDNSS and DNSSL options of the RA so that each
+// RA fits into the MTU. The addresses and domain names are packed into the
+// options in order as many as fit. The first RA keeps the other options at
+// their positions and the following RAs only carry the DNS options. An
+// entry which doesn't fit even alone is kept as is (selfTest catches it).
+func splitDNSOptions(msg *ndp.RouterAdvertisement, mtu int) []*ndp.RouterAdvertisement {
+	fits := func(options []ndp.Option) bool {
+		m := *msg
+		m.Options = options
+		// The options longer than the maximum length fail to marshal
+		b, err := ndp.MarshalMessage(&m)
+		return err == nil && ipv6.HeaderLen+len(b) <= mtu
+	}
+
+	if fits(msg.Options) {
+		return []*ndp.RouterAdvertisement{msg}
+	}
+
+	// The DNS options are placed at the position of the first one
+	var head, tail, dnsOptions []ndp.Option
+	for _, opt := range msg.Options {
+		switch opt.(type) {
+		case *ndp.RecursiveDNSServer, *ndp.DNSSearchList:
+			dnsOptions = append(dnsOptions, opt)
+		default:
+			if len(dnsOptions) == 0 {
+				head = append(head, opt)
+			} else {
+				tail = append(tail, opt)
+			}
+		}
+	}
+
+	msgs := []*ndp.RouterAdvertisement{}
+	dns := []ndp.Option{}
+	options := func(opt ...ndp.Option) []ndp.Option {
+		return slices.Concat(head, dns, opt, tail)
+	}
+	flush := func() {
+		m := *msg
+		m.Options = options()
+		msgs = append(msgs, &m)
+		head, tail, dns = nil, nil, []ndp.Option{}
+	}
+
+	for _, opt := range dnsOptions {
+		n, sub := dnsEntries(opt)
+		for i := 0; i < n; {
+			j := i + 1
+			for j < n && fits(options(sub(i, j+1))) {
+				j++
+			}
+			if !fits(options(sub(i, j))) && len(options()) > 0 {
+				// Continue in the next RA
+				flush()
+				continue
+			}
+			dns = append(dns, sub(i, j))
+			i = j
+		}
+	}
+	flush()
+
+	return msgs
+}
+
+// dnsEntries returns the number of the addresses or domain names of the DNS
+// option and the function to create the option with the subset of them
+func dnsEntries(opt ndp.Option) (int, func(i, j int) ndp.Option) {
+	switch o := opt.(type) {
+	case *ndp.RecursiveDNSServer:
+		return len(o.Servers), func(i, j int) ndp.Option {
+			return &ndp.RecursiveDNSServer{Lifetime: o.Lifetime, Servers: o.Servers[i:j]}
+		}
+	case *ndp.DNSSearchList:
+		return len(o.DomainNames), func(i, j int) ndp.Option {
+			return &ndp.DNSSearchList{Lifetime: o.Lifetime, DomainNames: o.DomainNames[i:j]}
+		}
+	}
+	return 0, nil
+}
+
+// paddingOptions returns the padding options to pad the RA to the given size
+// rounded up to the multiple of 8 octets. It returns nothing if the RA is
+// already larger than that.
+func paddingOptions(msg *ndp.RouterAdvertisement, size int) []ndp.Option {
+	b, err := ndp.MarshalMessage(msg)
+	if err != nil {
+		// selfTest catches it
+		return nil
+	}
+
+	// Both sizes are the multiple of 8 octets. The option length is
+	// limited to 31 units of 8 octets by the underlying library, so we
+	// may need multiple options.
+	options := []ndp.Option{}
+	for pad := (size+7)/8*8 - len(b); pad > 0; {
+		n := min(pad, 31*8)
+		options = append(options, &ndp.RawOption{
+			Type:   paddingOptionType,
+			Length: uint8(n / 8),
+			Value:  make([]byte, n-2),
 		})
+		pad -= n
+	}
+
+	return options
+}
+
+// createSolicitedRAMsg derives the RA message for the RS reply from the
+// unsolicited one
+func (s *advertiser) createSolicitedRAMsg(config *InterfaceConfig, deviceState *deviceState, msg *ndp.RouterAdvertisement) *ndp.RouterAdvertisement {
+	rebuild := !*config.IncludePrefixesInSolicited || config.SLLAInSolicitedOnly || hasSolicitedOnlyNAT64Prefixes(config)
+	if config.SolicitedPreference == "" && !rebuild &&
+		config.SolicitedReachableTimeMilliseconds == nil && config.SolicitedRetransmitTimeMilliseconds == nil {
+		return msg
+	}
+	solicitedMsg := *msg
+	if rebuild {
+		// Rebuild the message to recompute the padding
+		c := solicitedOnlyConfig(config)
+		if !*config.IncludePrefixesInSolicited {
+			c.Prefixes = nil
+		}
+		solicitedMsg = *s.createRAMsg(c, deviceState)
+	}
+	if config.SolicitedPreference != "" {
+		solicitedMsg.RouterSelectionPreference = s.toNDPPreference(config.SolicitedPreference)
+	}
+	if config.SolicitedReachableTimeMilliseconds != nil {
+		solicitedMsg.ReachableTime = time.Duration(*config.SolicitedReachableTimeMilliseconds) * time.Millisecond
+	}
+	if config.SolicitedRetransmitTimeMilliseconds != nil {
+		solicitedMsg.RetransmitTimer = time.Duration(*config.SolicitedRetransmitTimeMilliseconds) * time.Millisecond
+	}
+	return &solicitedMsg
+}
+
+// solicitedOnlyConfig returns a copy of the configuration with the options
+// only for the RS replies (e.g. SLLAInSolicitedOnly) turned into the regular
+// ones
+func solicitedOnlyConfig(config *InterfaceConfig) *InterfaceConfig {
+	c := config.deepCopy()
+	c.SLLAInSolicitedOnly = false
+	for _, nat64prefix := range c.NAT64Prefixes {
+		nat64prefix.SolicitedOnly = false
+	}
+	return c
+}
+
+func hasSolicitedOnlyNAT64Prefixes(config *InterfaceConfig) bool {
+	return slices.ContainsFunc(config.NAT64Prefixes, func(p *NAT64PrefixConfig) bool {
+		return p.SolicitedOnly
+	})
+}
+
+// poisonedConfig returns a copy of the configuration with zero router and
+// prefix lifetimes to withdraw the router and deprecate the prefixes
+func poisonedConfig(config *InterfaceConfig) *InterfaceConfig {
+	c := config.deepCopy()
+	c.RouterLifetimeSeconds = 0
+	// Preference must be medium when the router lifetime is zero
+	c.Preference = "medium"
+	c.SolicitedPreference = ""
+	for _, prefix := range c.Prefixes {
+		*prefix.ValidLifetimeSeconds = 0
+		*prefix.PreferredLifetimeSeconds = 0
 	}
+	return c
+}
+
+// withdrawalConfig returns a copy of the configuration with zero router
+// lifetime to withdraw the router from the default router list of the hosts
+func withdrawalConfig(config *InterfaceConfig) *InterfaceConfig {
+	c := config.deepCopy()
+	c.RouterLifetimeSeconds = 0
+	// Preference must be medium when the router lifetime is zero
+	c.Preference = "medium"
+	c.SolicitedPreference = ""
+	return c
+}
+
+// solicitorOverriddenConfig returns a copy of the configuration with the
+// overrides for the specific solicitor applied
+func solicitorOverriddenConfig(config *InterfaceConfig, override *SolicitorOverrideConfig) *InterfaceConfig {
+	c := config.deepCopy()
+	if override.Prefixes != nil {
+		c.Prefixes = make([]*PrefixConfig, len(override.Prefixes))
+		for i, prefix := range override.Prefixes {
+			c.Prefixes[i] = prefix.deepCopy()
+		}
+	}
+	if override.RDNSSes != nil {
+		c.RDNSSes = make([]*RDNSSConfig, len(override.RDNSSes))
+		for i, rdnss := range override.RDNSSes {
+			c.RDNSSes[i] = rdnss.deepCopy()
+		}
+	}
+	return c
+}
+
+// aliasedConfig returns a copy of the configuration with the options of the
+// alias applied. The configuration itself is returned when the alias is empty
+// or not found.
+func aliasedConfig(config *InterfaceConfig, alias string) *InterfaceConfig {
+	a, ok := config.Aliases[alias]
+	if alias == "" || !ok {
+		return config
+	}
+	c := config.deepCopy()
+	if a.Prefixes != nil {
+		c.Prefixes = make([]*PrefixConfig, len(a.Prefixes))
+		for i, prefix := range a.Prefixes {
+			c.Prefixes[i] = prefix.deepCopy()
+		}
+	}
+	if a.Routes != nil {
+		c.Routes = make([]*RouteConfig, len(a.Routes))
+		for i, route := range a.Routes {
+			c.Routes[i] = route.deepCopy()
+		}
+	}
+	if a.RDNSSes != nil {
+		c.RDNSSes = make([]*RDNSSConfig, len(a.RDNSSes))
+		for i, rdnss := range a.RDNSSes {
+			c.RDNSSes[i] = rdnss.deepCopy()
+		}
+	}
+	if a.DNSSLs != nil {
+		c.DNSSLs = make([]*DNSSLConfig, len(a.DNSSLs))
+		for i, dnssl := range a.DNSSLs {
+			c.DNSSLs[i] = dnssl.deepCopy()
+		}
+	}
+	return c
+}
+
+// dnsWithdrawnConfig returns a copy of the configuration with zero RDNSS and
+// DNSSL lifetimes to withdraw the DNS resolvers
+func dnsWithdrawnConfig(config *InterfaceConfig) *InterfaceConfig {
+	c := config.deepCopy()
+	for _, rdnss := range c.RDNSSes {
+		rdnss.LifetimeSeconds = 0
+	}
+	for _, dnssl := range c.DNSSLs {
+		dnssl.LifetimeSeconds = 0
+	}
+	return c
+}
 
+// effectiveInterval returns the RA interval raised to the minimum interval of
+// the daemon. The clamp is logged once per interface.
+func (s *advertiser) effectiveInterval(config *InterfaceConfig) time.Duration {
+	interval := time.Duration(config.RAIntervalMilliseconds) * time.Millisecond
+	if interval >= s.minInterval {
+		return interval
+	}
+	if !s.minIntervalWarned {
+		s.logger.Warn("RA interval is raised to the minimum effective interval", "configured", interval, "effective", s.minInterval)
+		s.minIntervalWarned = true
+	}
+	return s.minInterval
+}
+
+// prefixLoadKey identifies the prefix with DecrementLifetimes. The decrement
+// restarts when any of them changes.
+type prefixLoadKey struct {
+	prefix    string
+	preferred int
+	valid     int
+}
+
+func newPrefixLoadKey(p *PrefixConfig) prefixLoadKey {
+	return prefixLoadKey{prefix: p.Prefix, preferred: *p.PreferredLifetimeSeconds, valid: *p.ValidLifetimeSeconds}
+}
+
+// updatePrefixLoadedAt records the load time of the prefixes with
+// DecrementLifetimes. The prefixes loaded before keep their time and the
+// removed ones are forgotten.
+func (s *advertiser) updatePrefixLoadedAt(config *InterfaceConfig) {
+	loadedAt := map[prefixLoadKey]time.Time{}
 	for _, prefix := range config.Prefixes {
-		// At this point, we should have validated the
-		// configuration. If we haven't, it's a bug.
-		p := netip.MustParsePrefix(prefix.Prefix)
-		options = append(options, &ndp.PrefixInformation{
-			PrefixLength:                   uint8(p.Bits()),
-			OnLink:                         prefix.OnLink,
-			AutonomousAddressConfiguration: prefix.Autonomous,
-			ValidLifetime:                  time.Second * time.Duration(*prefix.ValidLifetimeSeconds),
-			PreferredLifetime:              time.Second * time.Duration(*prefix.PreferredLifetimeSeconds),
-			Prefix:                         p.Addr(),
+		if !prefix.DecrementLifetimes {
+			continue
+		}
+		key := newPrefixLoadKey(prefix)
+		if t, ok := s.prefixLoadedAt[key]; ok {
+			loadedAt[key] = t
+		} else {
+			loadedAt[key] = s.clock.Now()
+		}
+	}
+	s.prefixLoadedAt = loadedAt
+}
+
+// hasDecrementingLifetimes returns true if any prefix has DecrementLifetimes,
+// so that the RA must be rebuilt on each send
+func hasDecrementingLifetimes(config *InterfaceConfig) bool {
+	return slices.ContainsFunc(config.Prefixes, func(p *PrefixConfig) bool {
+		return p.DecrementLifetimes
+	})
+}
+
+// jitteredInterval returns the interval randomized uniformly within +/- the
+// percentage of it
+func jitteredInterval(interval time.Duration, percent int, rng *rand.Rand) time.Duration {
+	if percent == 0 {
+		return interval
+	}
+	delta := interval * time.Duration(percent) / 100
+	return interval - delta + time.Duration(rng.Int63n(int64(2*delta)+1))
+}
+
+// rotationSlot returns the number of the prefix rotations since the
+// advertiser started. It returns -1 if the prefix rotation is disabled.
+func (s *advertiser) rotationSlot(config *InterfaceConfig) int {
+	if config.PrefixRotation == nil {
+		return -1
+	}
+	interval := time.Duration(config.PrefixRotation.IntervalSeconds) * time.Second
+	return int(s.clock.Now().Sub(s.rotationStart) / interval)
+}
+
+// untilNextRotation returns the duration until the slot ends. The prefix
+// rotation must be enabled.
+func (s *advertiser) untilNextRotation(config *InterfaceConfig, slot int) time.Duration {
+	interval := time.Duration(config.PrefixRotation.IntervalSeconds) * time.Second
+	return s.rotationStart.Add(time.Duration(slot+1) * interval).Sub(s.clock.Now())
+}
+
+// rotatingPrefix returns the rotating prefix of the slot. It returns empty
+// string if the prefix rotation is disabled.
+func rotatingPrefix(config *InterfaceConfig, slot int) string {
+	if slot < 0 {
+		return ""
+	}
+	prefixes := config.PrefixRotation.Prefixes
+	return prefixes[slot%len(prefixes)]
+}
+
+// rotatedConfig returns a copy of the configuration with the rotating prefix
+// of the slot appended to the Prefixes. The withdrawn prefix, the one
+// advertised before the rotation, is also appended with zero lifetimes
+// unless it's empty or same as the current one.
+func rotatedConfig(config *InterfaceConfig, slot int, withdrawn string) *InterfaceConfig {
+	if slot < 0 {
+		return config
+	}
+
+	c := config.deepCopy()
+
+	current := &PrefixConfig{
+		Prefix:     rotatingPrefix(c, slot),
+		OnLink:     true,
+		Autonomous: true,
+	}
+	if err := defaults.Set(current); err != nil {
+		panic("BUG (Please report 🙏): Defaulting failed: " + err.Error())
+	}
+	c.Prefixes = append(c.Prefixes, current)
+
+	if withdrawn != "" && withdrawn != current.Prefix {
+		validLifetime, preferredLifetime := 0, 0
+		c.Prefixes = append(c.Prefixes, &PrefixConfig{
+			Prefix:                   withdrawn,
+			OnLink:                   true,
+			Autonomous:               true,
+			ValidLifetimeSeconds:     &validLifetime,
+			PreferredLifetimeSeconds: &preferredLifetime,
 		})
 	}
 
-	for _, route := range config.Routes {
-		// At this point, we should have validated the
-		// configuration. If we haven't, it's a bug.
-		p := netip.MustParsePrefix(route.Prefix)
-		options = append(options, &ndp.RouteInformation{
-			PrefixLength:  uint8(p.Bits()),
-			Preference:    s.toNDPPreference(route.Preference),
-			RouteLifetime: time.Second * time.Duration(route.LifetimeSeconds),
-			Prefix:        p.Addr(),
+	return c
+}
+
+// updateRemovedPrefixes returns the prefixes removed by the reload from the
+// old configuration to the new one added to the ones being withdrawn. The
+// prefixes added back by the reload are no longer withdrawn. Nothing is
+// withdrawn when the WithdrawalRepeat of the new configuration is zero.
+func updateRemovedPrefixes(removed []*removedPrefix, oldConfig, newConfig *InterfaceConfig) []*removedPrefix {
+	if newConfig.WithdrawalRepeat == 0 {
+		return nil
+	}
+
+	configured := func(prefix *PrefixConfig) bool {
+		p := prefix.advertisedPrefix().Masked()
+		return slices.ContainsFunc(newConfig.Prefixes, func(pc *PrefixConfig) bool {
+			return pc.advertisedPrefix().Masked() == p
 		})
 	}
 
-	for _, rdnss := range config.RDNSSes {
-		addresses := []netip.Addr{}
-		for _, addr := range rdnss.Addresses {
-			// At this point, we should have validated the
-			// configuration. If we haven't, it's a bug.
-			addresses = append(addresses, netip.MustParseAddr(addr))
+	ret := []*removedPrefix{}
+	for _, r := range removed {
+		if !configured(r.prefix) {
+			ret = append(ret, r)
+		}
+	}
+
+	for _, prefix := range oldConfig.Prefixes {
+		if configured(prefix) {
+			continue
 		}
-		options = append(options, &ndp.RecursiveDNSServer{
-			Lifetime: time.Second * time.Duration(rdnss.LifetimeSeconds),
-			Servers:  addresses,
+		validLifetime, preferredLifetime := 0, 0
+		ret = append(ret, &removedPrefix{
+			prefix: &PrefixConfig{
+				Prefix:                   prefix.Prefix,
+				SubnetID:                 prefix.SubnetID,
+				OnLink:                   prefix.OnLink,
+				Autonomous:               prefix.Autonomous,
+				ValidLifetimeSeconds:     &validLifetime,
+				PreferredLifetimeSeconds: &preferredLifetime,
+			},
+			left: newConfig.WithdrawalRepeat,
 		})
 	}
 
-	for _, dnssl := range config.DNSSLs {
-		options = append(options, &ndp.DNSSearchList{
-			Lifetime:    time.Second * time.Duration(dnssl.LifetimeSeconds),
-			DomainNames: dnssl.DomainNames,
+	return ret
+}
+
+// removedPrefixesConfig returns a copy of the configuration with the prefixes
+// being withdrawn appended with zero lifetimes
+func removedPrefixesConfig(config *InterfaceConfig, removed []*removedPrefix) *InterfaceConfig {
+	if len(removed) == 0 {
+		return config
+	}
+	c := config.deepCopy()
+	for _, r := range removed {
+		c.Prefixes = append(c.Prefixes, r.prefix)
+	}
+	return c
+}
+
+// advertisedPrefixes returns the prefixes advertised with the configuration
+// for PrefixChangeEvent. The lifetimes are the configured ones, so the
+// decrement of DecrementLifetimes is not a change.
+func advertisedPrefixes(config *InterfaceConfig) []AdvertisedPrefix {
+	prefixes := []AdvertisedPrefix{}
+	for _, prefix := range config.Prefixes {
+		prefixes = append(prefixes, AdvertisedPrefix{
+			Prefix:                   prefix.advertisedPrefix().String(),
+			OnLink:                   prefix.OnLink && !config.ForceOffLink,
+			Autonomous:               prefix.Autonomous,
+			ValidLifetimeSeconds:     *prefix.ValidLifetimeSeconds,
+			PreferredLifetimeSeconds: *prefix.PreferredLifetimeSeconds,
+		})
+	}
+	return prefixes
+}
+
+// everyNConfig returns a copy of the configuration without the prefixes which
+// are not advertised in the count-th (0-based) unsolicited RA. It returns the
+// configuration as is when no prefix has AdvertiseEveryN.
+func everyNConfig(config *InterfaceConfig, count int) *InterfaceConfig {
+	if !slices.ContainsFunc(config.Prefixes, func(p *PrefixConfig) bool { return p.AdvertiseEveryN > 1 }) {
+		return config
+	}
+	c := config.deepCopy()
+	c.Prefixes = slices.DeleteFunc(c.Prefixes, func(p *PrefixConfig) bool {
+		return p.AdvertiseEveryN > 1 && count%p.AdvertiseEveryN != 0
+	})
+	return c
+}
+
+func (s *advertiser) isDNSHealthy(iface string) bool {
+	if s.dnsHealthCheck == nil {
+		return true
+	}
+	return s.dnsHealthCheck(iface)
+}
+
+// createOptions creates the RA options from the configuration. The order of
+// the options is deterministic. The Source Link-Layer Address and MTU options
+// come first, followed by Prefix Information, Route Information, RDNSS,
+// DNSSL, PREF64, vendor-specific, and SEND options. The options of the same
+// type appear in the order of the configuration. Don't iterate over maps
+// here, otherwise the guarantee breaks. The types in the DisabledOptions are
+// omitted.
+func (s *advertiser) createOptions(config *InterfaceConfig, deviceState *deviceState) []ndp.Option {
+	options := []ndp.Option{}
+
+	disabled := func(option string) bool {
+		return slices.Contains(config.DisabledOptions, option)
+	}
+
+	// Point-to-point devices and the devices without link-layer address
+	// (e.g. tun) don't have the address to advertise. With
+	// SLLAInSolicitedOnly, only the RS replies include it.
+	if !deviceState.isPointToPoint && len(deviceState.addr) > 0 && !disabled("slla") && !config.SLLAInSolicitedOnly {
+		options = append(options, &ndp.LinkLayerAddress{
+			Direction: ndp.Source,
+			Addr:      deviceState.addr,
 		})
 	}
 
-	for _, nat64prefix := range config.NAT64Prefixes {
-		options = append(options, &ndp.PREF64{
-			Lifetime: time.Second * time.Duration(*nat64prefix.LifetimeSeconds),
-			Prefix:   netip.MustParsePrefix(nat64prefix.Prefix),
+	if config.MTU > 0 && !disabled("mtu") {
+		options = append(options, &ndp.MTU{
+			MTU: uint32(config.MTU),
 		})
 	}
 
+	if !disabled("prefix") {
+		for _, prefix := range config.Prefixes {
+			// At this point, we should have validated the
+			// configuration. If we haven't, it's a bug.
+			p := prefix.advertisedPrefix()
+			if prefix.RequireLocalAddress && !slices.ContainsFunc(deviceState.v6GlobalAddrs, p.Contains) {
+				continue
+			}
+			preferred, valid := uint32(*prefix.PreferredLifetimeSeconds), uint32(*prefix.ValidLifetimeSeconds)
+			if loadedAt, ok := s.prefixLoadedAt[newPrefixLoadKey(prefix)]; ok && prefix.DecrementLifetimes {
+				preferred, valid = EffectiveLifetimesAt(prefix, loadedAt, s.clock.Now())
+			}
+			options = append(options, &ndp.PrefixInformation{
+				PrefixLength:                   uint8(p.Bits()),
+				OnLink:                         prefix.OnLink && !config.ForceOffLink,
+				AutonomousAddressConfiguration: prefix.Autonomous,
+				ValidLifetime:                  time.Second * time.Duration(valid),
+				PreferredLifetime:              time.Second * time.Duration(preferred),
+				Prefix:                         p.Addr(),
+			})
+		}
+	}
+
+	if !disabled("route") {
+		for _, route := range config.Routes {
+			// At this point, we should have validated the
+			// configuration. If we haven't, it's a bug.
+			p := netip.MustParsePrefix(route.Prefix)
+			options = append(options, &ndp.RouteInformation{
+				PrefixLength:  uint8(p.Bits()),
+				Preference:    s.toNDPPreference(route.Preference),
+				RouteLifetime: time.Second * time.Duration(route.LifetimeSeconds),
+				Prefix:        p.Addr(),
+			})
+		}
+	}
+
+	if !disabled("rdnss") {
+		for _, rdnss := range config.RDNSSes {
+			addresses := []netip.Addr{}
+			for _, addr := range rdnss.Addresses {
+				// At this point, we should have validated the
+				// configuration. If we haven't, it's a bug.
+				addresses = append(addresses, netip.MustParseAddr(addr))
+			}
+			options = append(options, &ndp.RecursiveDNSServer{
+				Lifetime: time.Second * time.Duration(rdnss.LifetimeSeconds),
+				Servers:  addresses,
+			})
+		}
+	}
+
+	if !disabled("dnssl") {
+		for _, dnssl := range config.DNSSLs {
+			options = append(options, &ndp.DNSSearchList{
+				Lifetime:    time.Second * time.Duration(dnssl.LifetimeSeconds),
+				DomainNames: dnssl.DomainNames,
+			})
+		}
+	}
+
+	if !disabled("pref64") {
+		for _, nat64prefix := range config.NAT64Prefixes {
+			// Only the RS replies have it
+			if nat64prefix.SolicitedOnly {
+				continue
+			}
+			options = append(options, &ndp.PREF64{
+				Lifetime: time.Second * time.Duration(*nat64prefix.LifetimeSeconds),
+				Prefix:   netip.MustParsePrefix(nat64prefix.Prefix),
+			})
+		}
+	}
+
+	if !disabled("vendor") {
+		for _, vendor := range config.VendorOptions {
+			options = append(options, vendorOption(vendor))
+		}
+	}
+
+	if !disabled("send") {
+		for _, send := range config.SENDOptions {
+			// At this point, we should have validated the
+			// configuration. If we haven't, it's a bug.
+			data, _ := hex.DecodeString(send.Data)
+			options = append(options, &ndp.RawOption{
+				Type:   uint8(send.Type),
+				Length: uint8((2 + len(data)) / 8),
+				Value:  data,
+			})
+		}
+	}
+
 	return options
 }
 
+// vendorOption encodes the vendor-specific option. See VendorOptionConfig
+// for the layout. The config must be validated beforehand.
+func vendorOption(config *VendorOptionConfig) *ndp.RawOption {
+	payload, _ := hex.DecodeString(config.Payload)
+
+	// Type, Length, Reserved and Enterprise Number take 8 octets
+	length := (8 + len(payload) + 7) / 8
+
+	// Value doesn't include the Type and Length
+	value := make([]byte, length*8-2)
+	binary.BigEndian.PutUint32(value[2:6], uint32(config.EnterpriseNumber))
+	copy(value[6:], payload)
+
+	return &ndp.RawOption{
+		Type:   uint8(config.Type),
+		Length: uint8(length),
+		Value:  value,
+	}
+}
+
+// selfTest builds and marshals the RA message of each interface, including the
+// ones of the aliases and the tailored ones for the solicitor overrides, once
+// to catch the problems that the per-field validation can't catch (e.g. the
+// combined size of the options). The size is checked against the MTU field of
+// the configuration or the IPv6 minimum MTU (1280) when it's not set. The
+// config must be validated beforehand.
+func selfTest(config *Config) error {
+	for _, c := range config.Interfaces {
+		// The options of the interface itself and the ones of each
+		// alias which may be activated at runtime
+		aliases := append([]string{""}, sortedKeys(c.Aliases)...)
+		for _, alias := range aliases {
+			ac := aliasedConfig(c, alias)
+			name := c.Name
+			if alias != "" {
+				name = fmt.Sprintf("%s (alias %s)", c.Name, alias)
+			}
+
+			if err := selfTestRA(ac, name); err != nil {
+				return err
+			}
+
+			// The RS replies tailored for the solicitors
+			for _, addr := range sortedKeys(c.SolicitorOverrides) {
+				oc := solicitorOverriddenConfig(ac, c.SolicitorOverrides[addr])
+				if err := selfTestRA(oc, fmt.Sprintf("%s (solicitor override %s)", name, addr)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// selfTestRA checks the RAs built from the interface configuration. The name
+// identifies the configuration in the error.
+func selfTestRA(c *InterfaceConfig, name string) error {
+	// The actual link-layer address is unknown at this point. Use a
+	// placeholder with the Ethernet address length.
+	devState := &deviceState{addr: make(net.HardwareAddr, 6)}
+
+	s := &advertiser{logger: slog.Default()}
+
+	// Check the largest RA which has both of the current and the
+	// withdrawn rotating prefixes
+	if c.PrefixRotation != nil {
+		c = rotatedConfig(c, 1, c.PrefixRotation.Prefixes[0])
+	}
+
+	// Check the largest RA which has all the prefixes regardless of the
+	// local addresses. The RS reply is the larger one.
+	c = solicitedOnlyConfig(c)
+	for _, prefix := range c.Prefixes {
+		prefix.RequireLocalAddress = false
+	}
+
+	// Check all RAs when the DNS options are split
+	for _, msg := range s.createRAMsgs(c, devState) {
+		b, err := ndp.MarshalMessage(msg)
+		if err != nil {
+			return fmt.Errorf("%w: interface %s: cannot marshal RA: %w", ErrSelfTest, name, err)
+		}
+
+		if size, mtu := ipv6.HeaderLen+len(b), linkMTU(c); size > mtu {
+			return fmt.Errorf("%w: interface %s: RA size %d exceeds the MTU %d", ErrSelfTest, name, size, mtu)
+		}
+	}
+
+	b, _ := ndp.MarshalMessage(s.createRAMsg(c, devState))
+	if c.PadToBytes > 0 && len(b) > (c.PadToBytes+7)/8*8 {
+		return fmt.Errorf("%w: interface %s: RA size %d exceeds the PadToBytes %d", ErrSelfTest, name, len(b), c.PadToBytes)
+	}
+
+	return nil
+}
+
+// sortedKeys returns the keys of the map in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
 func (s *advertiser) toNDPPreference(preference string) ndp.Preference {
 	switch preference {
 	case "low":
@@ -149,56 +1050,255 @@ func (s *advertiser) toNDPPreference(preference string) ndp.Preference {
 	}
 }
 
+// preferenceRank returns the rank of the router preference. The higher rank
+// means the higher preference.
+func preferenceRank(preference ndp.Preference) int {
+	switch preference {
+	case ndp.Low:
+		return 0
+	case ndp.High:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// isPreempted returns true when any of the unexpired foreign routers
+// advertises a higher preference than ours
+func isPreempted(routers map[netip.Addr]*foreignRouter, preference ndp.Preference, now time.Time) bool {
+	for _, router := range routers {
+		if router.expiry.After(now) && preferenceRank(router.preference) > preferenceRank(preference) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowSolicitedReply returns true when the source hasn't exceeded the limit
+// of the solicited RAs within the window and records the reply. The replies
+// older than the window are forgotten.
+func allowSolicitedReply(replies map[netip.Addr][]time.Time, from netip.Addr, limit int, window time.Duration, now time.Time) bool {
+	recent := slices.DeleteFunc(replies[from], func(t time.Time) bool {
+		return now.Sub(t) >= window
+	})
+	if len(recent) >= limit {
+		replies[from] = recent
+		return false
+	}
+	replies[from] = append(recent, now)
+	return true
+}
+
+// isOwnRA returns true when the RA is sent by ourselves
+func isOwnRA(ra *ndp.RouterAdvertisement, from netip.Addr, hwAddr net.HardwareAddr, devState *deviceState) bool {
+	if devState.v6LLAddrAssigned && from.WithZone("") == devState.v6LLAddr.WithZone("") {
+		return true
+	}
+	for _, option := range ra.Options {
+		if lla, ok := option.(*ndp.LinkLayerAddress); ok && lla.Direction == ndp.Source {
+			return len(hwAddr) > 0 && slices.Equal(lla.Addr, hwAddr)
+		}
+	}
+	return false
+}
+
 func (s *advertiser) reportRunning() {
+	s.reportState(Running, nil)
+}
+
+func (s *advertiser) reportReloading() {
+	s.reportState(Reloading, nil)
+}
+
+func (s *advertiser) reportFailing(err error) {
+	s.reportState(Failing, err)
+}
+
+func (s *advertiser) reportStopped(err error) {
+	s.reportState(Stopped, err)
+}
+
+// reportState updates the state and message of the interface status and
+// notifies the change if there's any
+func (s *advertiser) reportState(state string, err error) {
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+
+	s.ifaceStatusLock.Lock()
+	changed := s.ifaceStatus.State != state || s.ifaceStatus.Message != message
+	s.ifaceStatus.State = state
+	s.ifaceStatus.Message = message
+	s.ifaceStatusLock.Unlock()
+
+	// Notify without holding the lock since the subscriber may take the
+	// status
+	if changed && s.notifyStatus != nil {
+		s.notifyStatus()
+	}
+}
+
+func (s *advertiser) setPreempted(preempted bool) {
+	s.ifaceStatusLock.Lock()
+	changed := s.ifaceStatus.Preempted != preempted
+	s.ifaceStatus.Preempted = preempted
+	s.ifaceStatusLock.Unlock()
+
+	if changed && s.notifyStatus != nil {
+		s.notifyStatus()
+	}
+}
+
+// setDeviceWatcherError reports the failure of the device watcher. The nil
+// error means the watcher is restarted.
+func (s *advertiser) setDeviceWatcherError(err error) {
+	s.ifaceStatusLock.Lock()
+	if err != nil {
+		s.ifaceStatus.DeviceWatcherError = err.Error()
+	} else {
+		s.ifaceStatus.DeviceWatcherError = ""
+		s.ifaceStatus.DeviceWatcherRestarts++
+	}
+	s.ifaceStatusLock.Unlock()
+
+	if err != nil {
+		s.logger.Warn("Device watcher failed. Restarting.", "error", err.Error())
+	} else {
+		s.logger.Info("Device watcher restarted")
+	}
+
+	if s.notifyStatus != nil {
+		s.notifyStatus()
+	}
+}
+
+func (s *advertiser) setRSSilent(silent bool) {
+	s.ifaceStatusLock.Lock()
+	changed := s.ifaceStatus.RSSilent != silent
+	s.ifaceStatus.RSSilent = silent
+	s.ifaceStatusLock.Unlock()
+
+	if changed && s.notifyStatus != nil {
+		s.notifyStatus()
+	}
+}
+
+// handleMalformedRS handles the RS which cannot be parsed according to the
+// policy. It's never replied.
+func (s *advertiser) handleMalformedRS(from netip.Addr, err error) {
+	switch s.malformedRSPolicy {
+	case MalformedRSDrop:
+		return
+	case MalformedRSLog:
+		s.logger.Warn("Dropped malformed RS", "from", from, "error", err.Error())
+	}
+
 	s.ifaceStatusLock.Lock()
 	defer s.ifaceStatusLock.Unlock()
-	s.ifaceStatus.State = Running
-	s.ifaceStatus.Message = ""
+	s.ifaceStatus.RxMalformedRS++
 }
 
-func (s *advertiser) reportReloading() {
+func (s *advertiser) incRxRateLimitedRS() {
 	s.ifaceStatusLock.Lock()
 	defer s.ifaceStatusLock.Unlock()
-	s.ifaceStatus.State = Reloading
-	s.ifaceStatus.Message = ""
+	s.ifaceStatus.RxRateLimitedRS++
 }
 
-func (s *advertiser) reportFailing(err error) {
+func (s *advertiser) incPrefixChanges() {
+	s.ifaceStatusLock.Lock()
+	defer s.ifaceStatusLock.Unlock()
+	s.ifaceStatus.PrefixChanges++
+}
+
+func (s *advertiser) incTxStat(solicited bool) {
+	s.ifaceStatusLock.Lock()
+	defer s.ifaceStatusLock.Unlock()
+	if solicited {
+		s.ifaceStatus.TxSolicitedRA++
+	} else {
+		s.ifaceStatus.TxUnsolicitedRA++
+	}
+}
+
+func (s *advertiser) incTxRetries() {
+	s.ifaceStatusLock.Lock()
+	defer s.ifaceStatusLock.Unlock()
+	s.ifaceStatus.TxRetries++
+}
+
+func (s *advertiser) incTxFailures() {
+	s.ifaceStatusLock.Lock()
+	defer s.ifaceStatusLock.Unlock()
+	s.ifaceStatus.TxFailures++
+}
+
+func (s *advertiser) setLastUpdate() {
+	s.ifaceStatusLock.Lock()
+	defer s.ifaceStatusLock.Unlock()
+	s.ifaceStatus.LastUpdate = time.Now().Unix()
+}
+
+func (s *advertiser) setLinkLocal(devState *deviceState) {
+	s.ifaceStatusLock.Lock()
+	defer s.ifaceStatusLock.Unlock()
+	s.ifaceStatus.HasLinkLocal = devState.v6LLAddrAssigned
+	s.ifaceStatus.LinkLocalAddress = ""
+	if devState.v6LLAddrAssigned && devState.v6LLAddr.IsValid() {
+		s.ifaceStatus.LinkLocalAddress = devState.v6LLAddr.String()
+	}
+}
+
+func (s *advertiser) setActiveAlias(alias string) {
+	s.ifaceStatusLock.Lock()
+	defer s.ifaceStatusLock.Unlock()
+	s.ifaceStatus.ActiveAlias = alias
+}
+
+func (s *advertiser) setAppliedGeneration(generation int) {
 	s.ifaceStatusLock.Lock()
 	defer s.ifaceStatusLock.Unlock()
-	s.ifaceStatus.State = Failing
-	if err == nil {
-		s.ifaceStatus.Message = ""
-	} else {
-		s.ifaceStatus.Message = err.Error()
-	}
+	s.ifaceStatus.AppliedGeneration = generation
 }
 
-func (s *advertiser) reportStopped(err error) {
+func (s *advertiser) setSchedule(start time.Time, interval time.Duration) {
 	s.ifaceStatusLock.Lock()
 	defer s.ifaceStatusLock.Unlock()
-	s.ifaceStatus.State = Stopped
-	if err == nil {
-		s.ifaceStatus.Message = ""
-	} else {
-		s.ifaceStatus.Message = err.Error()
-	}
+	s.scheduleStart = start
+	s.scheduleInterval = interval
 }
 
-func (s *advertiser) incTxStat(solicited bool) {
+// setRateLimiterState updates the state of the rate limiting of the multicast
+// RAs. Zero pendingAt means no reply is pending.
+func (s *advertiser) setRateLimiterState(lastMulticast time.Time, minDelay time.Duration, pendingAt time.Time) {
 	s.ifaceStatusLock.Lock()
 	defer s.ifaceStatusLock.Unlock()
-	if solicited {
-		s.ifaceStatus.TxSolicitedRA++
-	} else {
-		s.ifaceStatus.TxUnsolicitedRA++
+	s.rlState = RLState{LastMulticastRA: lastMulticast, PendingReplyAt: pendingAt}
+	if minDelay > 0 && !lastMulticast.IsZero() {
+		s.rlState.NextMulticastAllowed = lastMulticast.Add(minDelay)
 	}
 }
 
-func (s *advertiser) setLastUpdate() {
-	s.ifaceStatusLock.Lock()
-	defer s.ifaceStatusLock.Unlock()
-	s.ifaceStatus.LastUpdate = time.Now().Unix()
+func (s *advertiser) rateLimiterState() *RLState {
+	s.ifaceStatusLock.RLock()
+	defer s.ifaceStatusLock.RUnlock()
+	state := s.rlState
+	return &state
+}
+
+func (s *advertiser) timeToNextRA() (time.Duration, error) {
+	if s.isPaused() {
+		return 0, fmt.Errorf("advertisement is paused")
+	}
+
+	s.ifaceStatusLock.RLock()
+	defer s.ifaceStatusLock.RUnlock()
+
+	if s.scheduleStart.IsZero() {
+		return 0, fmt.Errorf("no RA is scheduled")
+	}
+
+	return s.scheduleInterval - s.clock.Now().Sub(s.scheduleStart)%s.scheduleInterval, nil
 }
 
 func (s *advertiser) run(ctx context.Context) {
@@ -208,11 +1308,28 @@ func (s *advertiser) run(ctx context.Context) {
 	// The current device state
 	devState := deviceState{}
 
-	// Set a timestamp for the first "update"
+	// Ticker of the unsolicited RA. Recreated on each reload. The previous
+	// one must be stopped, otherwise it keeps firing in the background.
+	var ticker *time.Ticker
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	// Set a timestamp and generation for the first "update"
 	s.setLastUpdate()
+	s.setAppliedGeneration(s.initialGeneration)
 
-	// Watch the device state
-	devCh, err := s.deviceWatcher.watch(ctx, config.Name)
+	// Poll the prefix source in the background until the advertiser
+	// stops
+	pollCtx, stopPoll := context.WithCancel(ctx)
+	defer stopPoll()
+	go s.pollPrefixSource(pollCtx)
+	go s.watchUnderlyingMTUs(pollCtx)
+
+	// Watch the device state. The watcher is restarted when it fails.
+	devCh, err := watchWithRestart(ctx, s.deviceWatcher, config.Name, deviceOpts{index: config.Index, netnsPath: config.NetnsPath}, s.setDeviceWatcherError)
 	if err != nil {
 		s.reportStopped(err)
 		return
@@ -228,17 +1345,34 @@ waitDevice:
 		case dev := <-devCh:
 			// Update the device state
 			devState = dev
+			s.setLinkLocal(&devState)
 
 			// If the device is up, mac and link-local address are
 			// assigned, we can proceed with the socket creation
 			if dev.isUp || len(dev.addr) > 0 || dev.v6LLAddrAssigned {
 				break waitDevice
 			}
+		case m := <-s.reloadCh:
+			// Nothing is advertised yet. Just take the new
+			// configuration, so that the reload doesn't wait for
+			// the device.
+			s.setAppliedGeneration(m.generation)
+			config = m.config
+			s.logHandler.setLevel(config.LogLevel)
+			s.setLastUpdate()
+		case <-s.stopCh:
+			s.reportStopped(nil)
+			return
 		}
 	}
 
+createSocket:
 	// Create the socket
-	sock, err := s.socketCtor(config.Name)
+	sockOpts := socketOpts{index: config.Index, vrf: config.VRF, netnsPath: config.NetnsPath}
+	if config.SourceAddress != "" {
+		sockOpts.srcAddr = netip.MustParseAddr(config.SourceAddress)
+	}
+	rawSock, err := s.socketCtor(config.Name, sockOpts)
 	if err != nil {
 		// These are the unrecoverable errors we're aware of now.
 		if errors.Is(err, unix.EPERM) || errors.Is(err, unix.EINVAL) {
@@ -249,13 +1383,36 @@ waitDevice:
 		s.reportFailing(err)
 		goto waitDevice
 	}
+	s.checkAcceptRA(config)
+
+	if sockOpts.srcAddr.IsValid() && !sockOpts.srcAddr.IsLinkLocalUnicast() {
+		s.logger.Warn("Sending RAs from the non-link-local source address. The hosts compliant to RFC4861 discard them.", "source", sockOpts.srcAddr.String())
+	}
+
+	reserved := &reservedBitsSocket{socket: rawSock}
+	retrier := &retrySocket{
+		socket: reserved,
+		onRetry: func(err error) {
+			s.logger.Debug("Retrying to send RA", "error", err.Error())
+			s.incTxRetries()
+		},
+		onFailure: func(error) {
+			s.incTxFailures()
+		},
+	}
+	sock := &shadowSocket{socket: retrier}
 
-	// Launch the RS receiver
+	// Launch the RS and foreign RA receiver
 	rsCh := make(chan *rsMsg)
+	raCh := make(chan *raMsg)
 	receiverCtx, cancelReceiver := context.WithCancel(ctx)
 	go func() {
 		for {
-			rs, addr, err := sock.recvRS(receiverCtx)
+			m, addr, err := sock.recv(receiverCtx)
+			if errors.Is(err, errMalformedRS) {
+				s.handleMalformedRS(addr, err)
+				continue
+			}
 			if err != nil {
 				if receiverCtx.Err() != nil {
 					return
@@ -263,73 +1420,759 @@ waitDevice:
 				s.reportFailing(err)
 				continue
 			}
-			rsCh <- &rsMsg{rs: rs, from: addr}
+			switch m := m.(type) {
+			case *ndp.RouterSolicitation:
+				select {
+				case rsCh <- &rsMsg{rs: m, from: addr}:
+				case <-receiverCtx.Done():
+					return
+				}
+			case *ndp.RouterAdvertisement:
+				select {
+				case raCh <- &raMsg{ra: m, from: addr}:
+				case <-receiverCtx.Done():
+					return
+				}
+			}
 		}
 	}()
 
 	s.reportRunning()
 
+	// Set while the device is down within the flap grace period
+	var graceCh <-chan time.Time
+
+	// Set when the RA must be sent immediately after reload
+	sendNow := false
+
+	// Number of the unsolicited RAs left in the initial burst
+	burstLeft := config.InitialRACount
+
+	// Number of the unsolicited RAs sent so far for AdvertiseEveryN
+	unsolicitedCount := 0
+
+	// Prefixes removed by the reload which are advertised with zero
+	// lifetimes for the next WithdrawalRepeat unsolicited RAs
+	var removedPrefixes []*removedPrefix
+
+	// Prefixes advertised last time. Nil until the first RA message is
+	// built.
+	var lastPrefixes []AdvertisedPrefix
+
+	// Reports the change of the advertised prefixes
+	checkPrefixChange := func(c *InterfaceConfig) {
+		prefixes := advertisedPrefixes(c)
+		if lastPrefixes == nil || slices.Equal(lastPrefixes, prefixes) {
+			lastPrefixes = prefixes
+			return
+		}
+		s.logger.Info("Advertised prefixes changed", "before", lastPrefixes, "after", prefixes)
+		s.incPrefixChanges()
+		if s.prefixChangeHandler != nil {
+			s.prefixChangeHandler(PrefixChangeEvent{
+				Interface: config.Name,
+				Before:    lastPrefixes,
+				After:     prefixes,
+			})
+		}
+		lastPrefixes = prefixes
+	}
+
+	// Solicited RAs to repeat. The head of the queue is sent each time
+	// repeatCh fires and goes back to the tail if it has repeats left.
+	var repeats []*solicitedRepeat
+	var repeatCh <-chan time.Time
+
+	// The multicast reply to the RSes from the unspecified address delayed
+	// by MinDelayBetweenRAsMilliseconds. Any multicast RA sent meanwhile
+	// answers them, so it cancels the delayed reply.
+	var delayedReplyCh <-chan time.Time
+	var lastMulticast time.Time
+
+	minDelayBetweenRAs := func() time.Duration {
+		return time.Duration(config.MinDelayBetweenRAsMilliseconds) * time.Millisecond
+	}
+
+	// The rotating prefix of the RAs being built, the one of the last
+	// multicast RA, and the one withdrawn by the rotation. The slots
+	// passed without sending any multicast RA (e.g. while paused) are
+	// skipped, so the prefix to withdraw is the advertised one rather
+	// than the one of the previous slot.
+	var rotationPrefix, advertisedRotationPrefix, withdrawnRotationPrefix string
+
+	// Records the multicast RA sent
+	sentMulticast := func() {
+		advertisedRotationPrefix = rotationPrefix
+		lastMulticast = s.clock.Now()
+		delayedReplyCh = nil
+		s.setRateLimiterState(lastMulticast, minDelayBetweenRAs(), time.Time{})
+	}
+
+	// The other default routers on the link
+	foreignRouters := map[netip.Addr]*foreignRouter{}
+
+	// Last time the RS is received. The RS silence is measured from the
+	// socket creation. rsSilenceCh fires when the window passes since
+	// then.
+	lastRS := s.clock.Now()
+	var rsSilenceCh <-chan time.Time
+
+	// Arms the RS silence watchdog for the rest of the window
+	armRSSilence := func() {
+		rsSilenceCh = nil
+		if config.RSSilenceWindowSeconds > 0 && !s.status().RSSilent {
+			window := time.Duration(config.RSSilenceWindowSeconds) * time.Second
+			rsSilenceCh = s.clock.After(window - s.clock.Now().Sub(lastRS))
+		}
+	}
+
+	// Times of the recent solicited RAs per source address for the rate
+	// limiting
+	solicitedReplies := map[netip.Addr][]time.Time{}
+
 reload:
 	for {
+		// Fires when the poisoning period ends
+		var poisonEndCh <-chan time.Time
+
+		// Fires when the staggered unsolicited RA is due
+		var staggerCh <-chan time.Time
+
+		// Don't transmit anything in the shadow mode. Everything
+		// else including the status counters works as usual.
+		if sock.shadow != config.ShadowMode {
+			s.logger.Info("Shadow mode changed", "enabled", config.ShadowMode)
+			sock.shadow = config.ShadowMode
+		}
+
+		retrier.retries = *config.SendRetries
+		reserved.reserved = uint8(config.ReservedBits)
+
+		if config.RSSilenceWindowSeconds == 0 {
+			s.setRSSilent(false)
+		}
+		armRSSilence()
+
+		// Fires when the prefix rotates
+		var rotationCh <-chan time.Time
+
+		rotationSlot := s.rotationSlot(config)
+		rotationPrefix = rotatingPrefix(config, rotationSlot)
+		if advertisedRotationPrefix != "" && advertisedRotationPrefix != rotationPrefix {
+			withdrawnRotationPrefix = advertisedRotationPrefix
+		}
+		if rotationSlot >= 0 {
+			rotationCh = s.clock.After(s.untilNextRotation(config, rotationSlot))
+		}
+
+		poisoned := false
+		if until := s.getPoisonUntil(); time.Now().Before(until) {
+			poisoned = true
+			poisonEndCh = time.After(time.Until(until))
+		}
+
+		dnsHealthy := s.isDNSHealthy(config.Name)
+
+		// Fires when the DNS health should be checked again
+		var dnsHealthCheckCh <-chan time.Time
+		if s.dnsHealthCheck != nil {
+			dnsHealthCheckCh = s.clock.After(dnsHealthCheckInterval)
+		}
+
+		// Falls back to the interface configuration itself when the
+		// alias is removed by the reload
+		alias := s.getAlias()
+		if _, ok := config.Aliases[alias]; !ok {
+			alias = ""
+		}
+		s.setActiveAlias(alias)
+
+		s.setMTUInterfaces(config.MTUFromInterfaces, config.NetnsPath)
+		underlyingMTU := s.getUnderlyingMTU()
+
+		s.setPrefixSource(config.PrefixSource)
+		sourced, withdrawnSourced := s.getSourcedPrefixes()
+		sourcedBaseConfig := sourcedConfig(aliasedConfig(config, alias), sourced, withdrawnSourced)
+		baseConfig := removedPrefixesConfig(sourcedBaseConfig, removedPrefixes)
+		s.updatePrefixLoadedAt(baseConfig)
+
+		// Applies the transformations to the base configuration of the
 		// RA message
-		msg := s.createRAMsg(config, &devState)
+		transform := func(c *InterfaceConfig) *InterfaceConfig {
+			c = underlyingMTUConfig(c, underlyingMTU)
+			c = rotatedConfig(c, rotationSlot, withdrawnRotationPrefix)
+			if poisoned {
+				c = poisonedConfig(c)
+			}
+			if !dnsHealthy {
+				c = dnsWithdrawnConfig(c)
+			}
+			return c
+		}
+
+		// RA message
+		msgConfig := transform(baseConfig)
+		checkPrefixChange(msgConfig)
+		var (
+			msg            *ndp.RouterAdvertisement
+			solicitedMsg   *ndp.RouterAdvertisement
+			overriddenMsgs map[netip.Addr]*ndp.RouterAdvertisement
+
+			// The split DNS options that don't fit into the RA.
+			// Sent only in reply to RS.
+			overflowMsgs           []*ndp.RouterAdvertisement
+			overriddenOverflowMsgs map[netip.Addr][]*ndp.RouterAdvertisement
+		)
+
+		// Builds the RA messages. The decrementing lifetimes need
+		// rebuilding on each send.
+		decrementing := hasDecrementingLifetimes(baseConfig)
+		buildMsgs := func() {
+			msgs := s.createRAMsgs(msgConfig, &devState)
+			msg, overflowMsgs = msgs[0], msgs[1:]
+			solicitedMsg = s.createSolicitedRAMsg(msgConfig, &devState, msg)
+
+			// Tailored RS replies for the specific solicitors
+			overriddenMsgs = map[netip.Addr]*ndp.RouterAdvertisement{}
+			overriddenOverflowMsgs = map[netip.Addr][]*ndp.RouterAdvertisement{}
+			for addr, override := range config.SolicitorOverrides {
+				// At this point, we should have validated the
+				// configuration. If we haven't, it's a bug.
+				c := transform(solicitorOverriddenConfig(baseConfig, override))
+				msgs := s.createRAMsgs(c, &devState)
+				overriddenMsgs[netip.MustParseAddr(addr)] = s.createSolicitedRAMsg(c, &devState, msgs[0])
+				overriddenOverflowMsgs[netip.MustParseAddr(addr)] = msgs[1:]
+			}
+		}
+		buildMsgs()
+
+		// The device state the messages are built with. The device
+		// updates are compared with it instead of the previous
+		// update, since the updates received while the device is down
+		// within the flap grace period don't rebuild the messages.
+		msgDevState := devState
+
+		// Our preference may have changed
+		s.setPreempted(isPreempted(foreignRouters, msg.RouterSelectionPreference, time.Now()))
+
+		// Returns the next unsolicited RA. The prefixes with
+		// AdvertiseEveryN are omitted unless the count matches.
+		unsolicitedMsg := func() *ndp.RouterAdvertisement {
+			if decrementing {
+				buildMsgs()
+			}
+			if c := everyNConfig(msgConfig, unsolicitedCount); c != msgConfig {
+				return s.createRAMsg(c, &devState)
+			}
+			return msg
+		}
+
+		// Counts down the repeats of the removed prefixes on each
+		// unsolicited RA. The ones advertised enough are dropped.
+		countRemovedPrefixes := func() {
+			if len(removedPrefixes) == 0 {
+				return
+			}
+			left := []*removedPrefix{}
+			for _, r := range removedPrefixes {
+				if r.left--; r.left > 0 {
+					left = append(left, r)
+				}
+			}
+			if len(left) != len(removedPrefixes) {
+				removedPrefixes = left
+				baseConfig = removedPrefixesConfig(sourcedBaseConfig, removedPrefixes)
+				msgConfig = transform(baseConfig)
+				checkPrefixChange(msgConfig)
+				buildMsgs()
+			}
+		}
+
+		// Don't send anything while paused
+		paused := s.isPaused()
+
+		if sendNow && graceCh == nil && !paused {
+			err := sock.sendRA(ctx, netip.IPv6LinkLocalAllNodes(), unsolicitedMsg())
+			if err != nil {
+				s.reportFailing(err)
+			} else {
+				unsolicitedCount++
+				countRemovedPrefixes()
+				sentMulticast()
+				s.incTxStat(false)
+				s.reportRunning()
+			}
+		}
+		sendNow = false
+
+		// Returns the next interval of the unsolicited RA. The
+		// interval is shortened during the initial burst.
+		nextInterval := func() time.Duration {
+			interval := jitteredInterval(s.effectiveInterval(config), config.JitterPercent, s.rng)
+			if burstLeft > 0 {
+				interval = min(interval, max(time.Duration(config.InitialRAIntervalMilliseconds)*time.Millisecond, s.minInterval))
+			}
+			return interval
+		}
 
 		// For unsolicited RA
-		ticker := time.NewTicker(time.Duration(config.RAIntervalMilliseconds) * time.Millisecond)
+		interval := nextInterval()
+		if ticker != nil {
+			ticker.Stop()
+		}
+		ticker = time.NewTicker(interval)
+		tickerStart := s.clock.Now()
+
+		// Returns the delay of the unsolicited RA after the tick (see
+		// WithSendStagger)
+		staggerDelay := func() time.Duration {
+			if delay := s.getSendDelay(); delay > 0 && delay < interval {
+				return delay
+			}
+			return 0
+		}
+
+		// Publishes the schedule of the unsolicited RAs. They are
+		// sent the stagger delay after the ticks.
+		schedule := func() {
+			s.setSchedule(tickerStart.Add(staggerDelay()), interval)
+		}
+
+		if graceCh == nil {
+			schedule()
+		}
+
+		// Sends the unsolicited RA on the tick
+		sendUnsolicited := func() {
+			err := sock.sendRA(ctx, netip.IPv6LinkLocalAllNodes(), unsolicitedMsg())
+			if err != nil {
+				s.logger.Debug("Failed to send unsolicited RA", "error", err.Error())
+				s.reportFailing(err)
+				return
+			}
+			unsolicitedCount++
+			countRemovedPrefixes()
+			sentMulticast()
+			s.logger.Debug("Sent unsolicited RA")
+			s.incTxStat(false)
+			s.reportRunning()
+
+			// The initial burst is over. Back to the regular
+			// interval.
+			if burstLeft > 0 {
+				if burstLeft--; burstLeft == 0 {
+					interval = nextInterval()
+					ticker.Reset(interval)
+					tickerStart = s.clock.Now()
+					schedule()
+				}
+			}
+		}
 
 		for {
 			select {
 			case rs := <-rsCh:
-				// Reply to RS
-				//
-				// TODO: Rate limit this to mitigate RS flooding attack
-				err := sock.sendRA(ctx, rs.from, msg)
+				s.logger.Debug("Received RS", "from", rs.from)
+
+				lastRS = s.clock.Now()
+				if s.status().RSSilent {
+					s.logger.Info("Received RS after the silence", "from", rs.from)
+					s.setRSSilent(false)
+				}
+				armRSSilence()
+
+				// Let the handler decide whether to reply
+				if s.rsHandler != nil && !s.rsHandler(config.Name, rs.rs, rs.from) {
+					continue
+				}
+
+				// The device is down or paused. Don't reply.
+				if graceCh != nil || paused {
+					continue
+				}
+
+				// Rate limit the replies per source to
+				// mitigate the RS flooding. The unspecified
+				// source is shared by the hosts without
+				// address, so it's not limited.
+				if config.SolicitedRateLimit > 0 && !rs.from.IsUnspecified() {
+					window := time.Duration(config.SolicitedRateLimitWindowSeconds) * time.Second
+					if !allowSolicitedReply(solicitedReplies, rs.from.WithZone(""), config.SolicitedRateLimit, window, s.clock.Now()) {
+						s.logger.Debug("Rate limited RS", "from", rs.from)
+						s.incRxRateLimitedRS()
+						continue
+					}
+				}
+
+				if decrementing {
+					buildMsgs()
+				}
+
+				// Don't send the multicast replies more often
+				// than MinDelayBetweenRAsMilliseconds (RFC4861
+				// Section 6.2.6). The RSes within the delay
+				// share the delayed reply.
+				if minDelay := minDelayBetweenRAs(); rs.from.IsUnspecified() && minDelay > 0 {
+					if now, next := s.clock.Now(), lastMulticast.Add(minDelay); now.Before(next) {
+						if delayedReplyCh == nil {
+							delayedReplyCh = s.clock.After(next.Sub(now))
+							s.setRateLimiterState(lastMulticast, minDelay, next)
+						}
+						s.logger.Debug("Delayed multicast RS reply", "until", next)
+						continue
+					}
+				}
+
+				// Reply to RS. If the source address is
+				// unspecified, reply with the multicast RA
+				// which has the same content as the
+				// unsolicited one (RFC4861 Section 6.2.6).
+				to, replyMsg, overflow := rs.from, solicitedMsg, overflowMsgs
+				if rs.from.IsUnspecified() {
+					to, replyMsg = netip.IPv6LinkLocalAllNodes(), msg
+				} else if overriddenMsg, ok := overriddenMsgs[rs.from.WithZone("")]; ok {
+					replyMsg, overflow = overriddenMsg, overriddenOverflowMsgs[rs.from.WithZone("")]
+				}
+				err := sock.sendRA(ctx, to, replyMsg)
 				if err != nil {
+					s.logger.Debug("Failed to send solicited RA", "to", to, "error", err.Error())
 					s.reportFailing(err)
 					continue
 				}
+				if rs.from.IsUnspecified() {
+					sentMulticast()
+				}
+				s.logger.Debug("Sent solicited RA", "to", to)
 				s.incTxStat(true)
 				s.reportRunning()
-			case <-ticker.C:
-				// Send unsolicited RA
+
+				// Follow up with the rest of the split DNS
+				// options. They share the header of the reply.
+				for _, o := range overflow {
+					extraMsg := *replyMsg
+					extraMsg.Options = o.Options
+					if err := sock.sendRA(ctx, to, &extraMsg); err != nil {
+						s.logger.Debug("Failed to send split DNS options", "to", to, "error", err.Error())
+						s.reportFailing(err)
+						break
+					}
+					s.incTxStat(true)
+				}
+				if config.SolicitedRARepeat > 1 {
+					repeats = append(repeats, &solicitedRepeat{to: to, msg: replyMsg, left: config.SolicitedRARepeat - 1})
+					if repeatCh == nil {
+						repeatCh = time.After(solicitedRARepeatInterval)
+					}
+				}
+			case <-delayedReplyCh:
+				delayedReplyCh = nil
+
+				// The device went down or paused while
+				// waiting. Don't reply.
+				if graceCh != nil || paused {
+					s.setRateLimiterState(lastMulticast, minDelayBetweenRAs(), time.Time{})
+					continue
+				}
+
+				if decrementing {
+					buildMsgs()
+				}
+
 				err := sock.sendRA(ctx, netip.IPv6LinkLocalAllNodes(), msg)
 				if err != nil {
+					s.logger.Debug("Failed to send delayed multicast RS reply", "error", err.Error())
+					s.setRateLimiterState(lastMulticast, minDelayBetweenRAs(), time.Time{})
 					s.reportFailing(err)
 					continue
 				}
-				s.incTxStat(false)
+				sentMulticast()
+				s.logger.Debug("Sent delayed multicast RS reply")
+				s.incTxStat(true)
 				s.reportRunning()
-			case newConfig := <-s.reloadCh:
-				if reflect.DeepEqual(config, newConfig) {
+
+				for _, o := range overflowMsgs {
+					extraMsg := *msg
+					extraMsg.Options = o.Options
+					if err := sock.sendRA(ctx, netip.IPv6LinkLocalAllNodes(), &extraMsg); err != nil {
+						s.logger.Debug("Failed to send split DNS options", "error", err.Error())
+						s.reportFailing(err)
+						break
+					}
+					s.incTxStat(true)
+				}
+			case ra := <-raCh:
+				// Our own multicast RA may be looped back
+				if isOwnRA(ra.ra, ra.from, sock.hardwareAddr(), &devState) {
+					continue
+				}
+				from := ra.from.WithZone("")
+				s.logger.Debug("Received foreign RA", "from", from, "preference", ra.ra.RouterSelectionPreference)
+
+				// The router with zero lifetime is not a
+				// default router
+				if ra.ra.RouterLifetime == 0 {
+					delete(foreignRouters, from)
+				} else {
+					foreignRouters[from] = &foreignRouter{
+						preference: ra.ra.RouterSelectionPreference,
+						expiry:     time.Now().Add(ra.ra.RouterLifetime),
+					}
+				}
+
+				preempted := isPreempted(foreignRouters, msg.RouterSelectionPreference, time.Now())
+				if preempted && !s.status().Preempted {
+					s.logger.Warn("Preempted by higher-preference router", "router", from)
+				}
+				s.setPreempted(preempted)
+			case <-repeatCh:
+				repeat := repeats[0]
+				repeats = repeats[1:]
+				repeatCh = nil
+
+				// Don't repeat while the device is down or paused
+				if graceCh == nil && !paused {
+					err := sock.sendRA(ctx, repeat.to, repeat.msg)
+					if err != nil {
+						s.logger.Debug("Failed to repeat solicited RA", "to", repeat.to, "error", err.Error())
+						s.reportFailing(err)
+					} else {
+						s.logger.Debug("Repeated solicited RA", "to", repeat.to)
+						s.incTxStat(true)
+						s.reportRunning()
+					}
+				}
+
+				if repeat.left--; repeat.left > 0 {
+					repeats = append(repeats, repeat)
+				}
+				if len(repeats) > 0 {
+					repeatCh = time.After(solicitedRARepeatInterval)
+				}
+			case <-ticker.C:
+				// Randomize the next interval
+				if config.JitterPercent > 0 {
+					interval = nextInterval()
+					ticker.Reset(interval)
+					tickerStart = s.clock.Now()
+					if graceCh == nil {
+						schedule()
+					}
+				}
+
+				// Forget the foreign routers whose lifetime
+				// has expired
+				for addr, router := range foreignRouters {
+					if !router.expiry.After(time.Now()) {
+						delete(foreignRouters, addr)
+					}
+				}
+				s.setPreempted(isPreempted(foreignRouters, msg.RouterSelectionPreference, time.Now()))
+
+				// Forget the sources which haven't solicited
+				// within the rate limit window
+				window := time.Duration(config.SolicitedRateLimitWindowSeconds) * time.Second
+				for from, times := range solicitedReplies {
+					if len(times) == 0 || s.clock.Now().Sub(times[len(times)-1]) >= window {
+						delete(solicitedReplies, from)
+					}
+				}
+
+				// The device is down or paused. Don't send.
+				if graceCh != nil || paused {
+					continue
+				}
+
+				// Spread the sends of the interfaces over
+				// the window (see WithSendStagger)
+				if delay := staggerDelay(); delay > 0 {
+					staggerCh = time.After(delay)
+					// The delay may have been changed
+					// since the schedule was published
+					schedule()
+					continue
+				}
+
+				sendUnsolicited()
+			case <-rsSilenceCh:
+				// No RS is received within the window. This is
+				// purely diagnostic and doesn't affect the
+				// advertisement.
+				rsSilenceCh = nil
+				s.logger.Warn("No RS received within the window", "window", time.Duration(config.RSSilenceWindowSeconds)*time.Second)
+				s.setRSSilent(true)
+			case <-dnsHealthCheckCh:
+				// DNS health has changed. Rebuild the RA and
+				// send it immediately. If the device is down
+				// or paused, it's sent when it's back.
+				if s.isDNSHealthy(config.Name) != dnsHealthy {
+					s.logger.Info("DNS health changed", "healthy", !dnsHealthy)
+					sendNow = true
+					continue reload
+				}
+				dnsHealthCheckCh = s.clock.After(dnsHealthCheckInterval)
+			case <-rotationCh:
+				// The rotating prefix has changed. Rebuild
+				// the RA and send it immediately. If the
+				// device is down or paused, it's sent when
+				// it's back.
+				s.logger.Info("Rotating prefix")
+				sendNow = true
+				continue reload
+			case <-staggerCh:
+				staggerCh = nil
+
+				// The device went down or paused while
+				// waiting. Don't send.
+				if graceCh != nil || paused {
+					continue
+				}
+
+				sendUnsolicited()
+			case m := <-s.reloadCh:
+				// Even if the configuration is the same, the
+				// new generation is applied at this point.
+				s.setAppliedGeneration(m.generation)
+				if reflect.DeepEqual(config, m.config) {
 					s.logger.Info("No configuration change. Skip reloading.")
 					continue
 				}
-				config = newConfig
+				socketChanged := config.VRF != m.config.VRF || config.SourceAddress != m.config.SourceAddress
+				removedPrefixes = updateRemovedPrefixes(removedPrefixes, config, m.config)
+				config = m.config
+				s.logHandler.setLevel(config.LogLevel)
+				s.logger.Debug("Reloading configuration", "generation", m.generation)
 				s.reportReloading()
 				s.setLastUpdate()
+				// The advertised information may have changed.
+				// Restart the initial burst (RFC4861 Section
+				// 6.2.4).
+				burstLeft = config.InitialRACount
+				// The socket is bound to the VRF and the
+				// source address. Recreate it.
+				if socketChanged {
+					s.setSchedule(time.Time{}, 0)
+					cancelReceiver()
+					sock.close()
+					graceCh = nil
+					goto createSocket
+				}
 				continue reload
 			case dev := <-devCh:
-				// Save the old address for comparison
-				oldAddr := devState.addr
-
 				// Update the device state
 				devState = dev
+				s.setLinkLocal(&devState)
 
 				// Device is stopped. Stop the advertisement
-				// and wait for the device to be up again.
+				// and wait for the device to be up again. If
+				// the flap grace is configured, keep the
+				// socket until the grace period expires.
 				if !devState.isUp {
-					cancelReceiver()
 					s.reportFailing(fmt.Errorf("device is down"))
-					goto waitDevice
+					s.setSchedule(time.Time{}, 0)
+					if s.flapGrace == 0 {
+						cancelReceiver()
+						s.setPreempted(false)
+						goto waitDevice
+					}
+					if graceCh == nil {
+						graceCh = time.After(s.flapGrace)
+					}
+					continue
+				}
+
+				// Device is back within the grace period
+				if graceCh != nil {
+					graceCh = nil
+					schedule()
+					s.reportRunning()
 				}
 
 				// Device address has changed. We need to
 				// change the Link Layer Address option in the
 				// RA message. Reload internally.
-				if !slices.Equal(oldAddr, dev.addr) {
+				if !slices.Equal(msgDevState.addr, dev.addr) {
+					s.reportReloading()
+					continue reload
+				}
+
+				// Global addresses have changed. The prefixes
+				// requiring the local address may appear or
+				// disappear. Reload internally.
+				if !slices.Equal(msgDevState.v6GlobalAddrs, dev.v6GlobalAddrs) {
 					s.reportReloading()
 					continue reload
 				}
+			case <-s.poisonCh:
+				// Poisoning is requested. Advertise the
+				// poisoned RA immediately.
+				s.logger.Warn("Advertising zero lifetimes", "until", s.getPoisonUntil())
+				sendNow = true
+				continue reload
+			case <-s.pauseCh:
+				if s.isPaused() == paused {
+					continue
+				}
+				if !paused {
+					// Send the final RA with zero router
+					// lifetime before pausing
+					s.logger.Info("Pausing advertisement")
+					paused = true
+					if graceCh != nil {
+						continue
+					}
+					err := sock.sendRA(ctx, netip.IPv6LinkLocalAllNodes(), s.createRAMsg(withdrawalConfig(msgConfig), &devState))
+					if err != nil {
+						s.logger.Debug("Failed to send withdrawal RA", "error", err.Error())
+						s.reportFailing(err)
+						continue
+					}
+					s.incTxStat(false)
+					continue
+				}
+				// Resumed. Advertise immediately and restart
+				// the initial burst.
+				s.logger.Info("Resuming advertisement")
+				burstLeft = config.InitialRACount
+				sendNow = true
+				continue reload
+			case <-s.aliasCh:
+				if s.getAlias() == alias {
+					continue
+				}
+				// Alias is switched. Advertise the new
+				// options immediately.
+				s.logger.Info("Activating alias", "alias", s.getAlias())
+				sendNow = true
+				continue reload
+			case <-s.sourcedPrefixesCh:
+				// Advertise the new prefixes from the source
+				// immediately
+				if cur, withdrawn := s.getSourcedPrefixes(); slices.Equal(cur, sourced) && slices.Equal(withdrawn, withdrawnSourced) {
+					continue
+				}
+				sendNow = true
+				continue reload
+			case <-s.underlyingMTUCh:
+				// Advertise the new MTU immediately
+				if s.getUnderlyingMTU() == underlyingMTU {
+					continue
+				}
+				s.logger.Info("MTU of the underlying interfaces changed", "mtu", s.getUnderlyingMTU())
+				sendNow = true
+				continue reload
+			case <-poisonEndCh:
+				// Poisoning is over. Advertise the normal RA
+				// immediately.
+				s.logger.Info("Stopped advertising zero lifetimes")
+				sendNow = true
+				continue reload
+			case <-graceCh:
+				// Device didn't come back within the grace
+				// period. Wait for the device to be up again.
+				graceCh = nil
+				cancelReceiver()
+				s.setPreempted(false)
+				goto waitDevice
 			case <-ctx.Done():
 				s.reportStopped(ctx.Err())
 				break reload
@@ -340,6 +2183,9 @@ reload:
 		}
 	}
 
+	s.setSchedule(time.Time{}, 0)
+	s.setPreempted(false)
+	s.setRSSilent(false)
 	cancelReceiver()
 	sock.close()
 }
@@ -350,15 +2196,95 @@ func (s *advertiser) status() *InterfaceStatus {
 	return s.ifaceStatus.deepCopy()
 }
 
-func (s *advertiser) reload(ctx context.Context, newConfig *InterfaceConfig) error {
+func (s *advertiser) reload(ctx context.Context, newConfig *InterfaceConfig, generation int) error {
 	select {
-	case s.reloadCh <- newConfig:
+	case s.reloadCh <- &reloadMsg{config: newConfig, generation: generation}:
 	case <-ctx.Done():
 		return ctx.Err()
 	}
+	s.aliasLock.Lock()
+	s.aliases = newConfig.Aliases
+	s.aliasLock.Unlock()
 	return nil
 }
 
+func (s *advertiser) getPoisonUntil() time.Time {
+	s.poisonUntilLock.Lock()
+	defer s.poisonUntilLock.Unlock()
+	return s.poisonUntil
+}
+
+func (s *advertiser) poison(duration time.Duration) {
+	s.poisonUntilLock.Lock()
+	s.poisonUntil = time.Now().Add(duration)
+	s.poisonUntilLock.Unlock()
+
+	// Notify the main loop. If there's a pending notification, the main
+	// loop will pick up the latest poisonUntil anyway.
+	select {
+	case s.poisonCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *advertiser) getAlias() string {
+	s.aliasLock.Lock()
+	defer s.aliasLock.Unlock()
+	return s.alias
+}
+
+// hasAlias returns true when the latest configuration has the alias
+func (s *advertiser) hasAlias(alias string) bool {
+	s.aliasLock.Lock()
+	defer s.aliasLock.Unlock()
+	_, ok := s.aliases[alias]
+	return ok
+}
+
+func (s *advertiser) setAlias(alias string) {
+	s.aliasLock.Lock()
+	s.alias = alias
+	s.aliasLock.Unlock()
+
+	// Notify the main loop. If there's a pending notification, the main
+	// loop will pick up the latest alias anyway.
+	select {
+	case s.aliasCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *advertiser) getSendDelay() time.Duration {
+	s.sendDelayLock.Lock()
+	defer s.sendDelayLock.Unlock()
+	return s.sendDelay
+}
+
+func (s *advertiser) setSendDelay(delay time.Duration) {
+	s.sendDelayLock.Lock()
+	defer s.sendDelayLock.Unlock()
+	s.sendDelay = delay
+}
+
+func (s *advertiser) isPaused() bool {
+	s.pausedLock.Lock()
+	defer s.pausedLock.Unlock()
+	return s.paused
+}
+
+func (s *advertiser) setPaused(paused bool) {
+	s.pausedLock.Lock()
+	s.paused = paused
+	s.pausedLock.Unlock()
+
+	// Notify the main loop. If there's a pending notification, the main
+	// loop will pick up the latest state anyway.
+	select {
+	case s.pauseCh <- struct{}{}:
+	default:
+	}
+}
+
 func (s *advertiser) stop() {
 	close(s.stopCh)
 }
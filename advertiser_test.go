@@ -0,0 +1,541 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/ndp"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+// newTestRAMsg builds the RA message for the given interface configuration
+// without running the advertiser.
+func newTestRAMsg(t *testing.T, c *InterfaceConfig) *ndp.RouterAdvertisement {
+	t.Helper()
+
+	config := &Config{Interfaces: []*InterfaceConfig{c}}
+	require.NoError(t, config.defaultAndValidate())
+
+	s := newAdvertiser(c, 1, &advertiserOptions{
+		malformedRSPolicy: MalformedRSCount,
+		clock:             realClock{},
+		logger:            slog.Default(),
+	})
+
+	return s.createRAMsg(c, &deviceState{
+		isUp: true,
+		addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66},
+	})
+}
+
+// findRawOptions marshals the RA and returns the raw options with the given
+// type. The returned option includes the type and length bytes.
+func findRawOptions(t *testing.T, msg *ndp.RouterAdvertisement, typ byte) [][]byte {
+	t.Helper()
+
+	b, err := ndp.MarshalMessage(msg)
+	require.NoError(t, err)
+
+	// Skip ICMPv6 header (4 bytes) + RA header (12 bytes)
+	opts := b[16:]
+
+	ret := [][]byte{}
+	for len(opts) > 0 {
+		length := int(opts[1]) * 8
+		if opts[0] == typ {
+			ret = append(ret, opts[:length])
+		}
+		opts = opts[length:]
+	}
+
+	return ret
+}
+
+func TestRouteInformationPrefixLength(t *testing.T) {
+	tests := []struct {
+		name         string
+		prefix       string
+		prefixLength uint8
+		// Option length in the units of 8 octets
+		optionLength uint8
+		prefixBytes  []byte
+	}{
+		{
+			name:         "/128",
+			prefix:       "2001:db8::1/128",
+			prefixLength: 128,
+			optionLength: 3,
+			prefixBytes:  netip.MustParseAddr("2001:db8::1").AsSlice(),
+		},
+		{
+			name:         "/48",
+			prefix:       "2001:db8:1::/48",
+			prefixLength: 48,
+			optionLength: 2,
+			prefixBytes:  []byte{0x20, 0x01, 0x0d, 0xb8, 0x00, 0x01, 0x00, 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := newTestRAMsg(t, &InterfaceConfig{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 1000,
+				Routes: []*RouteConfig{
+					{
+						Prefix:          tt.prefix,
+						LifetimeSeconds: 100,
+					},
+				},
+			})
+
+			opts := findRawOptions(t, msg, 24)
+			require.Len(t, opts, 1)
+
+			opt := opts[0]
+			require.Equal(t, tt.optionLength, opt[1], "Invalid option length")
+			require.Equal(t, tt.prefixLength, opt[2], "Invalid prefix length")
+			require.Equal(t, tt.prefixBytes, opt[8:], "Invalid prefix")
+		})
+	}
+}
+
+func TestPrefixInformationSubnetID(t *testing.T) {
+	msg := newTestRAMsg(t, &InterfaceConfig{
+		Name:                   "net0",
+		RAIntervalMilliseconds: 1000,
+		Prefixes: []*PrefixConfig{
+			{
+				Prefix:   "2001:db8:0:100::/56",
+				SubnetID: ptr.To(1),
+			},
+		},
+	})
+
+	opts := findRawOptions(t, msg, 3)
+	require.Len(t, opts, 1)
+
+	opt := opts[0]
+	require.Equal(t, uint8(64), opt[2], "Invalid prefix length")
+	require.Equal(t, netip.MustParseAddr("2001:db8:0:101::").AsSlice(), opt[16:], "Invalid prefix")
+}
+
+func TestVendorOption(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		optionLength uint8
+	}{
+		{
+			name:         "Empty payload",
+			payload:      "",
+			optionLength: 1,
+		},
+		{
+			name:         "Padded payload",
+			payload:      "deadbeef",
+			optionLength: 2,
+		},
+		{
+			name:         "Aligned payload",
+			payload:      "0011223344556677",
+			optionLength: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := newTestRAMsg(t, &InterfaceConfig{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 1000,
+				VendorOptions: []*VendorOptionConfig{
+					{
+						Type:             253,
+						EnterpriseNumber: 32473,
+						Payload:          tt.payload,
+					},
+				},
+			})
+
+			b, err := ndp.MarshalMessage(msg)
+			require.NoError(t, err)
+
+			// Parse it back
+			parsed, err := ndp.ParseMessage(b)
+			require.NoError(t, err)
+
+			var opt *ndp.RawOption
+			for _, option := range parsed.(*ndp.RouterAdvertisement).Options {
+				if raw, ok := option.(*ndp.RawOption); ok && raw.Type == 253 {
+					opt = raw
+				}
+			}
+			require.NotNil(t, opt, "Vendor option is not advertised")
+			require.Equal(t, tt.optionLength, opt.Length, "Invalid option length")
+
+			// Reserved
+			require.Equal(t, []byte{0, 0}, opt.Value[0:2], "Invalid reserved field")
+
+			// Enterprise Number
+			require.Equal(t, uint32(32473), binary.BigEndian.Uint32(opt.Value[2:6]), "Invalid enterprise number")
+
+			// Payload followed by zero padding
+			payload, err := hex.DecodeString(tt.payload)
+			require.NoError(t, err)
+			require.Equal(t, payload, opt.Value[6:6+len(payload)], "Invalid payload")
+			require.Equal(t, make([]byte, len(opt.Value)-6-len(payload)), opt.Value[6+len(payload):], "Invalid padding")
+		})
+	}
+}
+
+func TestPreferenceCaseInsensitive(t *testing.T) {
+	msg := newTestRAMsg(t, &InterfaceConfig{
+		Name:                   "net0",
+		RAIntervalMilliseconds: 1000,
+		RouterLifetimeSeconds:  1800,
+		Preference:             "High",
+		Routes: []*RouteConfig{
+			{
+				Prefix:          "2001:db8:1::/48",
+				LifetimeSeconds: 100,
+				Preference:      "MEDIUM",
+			},
+			{
+				Prefix:          "2001:db8:2::/48",
+				LifetimeSeconds: 100,
+				Preference:      "Low",
+			},
+		},
+	})
+
+	require.Equal(t, ndp.High, msg.RouterSelectionPreference)
+
+	preferences := []ndp.Preference{}
+	for _, option := range msg.Options {
+		if opt, ok := option.(*ndp.RouteInformation); ok {
+			preferences = append(preferences, opt.Preference)
+		}
+	}
+	require.Equal(t, []ndp.Preference{ndp.Medium, ndp.Low}, preferences)
+}
+
+func TestMultipleRDNSS(t *testing.T) {
+	msg := newTestRAMsg(t, &InterfaceConfig{
+		Name:                   "net0",
+		RAIntervalMilliseconds: 1000,
+		RDNSSes: []*RDNSSConfig{
+			{
+				LifetimeSeconds: 100,
+				Addresses:       []string{"2001:db8::1", "2001:db8::2"},
+			},
+			{
+				LifetimeSeconds: 200,
+				Addresses:       []string{"2001:db8::3"},
+			},
+		},
+	})
+
+	rdnsses := []*ndp.RecursiveDNSServer{}
+	for _, option := range msg.Options {
+		if opt, ok := option.(*ndp.RecursiveDNSServer); ok {
+			rdnsses = append(rdnsses, opt)
+		}
+	}
+	require.Len(t, rdnsses, 2)
+
+	require.Equal(t, time.Second*100, rdnsses[0].Lifetime)
+	require.Equal(t, []netip.Addr{
+		netip.MustParseAddr("2001:db8::1"),
+		netip.MustParseAddr("2001:db8::2"),
+	}, rdnsses[0].Servers)
+
+	require.Equal(t, time.Second*200, rdnsses[1].Lifetime)
+	require.Equal(t, []netip.Addr{
+		netip.MustParseAddr("2001:db8::3"),
+	}, rdnsses[1].Servers)
+
+	// Ensure they survive the marshaling as separate options
+	require.Len(t, findRawOptions(t, msg, 25), 2)
+}
+
+func TestSENDOption(t *testing.T) {
+	// Dummy RSA Signature option (Reserved, Key Hash, Digital Signature
+	// and Padding) computed by the external signer
+	data := "0000" + strings.Repeat("ab", 16) + strings.Repeat("cd", 4) + strings.Repeat("00", 8)
+
+	for _, padToBytes := range []int{0, 256} {
+		t.Run(fmt.Sprintf("PadToBytes %d", padToBytes), func(t *testing.T) {
+			msg := newTestRAMsg(t, &InterfaceConfig{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 1000,
+				PadToBytes:             padToBytes,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:                   "2001:db8::/64",
+						ValidLifetimeSeconds:     ptr.To(2592000),
+						PreferredLifetimeSeconds: ptr.To(604800),
+					},
+				},
+				SENDOptions: []*SENDOptionConfig{
+					{
+						Type: 12,
+						Data: data,
+					},
+				},
+			})
+
+			b, err := ndp.MarshalMessage(msg)
+			require.NoError(t, err)
+
+			// The option is transmitted intact as the last option
+			expected, err := hex.DecodeString("0c04" + data)
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{expected}, findRawOptions(t, msg, 12))
+			require.Equal(t, expected, b[len(b)-len(expected):])
+		})
+	}
+}
+
+func TestJitteredInterval(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	interval := time.Second
+
+	t.Run("Ensure no jitter keeps the interval", func(t *testing.T) {
+		require.Equal(t, interval, jitteredInterval(interval, 0, rng))
+	})
+
+	for _, percent := range []int{1, 20, 50} {
+		t.Run(fmt.Sprintf("Ensure intervals stay within %d%%", percent), func(t *testing.T) {
+			lower := interval - interval*time.Duration(percent)/100
+			upper := interval + interval*time.Duration(percent)/100
+
+			seen := map[time.Duration]struct{}{}
+			for i := 0; i < 1000; i++ {
+				d := jitteredInterval(interval, percent, rng)
+				require.GreaterOrEqual(t, d, lower)
+				require.LessOrEqual(t, d, upper)
+				seen[d] = struct{}{}
+			}
+
+			// The intervals are actually randomized
+			require.Greater(t, len(seen), 1)
+		})
+	}
+}
+
+func TestPadToBytes(t *testing.T) {
+	tests := []struct {
+		name       string
+		padToBytes int
+		size       int
+	}{
+		{
+			name:       "Aligned size",
+			padToBytes: 512,
+			size:       512,
+		},
+		{
+			name:       "Unaligned size",
+			padToBytes: 500,
+			size:       504,
+		},
+		{
+			name:       "Multiple padding options",
+			padToBytes: 1000,
+			size:       1000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := newTestRAMsg(t, &InterfaceConfig{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 1000,
+				PadToBytes:             tt.padToBytes,
+			})
+
+			b, err := ndp.MarshalMessage(msg)
+			require.NoError(t, err)
+			require.Len(t, b, tt.size)
+
+			// Padding options are filled with zeros
+			for _, opt := range findRawOptions(t, msg, paddingOptionType) {
+				require.Equal(t, make([]byte, len(opt)-2), opt[2:])
+			}
+		})
+	}
+
+	t.Run("Ensure PadToBytes smaller than the RA is rejected", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					PadToBytes:             16,
+				},
+			},
+		}
+		require.NoError(t, config.defaultAndValidate())
+		require.ErrorIs(t, selfTest(config), ErrSelfTest)
+	})
+
+	t.Run("Ensure PadToBytes exceeding the MTU is rejected", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					// 1248 bytes after rounding up + IPv6
+					// header exceeds 1280 bytes
+					PadToBytes: 1241,
+				},
+			},
+		}
+		require.NoError(t, config.defaultAndValidate())
+		require.ErrorIs(t, selfTest(config), ErrSelfTest)
+
+		config.Interfaces[0].MTU = 1500
+		require.NoError(t, selfTest(config))
+	})
+}
+
+func TestSelfTest(t *testing.T) {
+	// 48 Prefix Information options (32 bytes each) exceed 1280 bytes
+	largePrefixes := func() []*PrefixConfig {
+		prefixes := []*PrefixConfig{}
+		for i := 0; i < 48; i++ {
+			prefixes = append(prefixes, &PrefixConfig{Prefix: fmt.Sprintf("2001:db8:%x::/64", i)})
+		}
+		return prefixes
+	}
+
+	t.Run("Ensure the alias exceeding the MTU is rejected", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					Aliases: map[string]*AliasConfig{
+						"maintenance": {Prefixes: largePrefixes()},
+					},
+				},
+			},
+		}
+		require.NoError(t, config.defaultAndValidate())
+		require.ErrorIs(t, selfTest(config), ErrSelfTest)
+		require.ErrorContains(t, selfTest(config), "alias maintenance")
+	})
+
+	t.Run("Ensure the solicitor override exceeding the MTU is rejected", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					SolicitorOverrides: map[string]*SolicitorOverrideConfig{
+						"fe80::1": {Prefixes: largePrefixes()},
+					},
+				},
+			},
+		}
+		require.NoError(t, config.defaultAndValidate())
+		require.ErrorIs(t, selfTest(config), ErrSelfTest)
+	})
+}
+
+func TestRAOptionOrder(t *testing.T) {
+	newConfig := func() *InterfaceConfig {
+		return &InterfaceConfig{
+			Name:                   "net0",
+			RAIntervalMilliseconds: 1000,
+			MTU:                    1500,
+			Prefixes: []*PrefixConfig{
+				{Prefix: "2001:db8:2::/64"},
+				{Prefix: "2001:db8:1::/64"},
+				{Prefix: "2001:db8:3::/64"},
+			},
+			Routes: []*RouteConfig{
+				{Prefix: "2001:db8:20::/48", LifetimeSeconds: 100},
+				{Prefix: "2001:db8:10::/48", LifetimeSeconds: 100},
+			},
+			RDNSSes: []*RDNSSConfig{
+				{LifetimeSeconds: 100, Addresses: []string{"2001:db8::2", "2001:db8::1"}},
+				{LifetimeSeconds: 200, Addresses: []string{"2001:db8::3"}},
+			},
+			DNSSLs: []*DNSSLConfig{
+				{LifetimeSeconds: 100, DomainNames: []string{"b.example.com", "a.example.com"}},
+			},
+			NAT64Prefixes: []*NAT64PrefixConfig{
+				{Prefix: "64:ff9b::/96"},
+				{Prefix: "2001:db8:64::/96"},
+			},
+			VendorOptions: []*VendorOptionConfig{
+				{Type: 254, EnterpriseNumber: 32473},
+				{Type: 253, EnterpriseNumber: 32473},
+			},
+		}
+	}
+
+	msg0 := newTestRAMsg(t, newConfig())
+	msg1 := newTestRAMsg(t, newConfig())
+
+	t.Run("Ensure the same config produces byte-identical RA", func(t *testing.T) {
+		b0, err := ndp.MarshalMessage(msg0)
+		require.NoError(t, err)
+		b1, err := ndp.MarshalMessage(msg1)
+		require.NoError(t, err)
+		require.Equal(t, b0, b1)
+	})
+
+	t.Run("Ensure the options appear in the config order", func(t *testing.T) {
+		order := []string{}
+		for _, option := range msg0.Options {
+			switch opt := option.(type) {
+			case *ndp.LinkLayerAddress:
+				order = append(order, "sll")
+			case *ndp.MTU:
+				order = append(order, "mtu")
+			case *ndp.PrefixInformation:
+				order = append(order, "pi "+opt.Prefix.String())
+			case *ndp.RouteInformation:
+				order = append(order, "ri "+opt.Prefix.String())
+			case *ndp.RecursiveDNSServer:
+				order = append(order, "rdnss "+opt.Servers[0].String())
+			case *ndp.DNSSearchList:
+				order = append(order, "dnssl "+opt.DomainNames[0])
+			case *ndp.PREF64:
+				order = append(order, "pref64 "+opt.Prefix.String())
+			case *ndp.RawOption:
+				order = append(order, fmt.Sprintf("vendor %d", opt.Type))
+			}
+		}
+		require.Equal(t, []string{
+			"sll",
+			"mtu",
+			"pi 2001:db8:2::",
+			"pi 2001:db8:1::",
+			"pi 2001:db8:3::",
+			"ri 2001:db8:20::",
+			"ri 2001:db8:10::",
+			"rdnss 2001:db8::2",
+			"rdnss 2001:db8::3",
+			"dnssl b.example.com",
+			"pref64 64:ff9b::/96",
+			"pref64 2001:db8:64::/96",
+			"vendor 254",
+			"vendor 253",
+		}, order)
+	})
+}
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import "time"
+
+// clock is an abstraction of the wall clock to make the time-based behavior
+// testable
+type clock interface {
+	Now() time.Time
+
+	// After waits for the duration to elapse on the clock and then sends
+	// the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+var _ clock = realClock{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -47,27 +47,36 @@ func main() {
 		os.Exit(0)
 	}
 
-	client := internal.NewClient("localhost:8888")
-
 	if os.Args[1] == "reload" {
 		var (
 			config string
+			socket string
 		)
 		command := flag.NewFlagSet("reload", flag.ExitOnError)
 		command.StringVar(&config, "f", "", "config file path")
+		command.StringVar(&socket, "socket", "", "Unix socket path of the daemon (localhost:8888 if empty)")
 		command.Parse(os.Args[2:])
-		reload(client, config)
+		reload(newClient(socket), config)
 	}
 
 	if os.Args[1] == "status" {
 		var (
 			output string
+			socket string
 		)
 		command := flag.NewFlagSet("status", flag.ExitOnError)
 		command.StringVar(&output, "o", "table", "Output format (table, json, or yaml)")
+		command.StringVar(&socket, "socket", "", "Unix socket path of the daemon (localhost:8888 if empty)")
 		command.Parse(os.Args[2:])
-		status(client, output)
+		status(newClient(socket), output)
+	}
+}
+
+func newClient(socket string) *internal.Client {
+	if socket != "" {
+		return internal.NewUnixClient(socket)
 	}
+	return internal.NewClient("localhost:8888")
 }
 
 func reload(client *internal.Client, config string) {
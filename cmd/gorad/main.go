@@ -8,7 +8,10 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 
 	"github.com/YutaroHayakawa/go-ra"
 	"github.com/YutaroHayakawa/go-ra/cmd/internal"
@@ -25,6 +28,10 @@ var (
 func main() {
 	configFile := flag.String("f", "", "config file path")
 	v := flag.Bool("v", false, "show version information")
+	socket := flag.String("socket", "", "serve the control API on the Unix socket at the path instead of localhost:8888")
+	allowedUIDs := flag.String("allowed-uids", "", "comma-separated UIDs allowed to connect to the Unix socket (any UID if empty)")
+	failFast := flag.Duration("fail-fast", 0, "exit with an error if any interface isn't running within the given duration (disabled if 0)")
+	devicePollInterval := flag.Duration("device-poll-interval", 0, "poll the device state with the given interval instead of watching the netlink events (disabled if 0)")
 
 	flag.Parse()
 
@@ -38,32 +45,80 @@ func main() {
 		return
 	}
 
+	uids, err := parseUIDs(*allowedUIDs)
+	if err != nil {
+		slog.Error("Failed to parse allowed UIDs. Aborting.", "error", err.Error())
+		return
+	}
+
 	config, err := ra.ParseConfigYAMLFile(*configFile)
 	if err != nil {
 		slog.Error("Failed to parse config file. Aborting.", "error", err.Error())
 		return
 	}
 
-	daemon, err := ra.NewDaemon(
-		config,
+	opts := []ra.DaemonOption{
 		ra.WithLogger(slog.With("component", "daemon")),
-	)
+		ra.WithFailFast(*failFast),
+	}
+	if *devicePollInterval != 0 {
+		opts = append(opts, ra.WithDevicePollInterval(*devicePollInterval))
+	}
+
+	daemon, err := ra.NewDaemon(config, opts...)
 	if err != nil {
 		slog.Error("Failed to create daemon. Aborting.", "error", err.Error())
 		return
 	}
 
 	go func() {
-		server := internal.NewServer("localhost:8888", daemon, slog.With("component", "apiServer"))
+		logger := slog.With("component", "apiServer")
+		server := internal.NewServer("localhost:8888", daemon, logger)
+
+		if *socket == "" {
+			slog.Info("Starting HTTP server")
 
-		slog.Info("Starting HTTP server")
+			if err := server.ListenAndServe(); err != nil {
+				slog.Error("HTTP server failed with error", "error", err.Error())
+			}
+			return
+		}
+
+		l, err := internal.ListenUnix(*socket, uids, logger)
+		if err != nil {
+			slog.Error("Failed to listen on the Unix socket", "error", err.Error())
+			return
+		}
 
-		if err := server.ListenAndServe(); err != nil {
+		slog.Info("Starting HTTP server on the Unix socket", "path", *socket)
+
+		if err := server.Serve(l); err != nil {
 			slog.Error("HTTP server failed with error", "error", err.Error())
 		}
 	}()
 
 	ctx, cancel := signal.NotifyContext(context.Background(), unix.SIGINT, unix.SIGTERM)
-	daemon.Run(ctx)
+	err = daemon.Run(ctx)
 	cancel()
+	if err != nil {
+		slog.Error("Daemon failed with error", "error", err.Error())
+		os.Exit(1)
+	}
+}
+
+func parseUIDs(s string) ([]uint32, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	uids := []uint32{}
+	for _, f := range strings.Split(s, ",") {
+		uid, err := strconv.ParseUint(strings.TrimSpace(f), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UID %q: %w", f, err)
+		}
+		uids = append(uids, uint32(uid))
+	}
+
+	return uids, nil
 }
@@ -4,6 +4,7 @@
 package internal
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"log/slog"
@@ -27,6 +28,7 @@ func NewServer(host string, daemon *ra.Daemon, logger *slog.Logger) *Server {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/reload", srv.handleReload)
 	mux.HandleFunc("/status", srv.handleStatus)
+	mux.HandleFunc("/metrics", srv.handleMetrics)
 
 	srv.Addr = host
 	srv.Handler = mux
@@ -78,6 +80,16 @@ func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, ra.ErrSelfTest) {
+			s.writeError(w, http.StatusBadRequest, "SelfTestError", err.Error())
+			return
+		}
+
+		if errors.Is(err, ra.ErrValidationHook) {
+			s.writeError(w, http.StatusBadRequest, "ValidationHookError", err.Error())
+			return
+		}
+
 		if err = r.Context().Err(); err != nil {
 			s.writeError(w, http.StatusRequestTimeout, "RequestTimeout", err.Error())
 			return
@@ -112,3 +124,23 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write(j)
 }
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := s.daemon.WriteMetrics(&b); err != nil {
+		s.logger.Error("Failed to write metrics", "error", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b.Bytes())
+}
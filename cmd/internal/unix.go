@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+
+	"golang.org/x/sys/unix"
+)
+
+// The host name used in the URL when the client talks over the Unix socket.
+// It's only for the HTTP Host header and never resolved.
+const unixHost = "unix"
+
+// peerCredListener is a net.Listener on the Unix socket which closes the
+// connections from the peers whose UID is not allowed
+type peerCredListener struct {
+	*net.UnixListener
+	allowedUIDs []uint32
+	logger      *slog.Logger
+}
+
+// ListenUnix listens on the Unix socket at the path for the control server.
+// The stale socket left at the path is removed, but it fails if the path is
+// something other than the socket. When allowedUIDs is not empty, only the
+// peers running with one of the UIDs can connect. The credential of the peer
+// is taken with SO_PEERCRED at the connection time. Otherwise, the access is
+// only restricted by the file permission of the socket.
+func ListenUnix(path string, allowedUIDs []uint32, logger *slog.Logger) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &peerCredListener{
+		UnixListener: l,
+		allowedUIDs:  allowedUIDs,
+		logger:       logger,
+	}, nil
+}
+
+// removeStaleSocket removes the socket at the path. Nothing is done when the
+// path doesn't exist. Never removes the other kinds of files, so that the
+// wrong path doesn't destroy them.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if fi.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove the stale socket: %w", err)
+	}
+	return nil
+}
+
+func (l *peerCredListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.AcceptUnix()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(l.allowedUIDs) == 0 {
+			return conn, nil
+		}
+
+		cred, err := peerCred(conn)
+		if err != nil {
+			l.logger.Warn("Failed to get the peer credential. Closing the connection.", "error", err.Error())
+			conn.Close()
+			continue
+		}
+
+		if !slices.Contains(l.allowedUIDs, cred.Uid) {
+			l.logger.Warn("Rejected the connection from the disallowed UID", "uid", cred.Uid, "pid", cred.Pid)
+			conn.Close()
+			continue
+		}
+
+		return conn, nil
+	}
+}
+
+// peerCred returns the credential of the peer process of the connection
+func peerCred(conn *net.UnixConn) (*unix.Ucred, error) {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		cred    *unix.Ucred
+		credErr error
+	)
+	if err := rc.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+
+	return cred, credErr
+}
+
+// NewUnixClient returns the client talking to the control server over the
+// Unix socket at the path
+func NewUnixClient(path string) *Client {
+	return &Client{
+		Client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+		host: unixHost,
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package internal
+
+import (
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/YutaroHayakawa/go-ra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnixSocketPeerCred(t *testing.T) {
+	d, err := ra.NewDaemon(&ra.Config{})
+	require.NoError(t, err)
+
+	// The path of the Unix socket is limited to ~100 bytes, so don't use
+	// the long t.TempDir().
+	dir, err := os.MkdirTemp("", "gora")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	serve := func(t *testing.T, path string, allowedUIDs []uint32) {
+		l, err := ListenUnix(path, allowedUIDs, slog.Default())
+		require.NoError(t, err)
+
+		server := NewServer("", d, slog.Default())
+		go server.Serve(l)
+		t.Cleanup(func() { server.Close() })
+	}
+
+	uid := uint32(os.Getuid())
+
+	t.Run("Ensure the allowed UID can access", func(t *testing.T) {
+		path := filepath.Join(dir, "allowed.sock")
+		serve(t, path, []uint32{uid})
+
+		status, err := NewUnixClient(path).Status()
+		require.NoError(t, err)
+		require.Empty(t, status.Interfaces)
+	})
+
+	t.Run("Ensure the disallowed UID is rejected", func(t *testing.T) {
+		// Only allow the other UID to simulate the connection from the
+		// disallowed UID
+		path := filepath.Join(dir, "disallowed.sock")
+		serve(t, path, []uint32{uid + 1})
+
+		_, err := NewUnixClient(path).Status()
+		require.Error(t, err)
+
+		err = NewUnixClient(path).Reload(&ra.Config{})
+		require.Error(t, err)
+	})
+
+	t.Run("Ensure any UID can access without the allowed UIDs", func(t *testing.T) {
+		path := filepath.Join(dir, "any.sock")
+		serve(t, path, nil)
+
+		_, err := NewUnixClient(path).Status()
+		require.NoError(t, err)
+	})
+
+	t.Run("Ensure the stale socket is replaced", func(t *testing.T) {
+		path := filepath.Join(dir, "stale.sock")
+
+		// Leave the socket file behind as the crashed daemon does
+		l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+		require.NoError(t, err)
+		l.SetUnlinkOnClose(false)
+		require.NoError(t, l.Close())
+		_, err = os.Stat(path)
+		require.NoError(t, err)
+
+		serve(t, path, []uint32{uid})
+
+		_, err = NewUnixClient(path).Status()
+		require.NoError(t, err)
+	})
+
+	t.Run("Ensure the non-socket file is not removed", func(t *testing.T) {
+		path := filepath.Join(dir, "regular")
+		require.NoError(t, os.WriteFile(path, []byte("keep"), 0o600))
+
+		_, err := ListenUnix(path, []uint32{uid}, slog.Default())
+		require.Error(t, err)
+
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "keep", string(b))
+	})
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"fmt"
+	"time"
+)
+
+// The range of MaxRtrAdvInterval in RFC4861 Section 6.2.1
+const (
+	minRFC4861RAInterval = 4 * time.Second
+	maxRFC4861RAInterval = 1800 * time.Second
+)
+
+// The valid lifetime the hosts don't decrease the existing addresses' one
+// below (RFC4862 Section 5.5.3 e)
+const rfc4862ValidLifetimeFloor = 2 * time.Hour
+
+// Finding is a deviation from the RFC recommendation found by
+// ComplianceReport. Unlike the Warning, it's about the interoperability with
+// the hosts following the RFCs rather than the likely mistakes.
+type Finding struct {
+	// Name of the interface the finding is about
+	Interface string
+
+	// Path of the field within the interface configuration (e.g.
+	// "RDNSSes[0].LifetimeSeconds")
+	Field string
+
+	// The RFC section of the recommendation (e.g. "RFC8106 Section 5.1")
+	Reference string
+
+	// Human-readable description of the deviation
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("interface %s: %s: %s (%s)", f.Interface, f.Field, f.Message, f.Reference)
+}
+
+// ComplianceReport checks the running configuration against the
+// recommendations of RFC4861, RFC4862, and RFC8106 and returns the findings.
+// The running configuration includes the default values, but not the ULA
+// prefixes generated by AutoULA. It returns an empty slice when nothing is
+// found.
+func (d *Daemon) ComplianceReport() []Finding {
+	d.configLock.Lock()
+	defer d.configLock.Unlock()
+
+	findings := []Finding{}
+	for _, iface := range d.config.Interfaces {
+		findings = append(findings, iface.complianceFindings()...)
+	}
+	return findings
+}
+
+// complianceFindings returns the findings of ComplianceReport. The config
+// must be validated beforehand.
+func (c *InterfaceConfig) complianceFindings() []Finding {
+	findings := []Finding{}
+
+	add := func(field, reference, format string, args ...any) {
+		findings = append(findings, Finding{
+			Interface: c.Name,
+			Field:     field,
+			Reference: reference,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	interval := time.Duration(c.RAIntervalMilliseconds) * time.Millisecond
+
+	if interval < minRFC4861RAInterval || interval > maxRFC4861RAInterval {
+		add("RAIntervalMilliseconds", "RFC4861 Section 6.2.1", "RA interval %s is out of the range of MaxRtrAdvInterval (%s - %s)", interval, minRFC4861RAInterval, maxRFC4861RAInterval)
+	}
+
+	// Zero router lifetime means the router is not a default router
+	routerLifetime := time.Duration(c.RouterLifetimeSeconds) * time.Second
+	if routerLifetime > 0 && routerLifetime < interval {
+		add("RouterLifetimeSeconds", "RFC4861 Section 6.2.1", "router lifetime %s must be zero or no less than the RA interval %s", routerLifetime, interval)
+	}
+
+	// The hosts ignore the decrease of the valid lifetime of the existing
+	// addresses below two hours, so the shorter lifetime doesn't take
+	// effect as configured. Zero lifetime is intentional (e.g.
+	// deprecation).
+	for i, prefix := range c.Prefixes {
+		valid := time.Duration(*prefix.ValidLifetimeSeconds) * time.Second
+		if prefix.Autonomous && valid > 0 && valid < rfc4862ValidLifetimeFloor {
+			add(fmt.Sprintf("Prefixes[%d].ValidLifetimeSeconds", i), "RFC4862 Section 5.5.3", "valid lifetime %s is shorter than %s, the hosts don't decrease the lifetime of the existing addresses below it", valid, rfc4862ValidLifetimeFloor)
+		}
+	}
+
+	// The DNS information should survive a few lost RAs
+	checkDNSLifetime := func(field string, seconds int) {
+		lifetime := time.Duration(seconds) * time.Second
+		if lifetime > 0 && lifetime < 3*interval {
+			add(field, "RFC8106 Section 5.1", "lifetime %s is shorter than 3 times the RA interval %s", lifetime, interval)
+		}
+	}
+
+	for i, rdnss := range c.RDNSSes {
+		checkDNSLifetime(fmt.Sprintf("RDNSSes[%d].LifetimeSeconds", i), rdnss.LifetimeSeconds)
+	}
+
+	for i, dnssl := range c.DNSSLs {
+		checkDNSLifetime(fmt.Sprintf("DNSSLs[%d].LifetimeSeconds", i), dnssl.LifetimeSeconds)
+	}
+
+	return findings
+}
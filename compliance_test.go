@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestComplianceReport(t *testing.T) {
+	t.Run("Ensure short DNS lifetimes yield findings", func(t *testing.T) {
+		d, err := NewDaemon(&Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 600000,
+					RDNSSes: []*RDNSSConfig{
+						{
+							// 2 times the RA interval
+							LifetimeSeconds: 1200,
+							Addresses:       []string{"2001:db8::53"},
+						},
+					},
+					DNSSLs: []*DNSSLConfig{
+						{
+							LifetimeSeconds: 1800,
+							DomainNames:     []string{"example.com"},
+						},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		findings := d.ComplianceReport()
+		require.Len(t, findings, 1)
+		require.Equal(t, "net0", findings[0].Interface)
+		require.Equal(t, "RDNSSes[0].LifetimeSeconds", findings[0].Field)
+		require.Equal(t, "RFC8106 Section 5.1", findings[0].Reference)
+	})
+
+	t.Run("Ensure other recommendations are checked", func(t *testing.T) {
+		d, err := NewDaemon(&Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					RouterLifetimeSeconds:  1800,
+				},
+				{
+					Name:                   "net1",
+					RAIntervalMilliseconds: 600000,
+					RouterLifetimeSeconds:  300,
+					Prefixes: []*PrefixConfig{
+						{
+							Prefix:                   "2001:db8::/64",
+							Autonomous:               true,
+							ValidLifetimeSeconds:     ptr.To(3600),
+							PreferredLifetimeSeconds: ptr.To(1800),
+						},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		findings := d.ComplianceReport()
+		require.Len(t, findings, 3)
+		require.Equal(t, Finding{
+			Interface: "net0",
+			Field:     "RAIntervalMilliseconds",
+			Reference: "RFC4861 Section 6.2.1",
+			Message:   "RA interval 1s is out of the range of MaxRtrAdvInterval (4s - 30m0s)",
+		}, findings[0])
+		require.Equal(t, "net1", findings[1].Interface)
+		require.Equal(t, "RouterLifetimeSeconds", findings[1].Field)
+		require.Equal(t, "net1", findings[2].Interface)
+		require.Equal(t, "Prefixes[0].ValidLifetimeSeconds", findings[2].Field)
+	})
+
+	t.Run("Ensure the report follows the reloaded configuration", func(t *testing.T) {
+		d, err := NewDaemon(
+			&Config{},
+			withSocketConstructor(newFakeSockRegistry().newSock),
+			withDeviceWatcher(newFakeDeviceWatcher("net0")),
+		)
+		require.NoError(t, err)
+		require.Empty(t, d.ComplianceReport())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go d.Run(ctx)
+
+		err = d.Reload(ctx, &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		findings := d.ComplianceReport()
+		require.Len(t, findings, 1)
+		require.Equal(t, "RAIntervalMilliseconds", findings[0].Field)
+	})
+}
@@ -4,15 +4,27 @@
 package ra
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/netip"
 	"os"
+	"path"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/creasty/defaults"
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/net/idna"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,12 +34,123 @@ type Config struct {
 	// unique within the slice. The slice itself and elements must not be
 	// nil.
 	Interfaces []*InterfaceConfig `yaml:"interfaces" json:"interfaces" validate:"unique=Name,dive,required" default:"[]"`
+
+	// Advertise on all interfaces which are up, except the loopback
+	// ones. The interfaces are tracked dynamically as they appear,
+	// disappear, or go up and down. They are configured with the template
+	// given by WithInterfaceTemplate or the defaults if there's no
+	// template. The interfaces in Interfaces take precedence. Default is
+	// false.
+	AllInterfaces bool `yaml:"allInterfaces" json:"allInterfaces"`
+
+	// Advertise on the interfaces which are up and whose MAC address
+	// matches any of the prefixes. The prefix is the leading octets of the
+	// MAC address and the prefix length in bits (e.g. "00:11:22/24" for
+	// the OUI). The bits after the prefix length must be zero. The
+	// interfaces are tracked and configured in the same way as
+	// AllInterfaces. Default is empty.
+	InterfaceMACPrefixes []string `yaml:"interfaceMACPrefixes" json:"interfaceMACPrefixes" validate:"dive,mac_prefix"`
+
+	// Names or patterns of the interfaces not to advertise on even if
+	// they are selected by AllInterfaces, InterfaceMACPrefixes, or the
+	// InterfaceSelector (e.g. the management NICs). The pattern syntax is
+	// the one of path.Match (e.g. "mgmt*" or "eth[0-1]"). The interfaces
+	// in Interfaces are not affected. Default is empty.
+	ExcludeInterfaces []string `yaml:"excludeInterfaces" json:"excludeInterfaces" validate:"unique,dive,ifname_pattern"`
+}
+
+// excludesInterface returns true if the name matches any of the
+// ExcludeInterfaces. The patterns must be validated beforehand.
+func (c *Config) excludesInterface(name string) bool {
+	return slices.ContainsFunc(c.ExcludeInterfaces, func(pattern string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	})
+}
+
+// macPrefix is the parsed entry of Config.InterfaceMACPrefixes
+type macPrefix struct {
+	addr net.HardwareAddr
+	bits int
+}
+
+func parseMACPrefix(s string) (macPrefix, error) {
+	addrStr, bitsStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return macPrefix{}, fmt.Errorf("missing prefix length in %q", s)
+	}
+
+	bits, err := strconv.Atoi(bitsStr)
+	if err != nil || bits < 1 || bits > 48 {
+		return macPrefix{}, fmt.Errorf("invalid prefix length in %q", s)
+	}
+
+	octets := strings.Split(addrStr, ":")
+	if len(octets) > 6 || len(octets)*8 < bits {
+		return macPrefix{}, fmt.Errorf("invalid number of octets in %q", s)
+	}
+
+	addr := net.HardwareAddr{}
+	for _, octet := range octets {
+		b, err := hex.DecodeString(octet)
+		if err != nil || len(b) != 1 {
+			return macPrefix{}, fmt.Errorf("invalid octet %q in %q", octet, s)
+		}
+		addr = append(addr, b[0])
+	}
+
+	p := macPrefix{addr: addr, bits: bits}
+	if !bytes.Equal(addr, p.masked()) {
+		return macPrefix{}, fmt.Errorf("non-zero bits after the prefix length in %q", s)
+	}
+
+	return p, nil
+}
+
+// masked returns the address with the bits after the prefix length cleared
+func (p macPrefix) masked() net.HardwareAddr {
+	masked := slices.Clone(p.addr)
+	for i := range masked {
+		switch {
+		case (i+1)*8 <= p.bits:
+		case i*8 >= p.bits:
+			masked[i] = 0
+		default:
+			masked[i] &= byte(0xff << (8 - p.bits%8))
+		}
+	}
+	return masked
+}
+
+// contains returns true if the MAC address has the prefix. The prefix must be
+// masked.
+func (p macPrefix) contains(addr net.HardwareAddr) bool {
+	if len(addr) < len(p.addr) {
+		return false
+	}
+	return bytes.Equal(macPrefix{addr: addr[:len(p.addr)], bits: p.bits}.masked(), p.addr)
+}
+
+// matchesMACPrefixes returns true if the MAC address has any of the prefixes.
+// The prefixes must be validated beforehand.
+func matchesMACPrefixes(prefixes []string, addr net.HardwareAddr) bool {
+	return slices.ContainsFunc(prefixes, func(s string) bool {
+		p, err := parseMACPrefix(s)
+		return err == nil && p.contains(addr)
+	})
 }
 
 // InterfaceConfig represents the interface-specific configuration parameters
 type InterfaceConfig struct {
-	// Required: Network interface name. Must be unique within the configuration.
-	Name string `yaml:"name" json:"name" validate:"required"`
+	// Required: Network interface name. Must be unique within the
+	// configuration. Must be a valid Linux interface name, that is, at
+	// most 15 bytes long, not "." or "..", and must not contain "/", ":",
+	// or whitespaces.
+	//
+	// For a bridge, the MAC address of the bridge device itself (not the
+	// members) is advertised as the Source Link-Layer Address. It follows
+	// the changes caused by the members joining or leaving.
+	Name string `yaml:"name" json:"name" validate:"required,ifname"`
 
 	// Required: Interval between sending unsolicited RA. Must be >= 70 and
 	// <= 1800000. Default is 600000. The upper bound is chosen to be
@@ -37,12 +160,113 @@ type InterfaceConfig struct {
 	// higher than 3000 as RFC4861 suggests.
 	RAIntervalMilliseconds int `yaml:"raIntervalMilliseconds" json:"raIntervalMilliseconds" validate:"required,gte=70,lte=1800000" default:"600000"`
 
+	// Number of the unsolicited RAs sent at InitialRAIntervalMilliseconds
+	// when the advertisement starts, so that the hosts learn the router
+	// quickly (RFC4861 Section 6.2.4). The burst restarts when the
+	// configuration of the interface is changed by the reload or the
+	// advertisement is resumed by Daemon.ResumeAll, and continues
+	// otherwise. Must be >= 0 and <= 3. Default is 0 which means no
+	// burst.
+	InitialRACount int `yaml:"initialRACount" json:"initialRACount" validate:"gte=0,lte=3"`
+
+	// Interval between the RAs of the initial burst. The RAIntervalMilliseconds
+	// is used instead if it's shorter. Must be >= 70 and <= 16000. Default
+	// is 16000 (MAX_INITIAL_RTR_ADVERT_INTERVAL of RFC4861).
+	InitialRAIntervalMilliseconds int `yaml:"initialRAIntervalMilliseconds" json:"initialRAIntervalMilliseconds" validate:"required,gte=70,lte=16000" default:"16000"`
+
+	// Do everything except transmitting the RAs on the wire. The RAs are
+	// still built and counted in the status. This is useful to run the
+	// daemon alongside another RA daemon during the migration and
+	// compare the results before the cutover. Default is false.
+	ShadowMode bool `yaml:"shadowMode" json:"shadowMode"`
+
+	// Randomize each interval between the unsolicited RAs uniformly
+	// within +/- this percentage of the RAIntervalMilliseconds. This
+	// avoids the synchronization of the RAs from the multiple routers.
+	// Must be >= 0 and <= 50. Default is 0 which means no jitter.
+	JitterPercent int `yaml:"jitterPercent" json:"jitterPercent" validate:"gte=0,lte=50"`
+
+	// Number of times to retry sending the RA when the send fails with
+	// the transient error (ENOBUFS or EAGAIN). The retries are done with
+	// the exponential backoff starting from 10ms. Must be >= 0 and <= 5.
+	// Default is 3. Set to 0 to disable the retries.
+	SendRetries *int `yaml:"sendRetries" json:"sendRetries" validate:"required,gte=0,lte=5" default:"3"`
+
+	// Report the interface as RS-silent when no RS is received within
+	// this window in seconds. The hosts on the healthy link solicit the
+	// RA occasionally, so the silence may indicate the bridging or
+	// filtering problem. This is purely diagnostic and doesn't affect
+	// the advertisement. Must be >= 0. Default is 0 which means disabled.
+	RSSilenceWindowSeconds int `yaml:"rsSilenceWindowSeconds" json:"rsSilenceWindowSeconds" validate:"gte=0"`
+
+	// Option types to omit from the RAs while keeping their configuration
+	// (e.g. to suppress RDNSS on this interface only). Must be one of
+	// "slla" (Source Link-Layer Address), "mtu", "prefix", "route",
+	// "rdnss", "dnssl", "pref64", "vendor", and "send". Case-insensitive.
+	// Default is empty.
+	DisabledOptions []string `yaml:"disabledOptions" json:"disabledOptions" validate:"unique,dive,oneof=slla mtu prefix route rdnss dnssl pref64 vendor send"`
+
+	// Include the Source Link-Layer Address option only in the unicast
+	// replies to RS to reduce the size of the multicast RAs. The hosts
+	// learn the link-layer address from the reply without the address
+	// resolution. The reply to the RS from the unspecified address is
+	// multicast, so it doesn't have the option either. Must not be set
+	// when "slla" is in DisabledOptions. Default is false.
+	SLLAInSolicitedOnly bool `yaml:"sllaInSolicitedOnly" json:"sllaInSolicitedOnly" validate:"slla_not_disabled"`
+
+	// Index of the network interface. When set, the daemon watches and
+	// binds the socket to the interface with this index instead of
+	// resolving the Name, and the Name is only used for display. This is
+	// useful when the name is ambiguous (e.g. renamed interfaces).
+	// Changing it restarts the advertisement on the interface. Must be
+	// > 0 if set. Default is 0 which means resolving the Name.
+	Index int `yaml:"index" json:"index" validate:"omitempty,gt=0"`
+
+	// Name of the VRF device the interface is enslaved to. When set, the
+	// daemon makes sure the interface belongs to the VRF before sending
+	// RAs. Changing it recreates the socket. Default is empty which means
+	// the default VRF.
+	VRF string `yaml:"vrf" json:"vrf"`
+
+	// Path to the network namespace the interface belongs to (e.g.
+	// /var/run/netns/router). When set, the device is watched and the
+	// socket is created within the namespace. Changing it restarts the
+	// advertisement on the interface. Must be an existing file if set.
+	// Default is empty which means the namespace of the daemon.
+	NetnsPath string `yaml:"netnsPath" json:"netnsPath" validate:"omitempty,file"`
+
+	// IPv6 address the RAs are sent from. Must be assigned to the
+	// interface. RFC4861 Section 4.2 requires the link-local source, so a
+	// non-link-local address is rejected unless AllowNonLinkLocalSource
+	// is set. Changing it recreates the socket. Default is empty which
+	// means the link-local address of the interface.
+	SourceAddress string `yaml:"sourceAddress" json:"sourceAddress" validate:"omitempty,ipv6,link_local_source"`
+
+	// Allow SourceAddress to be a non-link-local unicast address. This is
+	// an advanced option for the special cases such as the lab testing.
+	// The hosts compliant to RFC4861 silently discard such RAs, so it
+	// always yields a warning. Default is false.
+	AllowNonLinkLocalSource bool `yaml:"allowNonLinkLocalSource" json:"allowNonLinkLocalSource"`
+
+	// Set net.ipv6.conf.<interface>.accept_ra to 0 when the advertisement
+	// starts on the interface. Otherwise, the daemon only warns when it
+	// is enabled as the router may configure itself from the RAs. Default
+	// is false.
+	DisableAcceptRA bool `yaml:"disableAcceptRA" json:"disableAcceptRA"`
+
+	// Override the log level of the daemon logger for this interface. Must
+	// be one of "debug", "info", "warn", or "error" if set. Default is
+	// empty which means the daemon logger's level is used.
+	LogLevel string `yaml:"logLevel" json:"logLevel" validate:"omitempty,oneof=debug info warn error"`
+
 	// RA header fields
 
 	// The default value that should be placed in the Hop Count field of
 	// the IP header for outgoing IP packets. Must be >= 0 and <= 255.
-	// Default is 0. If set to zero, it means the reachable time is
-	// unspecified by this router.
+	// Default is 0. If set to zero, it means the hop limit is unspecified
+	// by this router and the hosts keep using their default. The zero is
+	// advertised as is, so the unset field and the intentional zero are
+	// not distinguished. Both mean unspecified.
 	CurrentHopLimit int `yaml:"currentHopLimit" json:"currentHopLimit" validate:"gte=0,lte=255" default:"0"`
 
 	// Set M (Managed address configuration) flag. When set, it indicates
@@ -53,51 +277,216 @@ type InterfaceConfig struct {
 	// configuration information is available via DHCPv6. Default is false.
 	Other bool `yaml:"other" json:"other"`
 
+	// Set the reserved bits (the lowest two bits of the flags octet) of
+	// the RA header. For conformance testing against the strict hosts
+	// only. RFC4861 requires the sender to set them to zero and the
+	// receiver to ignore them. Must be >= 0 and <= 3. Must be zero
+	// unless AllowReservedBits is set. Default is 0.
+	ReservedBits int `yaml:"reservedBits" json:"reservedBits" validate:"gte=0,lte=3,excluded_unless=AllowReservedBits true"`
+
+	// Acknowledge that ReservedBits is set for testing. Default is false.
+	AllowReservedBits bool `yaml:"allowReservedBits" json:"allowReservedBits"`
+
 	// Set Prf (Default Router Preference) field. Must be one of "low",
-	// "medium", or "high". If RouterLifetimeSeconds is 0, it must be set
-	// to "medium". Default is "medium".
+	// "medium", or "high" (case-insensitive). If RouterLifetimeSeconds is
+	// 0, it must be set to "medium". Default is "medium".
 	Preference string `yaml:"preference" json:"preference" validate:"eq_if medium RouterLifetimeSeconds 0,oneof=low medium high" default:"medium"`
 
+	// Override Prf (Default Router Preference) field of the RA sent in
+	// reply to RS. This is useful to attract the specific solicitors
+	// while keeping the multicast RA with Preference. Must be one of
+	// "low", "medium", or "high" (case-insensitive) if set. If
+	// RouterLifetimeSeconds is 0, it must be set to "medium". Default is
+	// empty which means the Preference is used.
+	SolicitedPreference string `yaml:"solicitedPreference" json:"solicitedPreference" validate:"omitempty,eq_if medium RouterLifetimeSeconds 0,oneof=low medium high"`
+
+	// Number of times to send the RA in reply to RS. The repeated RAs are
+	// spaced by 50ms. This is useful for the lossy links (e.g. wireless)
+	// since RAs are not acknowledged. Must be >= 1 and <= 5. Default is 1.
+	SolicitedRARepeat int `yaml:"solicitedRARepeat" json:"solicitedRARepeat" validate:"required,gte=1,lte=5" default:"1"`
+
+	// Maximum number of RSes replied per source address within
+	// SolicitedRateLimitWindowSeconds. This mitigates a single host
+	// flooding RSes. The excess RSes are not replied and counted in the
+	// status. The RSes from the unspecified address are not limited. Must
+	// be >= 0. Default is 0 which means no limit.
+	SolicitedRateLimit int `yaml:"solicitedRateLimit" json:"solicitedRateLimit" validate:"gte=0"`
+
+	// The window of SolicitedRateLimit in seconds. Must be >= 1 and <=
+	// 3600. Default is 60.
+	SolicitedRateLimitWindowSeconds int `yaml:"solicitedRateLimitWindowSeconds" json:"solicitedRateLimitWindowSeconds" validate:"required,gte=1,lte=3600" default:"60"`
+
+	// Minimum delay in milliseconds between the multicast RA and the
+	// multicast reply to the RS from the unspecified address
+	// (MIN_DELAY_BETWEEN_RAS in RFC4861 Section 6.2.6, which is 3000).
+	// The RSes within the delay are answered together by a single
+	// multicast RA when the delay passes. See Daemon.RateLimiterState to
+	// inspect the state. Must be >= 0 and <= 60000. Default is 0 which
+	// means the RSes are answered immediately.
+	MinDelayBetweenRAsMilliseconds int `yaml:"minDelayBetweenRAsMilliseconds" json:"minDelayBetweenRAsMilliseconds" validate:"gte=0,lte=60000"`
+
+	// Include the Prefix Information options in the RA sent in reply to
+	// RS. Setting it to false makes the unicast replies lean (e.g. only
+	// the router lifetime and DNS information) while the multicast RAs
+	// keep carrying the full prefixes. Default is true.
+	IncludePrefixesInSolicited *bool `yaml:"includePrefixesInSolicited" json:"includePrefixesInSolicited" validate:"required" default:"true"`
+
+	// Tailor the RA sent in reply to RS from the specific solicitors. The
+	// key is the IPv6 source address of the RS (without zone). The
+	// override applies only to the unicast replies. Default is empty.
+	SolicitorOverrides map[string]*SolicitorOverrideConfig `yaml:"solicitorOverrides" json:"solicitorOverrides" validate:"dive,keys,ipv6,endkeys,required"`
+
+	// Named alternative sets of the options staged for the interface
+	// (e.g. "blue" and "green"). The alias is switched with
+	// Daemon.ActivateAlias without reloading the configuration. While no
+	// alias is active, the options of the interface itself are
+	// advertised. The key must not be empty. Default is empty.
+	Aliases map[string]*AliasConfig `yaml:"aliases" json:"aliases" validate:"dive,keys,required,endkeys,required"`
+
 	// The lifetime associated with the default router in seconds. Must be
 	// >= 0 and <= 65535. Default is 0. The upper bound is chosen to be
 	// compliant to the RFC8319. If set to zero, the router is not
-	// considered as a default router.
+	// considered as a default router. Routes are still advertised in this
+	// case, so that the hosts use the router only for the specific
+	// routes (RFC4191 Section 3).
 	RouterLifetimeSeconds int `yaml:"routerLifetimeSeconds" json:"routerLifetimeSeconds" validate:"gte=0,lte=65535"`
 
+	// Acknowledge that RouterLifetimeSeconds exceeds 9000, the upper bound
+	// of RFC4861. Otherwise, such lifetime yields a warning since the
+	// hosts which don't implement RFC8319 may clamp or ignore it. Default
+	// is false.
+	AllowExtendedRouterLifetime bool `yaml:"allowExtendedRouterLifetime" json:"allowExtendedRouterLifetime"`
+
 	// The time, in milliseconds, that a node assumes a neighbor is
 	// reachable after having received a reachability confirmation. Must be
 	// >= 0 and <= 4294967295. Default is 0. If set to zero, it means the
-	// reachable time is unspecified by this router.
-	ReachableTimeMilliseconds int `yaml:"reachableTimeMilliseconds" json:"reachableTimeMilliseconds" validate:"gte=0,lte=4294967295"`
+	// reachable time is unspecified by this router. Zero is advertised as
+	// is, so that the hosts keep using their own default.
+	ReachableTimeMilliseconds int `yaml:"reachableTimeMilliseconds" json:"reachableTimeMilliseconds" validate:"gte=0,lte=4294967295" default:"0"`
 
 	// The time, in milliseconds, between retransmitted Neighbor
 	// Solicitation messages. Must be >= 0 and <= 4294967295. Default is 0.
 	// If set to zero, it means the retransmission time is unspecified by
-	// this router.
-	RetransmitTimeMilliseconds int `yaml:"retransmitTimeMilliseconds" json:"retransmitTimeMilliseconds" validate:"gte=0,lte=4294967295"`
+	// this router. Zero is advertised as is, so that the hosts keep using
+	// their own default.
+	RetransmitTimeMilliseconds int `yaml:"retransmitTimeMilliseconds" json:"retransmitTimeMilliseconds" validate:"gte=0,lte=4294967295" default:"0"`
+
+	// Override ReachableTimeMilliseconds of the RA sent in reply to RS.
+	// This is useful to experiment with the ND timers of the hosts
+	// without affecting the multicast RA. Must be >= 0 and <= 4294967295
+	// if set. Default is unset which means ReachableTimeMilliseconds is
+	// used.
+	SolicitedReachableTimeMilliseconds *int `yaml:"solicitedReachableTimeMilliseconds" json:"solicitedReachableTimeMilliseconds" validate:"omitempty,gte=0,lte=4294967295"`
+
+	// Override RetransmitTimeMilliseconds of the RA sent in reply to RS.
+	// Must be >= 0 and <= 4294967295 if set. Default is unset which means
+	// RetransmitTimeMilliseconds is used.
+	SolicitedRetransmitTimeMilliseconds *int `yaml:"solicitedRetransmitTimeMilliseconds" json:"solicitedRetransmitTimeMilliseconds" validate:"omitempty,gte=0,lte=4294967295"`
 
 	// The maximum transmission unit (MTU) that should be used for outgoing
 	// This value specifies the largest packet size, in bytes,
-	// If set to zero or not specified, MTU opton will not be advertised
-	MTU int `yaml:"mtu" json:"mtu" validate:"gte=0,lte=4294967295"`
+	// If set to zero or not specified, MTU opton will not be advertised.
+	// Otherwise, must be >= 1280 (the IPv6 minimum MTU) and <= 4294967295.
+	MTU int `yaml:"mtu" json:"mtu" validate:"gte=0,lte=4294967295,ipv6_min_mtu"`
+
+	// Advertise the smallest MTU of these interfaces (e.g. the interfaces
+	// the stacked tunnels run over) as the MTU option. The option is
+	// updated as their MTUs change. The MTU smaller than the IPv6 minimum
+	// MTU is advertised as 1280. The MTU option is not advertised until
+	// the MTU of any of them is known. Must be the unique valid interface
+	// names other than the interface itself and must not be set with MTU.
+	// Default is empty.
+	MTUFromInterfaces []string `yaml:"mtuFromInterfaces" json:"mtuFromInterfaces" validate:"omitempty,excluded_with=MTU,unique,excludes_self,dive,ifname"`
+
+	// Pad the RA (ICMPv6 message without the IPv6 header) to this size in
+	// bytes with the trailing padding options. This is a workaround for
+	// the legacy clients which need the fixed size RA. The padding
+	// option uses the experimental option type 253 (RFC4727) filled with
+	// zeros, so that the other hosts ignore it. The size is rounded up to
+	// the multiple of 8 octets. Must not be smaller than the RA without
+	// padding and must fit into the MTU (or the IPv6 minimum MTU if the
+	// MTU is not set). Default is 0 which means no padding.
+	PadToBytes int `yaml:"padToBytes" json:"padToBytes" validate:"gte=0,lte=65535"`
+
+	// Split the RDNSS and DNSSL options into multiple options when the RA
+	// doesn't fit into the MTU (or the IPv6 minimum MTU if the MTU is not
+	// set). The addresses and domain names are chunked so that each RA
+	// fits. The unsolicited RA carries as many of them as fit and the rest
+	// is carried by the additional RAs which only have the DNS options and
+	// are sent only in reply to RS. Must not be set with PadToBytes.
+	// Default is false.
+	SplitDNSOptions bool `yaml:"splitDNSOptions" json:"splitDNSOptions" validate:"excluded_with=PadToBytes"`
+
+	// Advertise an auto-generated RFC4193 ULA prefix when no prefix is
+	// configured. The /48 prefix is generated from AutoULASeed and the /64
+	// prefix advertised on the interface is derived from it with the
+	// Subnet ID generated from the interface name. The prefix is
+	// advertised with L and A flags set and the default lifetimes.
+	// Default is false.
+	AutoULA bool `yaml:"autoULA" json:"autoULA"`
+
+	// Seed of the ULA prefix generation. The same seed always generates
+	// the same prefix. If empty, the content of /etc/machine-id is used.
+	AutoULASeed string `yaml:"autoULASeed" json:"autoULASeed"`
+
+	// Number of the unsolicited RAs advertising the prefix removed from
+	// the Prefixes by reload with zero lifetimes before dropping it. The
+	// repeats are spaced by the RA interval, so that the hosts deprecate
+	// the prefix even if some RAs are lost. Must be >= 0. Default is 0
+	// which means the removed prefix is dropped immediately.
+	WithdrawalRepeat int `yaml:"withdrawalRepeat" json:"withdrawalRepeat" validate:"gte=0"`
+
+	// Clear L (On-Link) flag of all prefixes advertised on the interface
+	// regardless of their OnLink (e.g. on the L3-only segments where the
+	// hosts must send everything to the router). This also applies to the
+	// prefixes generated by AutoULA and PrefixRotation. Default is false.
+	ForceOffLink bool `yaml:"forceOffLink" json:"forceOffLink"`
 
 	// Prefix-specific configuration parameters. The prefix fields must be
 	// non-overlapping with each other. The slice itself and elements must
 	// not be nil.
 	Prefixes []*PrefixConfig `yaml:"prefixes" json:"prefixes" validate:"non_overlapping_prefix,dive,required" default:"[]"`
 
+	// Ratio of the preferred lifetime to the valid lifetime of the
+	// prefixes. When set, the PreferredLifetimeSeconds of the prefix is
+	// computed as the ValidLifetimeSeconds multiplied by this ratio
+	// (rounded down) if the former is omitted and the latter is set. This
+	// also applies to the prefixes of the SolicitorOverrides and the
+	// Aliases. Must be > 0 and <= 1. Default is unset which means the
+	// default PreferredLifetimeSeconds is used.
+	PreferredValidRatio float64 `yaml:"preferredValidRatio" json:"preferredValidRatio" validate:"omitempty,gt=0,lte=1"`
+
 	// Route-specific configuration parameters. The prefix fields must not
 	// be the same each other. The slice itself and elements must not be nil.
 	Routes []*RouteConfig `yaml:"routes" json:"routes" validate:"unique=Prefix,dive,required" default:"[]"`
 
-	// RDNSS-specific configuration parameters.
-	RDNSSes []*RDNSSConfig `yaml:"rdnsses" json:"rdnsses" validate:"dive,required" default:"[]"`
+	// RDNSS-specific configuration parameters. Each element is advertised
+	// as a separate RDNSS option with its own lifetime. The same address
+	// must not appear in multiple elements.
+	RDNSSes []*RDNSSConfig `yaml:"rdnsses" json:"rdnsses" validate:"unique_rdnss_address,dive,required" default:"[]"`
 
 	// DNSSL-specific configuration parameters.
 	DNSSLs []*DNSSLConfig `yaml:"dnssls" json:"dnssls" validate:"dive,required" default:"[]"`
 
 	// NAT64 prefix-specific configuration parameters.
 	NAT64Prefixes []*NAT64PrefixConfig `yaml:"nat64prefixes" json:"nat64prefixes" validate:"dive,required" default:"[]"`
+
+	// Vendor-specific option configuration parameters.
+	VendorOptions []*VendorOptionConfig `yaml:"vendorOptions" json:"vendorOptions" validate:"dive,required" default:"[]"`
+
+	// Pre-computed Secure Neighbor Discovery (RFC3971) options. They are
+	// advertised as-is after all other options, so that the options
+	// computed by the external signer (e.g. RSA Signature which must be
+	// the last option) can be carried.
+	SENDOptions []*SENDOptionConfig `yaml:"sendOptions" json:"sendOptions" validate:"dive,required" default:"[]"`
+
+	// Rotate the advertised autonomous prefix on a schedule. This is
+	// useful for testing the privacy behavior of the hosts. Optional.
+	PrefixRotation *PrefixRotationConfig `yaml:"prefixRotation" json:"prefixRotation" validate:"omitempty,non_overlapping_rotation"`
+
+	// Advertise the prefixes loaded from the external source (e.g. IPAM
+	// service) in addition to the Prefixes. Optional.
+	PrefixSource *PrefixSourceConfig `yaml:"prefixSource" json:"prefixSource" validate:"omitempty"`
 }
 
 // PrefixConfig represents the prefix-specific configuration parameters
@@ -123,12 +512,194 @@ type PrefixConfig struct {
 	// 4294967295 and must be <= ValidLifetimeSeconds. Default is 604800 (7
 	// days). If set to 4294967295, it indicates infinity.
 	PreferredLifetimeSeconds *int `yaml:"preferredLifetimeSeconds" json:"preferredLifetimeSeconds" validate:"required,gte=0,ltefield=ValidLifetimeSeconds" default:"604800"`
+
+	// Subnet ID of the /64 prefix to carve out of the Prefix. When set,
+	// the /64 prefix is advertised instead of the Prefix itself (e.g.
+	// 2001:db8:0:100::/56 with SubnetID 1 advertises 2001:db8:0:101::/64).
+	// This is useful when the Prefix is delegated to the router and some
+	// of the subnets are reserved for the other purposes. The Prefix
+	// length must be <= 64 and the SubnetID must fit into the bits between
+	// the Prefix length and 64. Default is unset.
+	SubnetID *int `yaml:"subnetID" json:"subnetID" validate:"omitempty,gte=0,subnet_id_fits"`
+
+	// Advertise the prefix only while the interface has a global address
+	// within it to avoid black-holing the traffic of the hosts. The
+	// prefix is withheld from the RAs while there's no such address and
+	// included again once it appears. Default is false.
+	RequireLocalAddress bool `yaml:"requireLocalAddress" json:"requireLocalAddress"`
+
+	// Include the prefix only in every Nth unsolicited RA (the first one,
+	// the N+1th one, and so on) to reduce the size of the RAs for the
+	// stable prefixes. The RAs sent in reply to RS always include it.
+	// Must be >= 0. Default is 0 which means every RA like 1.
+	AdvertiseEveryN int `yaml:"advertiseEveryN" json:"advertiseEveryN" validate:"gte=0"`
+
+	// Decrement the lifetimes in real time from when the prefix is loaded
+	// instead of advertising the fixed values (RFC4861 Section 6.2.1,
+	// AdvValidLifetime and AdvPreferredLifetime). This is useful for the
+	// prefix which expires at the certain time (e.g. delegated by DHCPv6-PD).
+	// The lifetimes stop at zero. The infinite lifetime isn't decremented.
+	// The decrement restarts when the prefix or its lifetimes are changed by
+	// Reload. Default is false.
+	DecrementLifetimes bool `yaml:"decrementLifetimes" json:"decrementLifetimes"`
+}
+
+// advertisedPrefix returns the prefix to advertise. The config must be
+// validated beforehand.
+func (c *PrefixConfig) advertisedPrefix() netip.Prefix {
+	p := netip.MustParsePrefix(c.Prefix)
+	if c.SubnetID == nil {
+		return p
+	}
+	return subnetPrefix(p, uint64(*c.SubnetID))
+}
+
+// infiniteLifetime is the lifetime value which indicates infinity
+const infiniteLifetime = 4294967295
+
+// EffectiveLifetimesAt returns the preferred and valid lifetimes in seconds
+// advertised at now for the prefix loaded at loadedAt. Unless
+// DecrementLifetimes is set, they're the configured lifetimes. Otherwise, the
+// elapsed whole seconds are subtracted and the result is floored at zero. The
+// infinite lifetime is kept as is. The time before loadedAt is treated as
+// loadedAt. The config must be validated beforehand.
+func EffectiveLifetimesAt(p *PrefixConfig, loadedAt, now time.Time) (preferred, valid uint32) {
+	preferred = uint32(*p.PreferredLifetimeSeconds)
+	valid = uint32(*p.ValidLifetimeSeconds)
+	if !p.DecrementLifetimes || !now.After(loadedAt) {
+		return preferred, valid
+	}
+
+	elapsed := uint64(now.Sub(loadedAt) / time.Second)
+	decrement := func(lifetime uint32) uint32 {
+		if lifetime == infiniteLifetime {
+			return lifetime
+		}
+		if elapsed >= uint64(lifetime) {
+			return 0
+		}
+		return lifetime - uint32(elapsed)
+	}
+
+	return decrement(preferred), decrement(valid)
+}
+
+// subnetPrefix carves the /64 prefix with the given Subnet ID out of the
+// prefix
+func subnetPrefix(p netip.Prefix, subnetID uint64) netip.Prefix {
+	addr := p.Masked().Addr().As16()
+	hi := binary.BigEndian.Uint64(addr[:8]) | subnetID
+	binary.BigEndian.PutUint64(addr[:8], hi)
+	return netip.PrefixFrom(netip.AddrFrom16(addr), 64)
+}
+
+// PrefixesOverlap reports whether the IPv6 prefixes a and b share any
+// address, i.e. one contains the other. This is the check used by the
+// validation of the Prefixes fields. The bits after the prefix length are
+// ignored. It returns an error if either of them is not a valid IPv6 prefix.
+func PrefixesOverlap(a, b string) (bool, error) {
+	p0, err := parseIPv6Prefix(a)
+	if err != nil {
+		return false, err
+	}
+	p1, err := parseIPv6Prefix(b)
+	if err != nil {
+		return false, err
+	}
+	return prefixesOverlap(p0, p1), nil
+}
+
+func parseIPv6Prefix(s string) (netip.Prefix, error) {
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if !p.Addr().Is6() || p.Addr().Is4In6() {
+		return netip.Prefix{}, fmt.Errorf("%s is not an IPv6 prefix", s)
+	}
+	return p, nil
+}
+
+// prefixesOverlap is PrefixesOverlap for the parsed prefixes
+func prefixesOverlap(p0, p1 netip.Prefix) bool {
+	return p0.Masked().Overlaps(p1.Masked())
+}
+
+// PrefixRotationConfig represents the prefix rotation parameters. Only one of
+// the Prefixes is advertised at a time with L and A flags set and the default
+// lifetimes. Every IntervalSeconds, the next prefix in the list is advertised
+// and the last advertised one is withdrawn by advertising it with zero
+// lifetimes. The rotation wraps around at the end of the list.
+//
+// The withdrawal deprecates the addresses on the hosts immediately, but
+// doesn't remove them. RFC4862 Section 5.5.3 (e) doesn't allow the
+// unauthenticated RA to reduce the remaining valid lifetime below 2 hours, so
+// the addresses stay valid for up to 2 hours after the rotation.
+type PrefixRotationConfig struct {
+	// Required: Prefixes to rotate in order. Must be valid IPv6 prefixes
+	// and must not be the same each other. You must specify at least two
+	// prefixes.
+	Prefixes []string `yaml:"prefixes" json:"prefixes" validate:"required,unique,min=2,dive,cidrv6"`
+
+	// Required: Interval of the rotation in seconds. Must be >= 1.
+	IntervalSeconds int `yaml:"intervalSeconds" json:"intervalSeconds" validate:"required,gte=1"`
+}
+
+// PrefixSourceConfig represents the external source of the prefixes. The URL
+// is polled with HTTP GET and must return a JSON list of the IPv6 prefixes
+// (e.g. ["2001:db8::/64"]). The prefixes are advertised with L and A flags
+// set and the default lifetimes. The prefixes removed from the list are
+// advertised with zero lifetimes until the next poll. When the poll fails,
+// the previous prefixes are kept. The prefixes which are also in the Prefixes
+// of the interface are advertised with that configuration instead.
+type PrefixSourceConfig struct {
+	// Required: HTTP(S) URL of the source
+	URL string `yaml:"url" json:"url" validate:"required,http_url"`
+
+	// Interval of the polling in seconds. Must be >= 1. Default is 60.
+	PollIntervalSeconds int `yaml:"pollIntervalSeconds" json:"pollIntervalSeconds" validate:"required,gte=1" default:"60"`
+}
+
+// SolicitorOverrideConfig represents the partial overrides of the RA sent to
+// the specific solicitor. The fields left nil are taken from the interface
+// configuration.
+type SolicitorOverrideConfig struct {
+	// Prefixes to advertise instead of the Prefixes of the interface.
+	// Same constraints as the Prefixes of the interface apply.
+	Prefixes []*PrefixConfig `yaml:"prefixes" json:"prefixes" validate:"omitempty,non_overlapping_prefix,dive,required"`
+
+	// RDNSSes to advertise instead of the RDNSSes of the interface. Same
+	// constraints as the RDNSSes of the interface apply.
+	RDNSSes []*RDNSSConfig `yaml:"rdnsses" json:"rdnsses" validate:"omitempty,unique_rdnss_address,dive,required"`
+}
+
+// AliasConfig represents the set of the options advertised while the alias is
+// active. The fields left nil are taken from the interface configuration.
+type AliasConfig struct {
+	// Prefixes to advertise instead of the Prefixes of the interface.
+	// Same constraints as the Prefixes of the interface apply.
+	Prefixes []*PrefixConfig `yaml:"prefixes" json:"prefixes" validate:"omitempty,non_overlapping_prefix,dive,required"`
+
+	// Routes to advertise instead of the Routes of the interface. Same
+	// constraints as the Routes of the interface apply.
+	Routes []*RouteConfig `yaml:"routes" json:"routes" validate:"omitempty,unique=Prefix,dive,required"`
+
+	// RDNSSes to advertise instead of the RDNSSes of the interface. Same
+	// constraints as the RDNSSes of the interface apply.
+	RDNSSes []*RDNSSConfig `yaml:"rdnsses" json:"rdnsses" validate:"omitempty,unique_rdnss_address,dive,required"`
+
+	// DNSSLs to advertise instead of the DNSSLs of the interface. Same
+	// constraints as the DNSSLs of the interface apply.
+	DNSSLs []*DNSSLConfig `yaml:"dnssls" json:"dnssls" validate:"omitempty,dive,required"`
 }
 
 // RouteConfig represents the route-specific configuration parameters
 type RouteConfig struct {
-	// Required: Prefix. Must be a valid IPv6 prefix.
-	Prefix string `yaml:"prefix" json:"prefix" validate:"required,cidrv6"`
+	// Required: Prefix. Must be a valid IPv6 prefix. Unlike the prefix in
+	// PrefixConfig, the prefix length can be anything between 0 and 128
+	// (e.g. /128 for the host route). The bits after the prefix length
+	// must be zero.
+	Prefix string `yaml:"prefix" json:"prefix" validate:"required,cidrv6,masked_prefix"`
 
 	// Required: The valid lifetime of the route in seconds. Must be >= 0
 	// and <= 4294967295. If set to 4294967295, it indicates infinity.
@@ -137,7 +708,8 @@ type RouteConfig struct {
 	// Set Prf (Route Preference) field. It indicates whether to prefer the
 	// router associated with this prefix over others, when multiple
 	// identical prefixes (for different routers) have been received. Must
-	// be one of "low", "medium", or "high". Default is "medium".
+	// be one of "low", "medium", or "high" (case-insensitive). Default is
+	// "medium".
 	Preference string `yaml:"preference" json:"preference" validate:"oneof=low medium high" default:"medium"`
 }
 
@@ -147,8 +719,11 @@ type RDNSSConfig struct {
 	// addresses may be used for name resolution.
 	LifetimeSeconds int `yaml:"lifetimeSeconds" json:"lifetimeSeconds" validate:"required,gte=0,lte=4294967295"`
 
-	// Required: The addresses of the RDNSS servers. You must specify at least one address.
-	Addresses []string `yaml:"addresses" json:"addresses" validate:"required,unique,min=1,dive,ipv6"`
+	// Required: The addresses of the RDNSS servers. You must specify at
+	// least one address. Must be <= 127 addresses since the option length
+	// (8 octets header and 16 octets per address) is encoded in 8 bits in
+	// units of 8 octets.
+	Addresses []string `yaml:"addresses" json:"addresses" validate:"required,unique,min=1,max=127,dive,ipv6"`
 }
 
 // DNSSLConfig represents the DNSSL-specific configuration parameters
@@ -158,6 +733,10 @@ type DNSSLConfig struct {
 	LifetimeSeconds int `yaml:"lifetimeSeconds" json:"lifetimeSeconds" validate:"required,gte=0,lte=4294967295"`
 
 	// Required: The domain names to be used for DNS search list. You must specify at least one domain name.
+	// The internationalized domain names must be written in the
+	// ASCII-compatible form (A-labels, e.g. "xn--bcher-kva.example"
+	// instead of "bücher.example") since the RA carries them as is. The
+	// raw Unicode domain names are rejected rather than converted.
 	DomainNames []string `yaml:"domainNames" json:"domainNames" validate:"required,unique,min=1,dive,domain"`
 }
 
@@ -172,19 +751,124 @@ type NAT64PrefixConfig struct {
 	// Should not be shorter than Router Lifetime. This lifetime is encoded
 	// in units of 8-seconds increments as ScaledLifetime.
 	LifetimeSeconds *int `yaml:"lifetimeSeconds" json:"lifetimeSeconds" validate:"required,gte=0,lte=65528" default:"65528"`
+
+	// Advertise the NAT64 prefix only in the RS replies, not in the
+	// unsolicited RAs. Useful to roll out the new NAT64 prefix to the
+	// subset of the hosts (e.g. the newly attached ones) before promoting
+	// it to all hosts. Default is false.
+	SolicitedOnly bool `yaml:"solicitedOnly" json:"solicitedOnly"`
+}
+
+// VendorOptionConfig represents the vendor-specific option configuration
+// parameters. The option is encoded as below. The payload is padded with
+// zeros to the multiple of 8 octets.
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     Type      |    Length     |           Reserved            |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                       Enterprise Number                       |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                       Payload (variable)                      |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+type VendorOptionConfig struct {
+	// Required: ND option type. Must be an IANA-assigned or experimental
+	// (253 or 254, RFC4727) type. Must not be the type of the options
+	// the daemon understands (e.g. Prefix Information).
+	Type int `yaml:"type" json:"type" validate:"required,gte=1,lte=255,unknown_option_type"`
+
+	// Required: IANA Private Enterprise Number of the vendor. Must be >= 1
+	// and <= 4294967295.
+	EnterpriseNumber int `yaml:"enterpriseNumber" json:"enterpriseNumber" validate:"required,gte=1,lte=4294967295"`
+
+	// Hex-encoded payload. Must be <= 240 octets. Default is empty.
+	Payload string `yaml:"payload" json:"payload" validate:"omitempty,hexadecimal,vendor_payload_len"`
+}
+
+// SENDOptionConfig represents the pre-computed Secure Neighbor Discovery
+// (RFC3971) option. The daemon doesn't interpret the content.
+type SENDOptionConfig struct {
+	// Required: ND option type. Must be one of 11 (CGA), 12 (RSA
+	// Signature), or 13 (Timestamp).
+	Type int `yaml:"type" json:"type" validate:"required,oneof=11 12 13"`
+
+	// Required: Hex-encoded option data following the Type and Length
+	// fields including the padding. The whole option (the 2 octets
+	// header and the data) must be a multiple of 8 octets and must be <=
+	// 248 octets.
+	Data string `yaml:"data" json:"data" validate:"required,hexadecimal,send_option_len"`
 }
 
 // ValidationErrors is a type alias for the validator.ValidationErrors
 type ValidationErrors = validator.ValidationErrors
 
+// ND option types the daemon understands. The vendor-specific option must not
+// use these types.
+var knownOptionTypes = map[int64]bool{
+	1:  true, // Source Link-Layer Address
+	2:  true, // Target Link-Layer Address
+	3:  true, // Prefix Information
+	5:  true, // MTU
+	14: true, // Nonce
+	24: true, // Route Information
+	25: true, // RDNSS
+	26: true, // RA Flags Extension
+	31: true, // DNSSL
+	37: true, // Captive Portal
+	38: true, // PREF64
+}
+
+// The maximum length of the raw option. The option length is limited to 31
+// units of 8 octets by the underlying library.
+const maxOptionLen = 31 * 8
+
+// The maximum length of the vendor-specific option payload. The header takes
+// 8 octets.
+const maxVendorPayloadLen = maxOptionLen - 8
+
+// The maximum length of the Linux interface name (IFNAMSIZ - 1)
+const maxIfNameLen = 15
+
 // Regular expression to validate the domain name in DNSSL configuration
 var domainRegexp = regexp.MustCompile(`^(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z0-9][a-z0-9-]{0,61}[a-z0-9]$`)
 
 func (c *Config) defaultAndValidate() error {
+	c.applyPreferredValidRatio()
+
 	if err := defaults.Set(c); err != nil {
 		panic("BUG (Please report 🙏): Defaulting failed: " + err.Error())
 	}
 
+	c.normalize()
+
+	return validationError(newValidator().Struct(c))
+}
+
+// ValidateInterfaceConfig validates the single interface configuration in
+// isolation. Unlike the validation done by the Daemon, it doesn't check the
+// constraints across the interfaces (e.g. the uniqueness of the Name). The
+// defaults are applied to the copy of the configuration, so ic is not
+// modified. The error is ValidationErrors when the configuration is invalid.
+func ValidateInterfaceConfig(ic *InterfaceConfig) error {
+	if ic == nil {
+		return errors.New("interface configuration must not be nil")
+	}
+
+	c := &Config{Interfaces: []*InterfaceConfig{ic.deepCopy()}}
+	c.applyPreferredValidRatio()
+
+	if err := defaults.Set(c); err != nil {
+		panic("BUG (Please report 🙏): Defaulting failed: " + err.Error())
+	}
+
+	c.normalize()
+
+	return validationError(newValidator().Struct(c.Interfaces[0]))
+}
+
+// newValidator returns the validator with the custom validators registered
+func newValidator() *validator.Validate {
 	validate := validator.New(validator.WithRequiredStructEnabled())
 
 	// Adhoc custom validator which validates the Prefix fields are non-overlapping with each other.
@@ -201,13 +885,22 @@ func (c *Config) defaultAndValidate() error {
 				continue
 			}
 
+			// Compare the carved out /64 when the Subnet ID is set
+			if subnetID := prefixSlice.Index(i).Elem().FieldByName("SubnetID"); !subnetID.IsNil() {
+				if p.Bits() > 64 {
+					// subnet_id_fits constraint will catch it later.
+					continue
+				}
+				p = subnetPrefix(p, uint64(subnetID.Elem().Int()))
+			}
+
 			prefixes = append(prefixes, p)
 		}
 
 		// Check the prefix is not overlapping with each other
 		for _, p0 := range prefixes {
 			for _, p1 := range prefixes {
-				if p0 != p1 && p0.Overlaps(p1) {
+				if p0 != p1 && prefixesOverlap(p0, p1) {
 					return false
 				}
 			}
@@ -216,6 +909,76 @@ func (c *Config) defaultAndValidate() error {
 		return true
 	})
 
+	// Adhoc custom validator which validates the rotating prefixes are not
+	// overlapping with the Prefixes of the interface. The overlapping
+	// prefix would be withdrawn by the rotation.
+	validate.RegisterValidation("non_overlapping_rotation", func(fl validator.FieldLevel) bool {
+		rotation, ok := fl.Field().Interface().(PrefixRotationConfig)
+		if !ok {
+			return true
+		}
+		prefixes, ok := fl.Parent().FieldByName("Prefixes").Interface().([]*PrefixConfig)
+		if !ok {
+			return true
+		}
+		for _, r := range rotation.Prefixes {
+			p0, err := netip.ParsePrefix(r)
+			if err != nil {
+				// cidrv6 constraint will catch it later
+				continue
+			}
+			for _, prefix := range prefixes {
+				if prefix == nil {
+					continue
+				}
+				p1, err := netip.ParsePrefix(prefix.Prefix)
+				if err != nil {
+					continue
+				}
+				if prefixesOverlap(p0, p1) {
+					return false
+				}
+			}
+		}
+		return true
+	})
+
+	// Adhoc custom validator which validates the RDNSS addresses are not
+	// duplicated across the RDNSS configurations.
+	validate.RegisterValidation("unique_rdnss_address", func(fl validator.FieldLevel) bool {
+		seen := map[netip.Addr]bool{}
+
+		rdnssSlice := fl.Field()
+		for i := 0; i < rdnssSlice.Len(); i++ {
+			rdnss := rdnssSlice.Index(i)
+			if rdnss.IsNil() {
+				// Just ignore this error here. required constraint will catch it later.
+				continue
+			}
+
+			// Duplicates within the same element are caught by
+			// the unique constraint.
+			addrs := map[netip.Addr]bool{}
+			for _, addr := range rdnss.Elem().FieldByName("Addresses").Interface().([]string) {
+				a, err := netip.ParseAddr(addr)
+				if err != nil {
+					// Just ignore this error here. ipv6 constraint will catch it later.
+					continue
+				}
+				addrs[a] = true
+			}
+
+			for a := range addrs {
+				if seen[a] {
+					return false
+				}
+				seen[a] = true
+			}
+		}
+
+		return true
+	})
+
 	// Adhoc custom validator which validates the value of this field must
 	// be medium if RouterLifetimeSeconds is 0.
 	validate.RegisterValidation("eq_if medium RouterLifetimeSeconds 0", func(fl validator.FieldLevel) bool {
@@ -227,10 +990,151 @@ func (c *Config) defaultAndValidate() error {
 		return true
 	})
 
-	// Adhoc custom validator which validates the string is a valid domain name.
+	// Adhoc custom validator which validates the SLLA option is not in
+	// DisabledOptions if this field is set.
+	validate.RegisterValidation("slla_not_disabled", func(fl validator.FieldLevel) bool {
+		if !fl.Field().Bool() {
+			return true
+		}
+		disabled, ok := fl.Parent().FieldByName("DisabledOptions").Interface().([]string)
+		return !ok || !slices.Contains(disabled, "slla")
+	})
+
+	// Adhoc custom validator which validates the string is a valid domain
+	// name. The A-labels must be valid punycode in the canonical form.
 	validate.RegisterValidation("domain", func(fl validator.FieldLevel) bool {
 		dom := fl.Field().String()
-		return domainRegexp.Match([]byte(dom))
+		if !domainRegexp.Match([]byte(dom)) {
+			return false
+		}
+		for _, label := range strings.Split(dom, ".") {
+			if !strings.HasPrefix(label, "xn--") {
+				continue
+			}
+			// The A-label must survive the round trip
+			u, err := idna.Lookup.ToUnicode(label)
+			if err != nil {
+				return false
+			}
+			a, err := idna.Lookup.ToASCII(u)
+			if err != nil || a != label {
+				return false
+			}
+		}
+		return true
+	})
+
+	// Adhoc custom validator which validates the string is a valid Linux
+	// interface name. See dev_valid_name() in the Linux kernel.
+	validate.RegisterValidation("ifname", func(fl validator.FieldLevel) bool {
+		name := fl.Field().String()
+		if len(name) > maxIfNameLen || name == "." || name == ".." {
+			return false
+		}
+		return !strings.ContainsFunc(name, func(r rune) bool {
+			return r == '/' || r == ':' || unicode.IsSpace(r)
+		})
+	})
+
+	// Adhoc custom validator which validates the string is a valid
+	// pattern of the interface names. The syntax is the one of path.Match
+	// and the characters not allowed in the interface names are rejected.
+	validate.RegisterValidation("ifname_pattern", func(fl validator.FieldLevel) bool {
+		pattern := fl.Field().String()
+		if pattern == "" {
+			return false
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return false
+		}
+		return !strings.ContainsFunc(pattern, func(r rune) bool {
+			return r == '/' || r == ':' || unicode.IsSpace(r)
+		})
+	})
+
+	// Adhoc custom validator which validates the interface names don't
+	// include the name of the interface itself.
+	validate.RegisterValidation("excludes_self", func(fl validator.FieldLevel) bool {
+		names, ok := fl.Field().Interface().([]string)
+		return !ok || !slices.Contains(names, fl.Parent().FieldByName("Name").String())
+	})
+
+	// Adhoc custom validator which validates the source address is a
+	// link-local unicast address, or any unicast address when
+	// AllowNonLinkLocalSource is set.
+	validate.RegisterValidation("link_local_source", func(fl validator.FieldLevel) bool {
+		addr, err := netip.ParseAddr(fl.Field().String())
+		if err != nil || addr.Zone() != "" || addr.Is4In6() {
+			return false
+		}
+		if addr.IsLinkLocalUnicast() {
+			return true
+		}
+		if addr.IsUnspecified() || addr.IsLoopback() || addr.IsMulticast() {
+			return false
+		}
+		return fl.Parent().FieldByName("AllowNonLinkLocalSource").Bool()
+	})
+
+	// Adhoc custom validator which validates the MAC address prefix
+	// (e.g. "00:11:22/24").
+	validate.RegisterValidation("mac_prefix", func(fl validator.FieldLevel) bool {
+		_, err := parseMACPrefix(fl.Field().String())
+		return err == nil
+	})
+
+	// Adhoc custom validator which validates the MTU is zero (not
+	// advertised) or >= IPv6 minimum MTU.
+	validate.RegisterValidation("ipv6_min_mtu", func(fl validator.FieldLevel) bool {
+		mtu := fl.Field().Int()
+		return mtu == 0 || mtu >= ipv6MinMTU
+	})
+
+	// Adhoc custom validator which validates the bits after the prefix
+	// length are zero.
+	validate.RegisterValidation("masked_prefix", func(fl validator.FieldLevel) bool {
+		p := netip.MustParsePrefix(fl.Field().String())
+		return p == p.Masked()
+	})
+
+	// Adhoc custom validator which validates the SubnetID fits into the
+	// bits between the Prefix length and 64.
+	validate.RegisterValidation("subnet_id_fits", func(fl validator.FieldLevel) bool {
+		p, err := netip.ParsePrefix(fl.Parent().FieldByName("Prefix").String())
+		if err != nil {
+			// Just ignore this error here. cidrv6 constraint will catch it.
+			return true
+		}
+		if p.Bits() > 64 {
+			return false
+		}
+		subnetBits := 64 - p.Bits()
+		return subnetBits >= 63 || fl.Field().Int() < 1<<subnetBits
+	})
+
+	// Adhoc custom validator which validates the option type is not the
+	// one the daemon understands.
+	validate.RegisterValidation("unknown_option_type", func(fl validator.FieldLevel) bool {
+		return !knownOptionTypes[fl.Field().Int()]
+	})
+
+	// Adhoc custom validator which validates the decoded payload fits
+	// into the vendor-specific option.
+	validate.RegisterValidation("vendor_payload_len", func(fl validator.FieldLevel) bool {
+		// Just ignore the decode error here. hexadecimal constraint
+		// will catch it.
+		b, _ := hex.DecodeString(fl.Field().String())
+		return len(b) <= maxVendorPayloadLen
+	})
+
+	// Adhoc custom validator which validates the SEND option data forms
+	// the option of the valid length.
+	validate.RegisterValidation("send_option_len", func(fl validator.FieldLevel) bool {
+		// Just ignore the decode error here. hexadecimal constraint
+		// will catch it.
+		b, _ := hex.DecodeString(fl.Field().String())
+		length := 2 + len(b)
+		return length%8 == 0 && length <= maxOptionLen
 	})
 
 	// Adhoc custom validator which validates the prefix length must
@@ -248,22 +1152,87 @@ func (c *Config) defaultAndValidate() error {
 		return validPrefixLengths[p.Bits()]
 	})
 
-	if err := validate.Struct(c); err != nil {
-		if _, ok := err.(*validator.InvalidValidationError); ok {
-			panic("BUG (Please report 🙏): Invalid validation: " + err.Error())
-		}
+	return validate
+}
+
+// validationError converts the error returned from the validator
+func validationError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		panic("BUG (Please report 🙏): Invalid validation: " + err.Error())
+	}
 
-		var verrs ValidationErrors
-		if errors.As(err, &verrs) {
-			return verrs
+	var verrs ValidationErrors
+	if errors.As(err, &verrs) {
+		return verrs
+	}
+
+	// This is impossible, according to the validator's documentation
+	// https://pkg.go.dev/github.com/go-playground/validator/v10#hdr-Validation_Functions_Return_Type_error
+	return err
+}
+
+// applyPreferredValidRatio computes the omitted preferred lifetimes of the
+// prefixes from the PreferredValidRatio. This must be done before the
+// defaulting which fills the omitted preferred lifetimes.
+func (c *Config) applyPreferredValidRatio() {
+	for _, iface := range c.Interfaces {
+		if iface == nil || iface.PreferredValidRatio == 0 {
+			continue
 		}
+		prefixes := slices.Clone(iface.Prefixes)
+		for _, override := range iface.SolicitorOverrides {
+			if override != nil {
+				prefixes = append(prefixes, override.Prefixes...)
+			}
+		}
+		for _, alias := range iface.Aliases {
+			if alias != nil {
+				prefixes = append(prefixes, alias.Prefixes...)
+			}
+		}
+		for _, prefix := range prefixes {
+			if prefix == nil || prefix.PreferredLifetimeSeconds != nil || prefix.ValidLifetimeSeconds == nil {
+				continue
+			}
+			preferred := int(iface.PreferredValidRatio * float64(*prefix.ValidLifetimeSeconds))
+			prefix.PreferredLifetimeSeconds = &preferred
+		}
+	}
+}
 
-		// This is impossible, according to the validator's documentation
-		// https://pkg.go.dev/github.com/go-playground/validator/v10#hdr-Validation_Functions_Return_Type_error
-		return err
+// normalize converts the case-insensitive fields into the canonical form
+// before the validation
+func (c *Config) normalize() {
+	for _, iface := range c.Interfaces {
+		if iface == nil {
+			continue
+		}
+		iface.Preference = strings.ToLower(iface.Preference)
+		iface.SolicitedPreference = strings.ToLower(iface.SolicitedPreference)
+		for i, option := range iface.DisabledOptions {
+			iface.DisabledOptions[i] = strings.ToLower(option)
+		}
+		normalizeRoutes(iface.Routes)
+		for _, alias := range iface.Aliases {
+			if alias == nil {
+				continue
+			}
+			normalizeRoutes(alias.Routes)
+		}
 	}
+}
 
-	return nil
+func normalizeRoutes(routes []*RouteConfig) {
+	for _, route := range routes {
+		if route == nil {
+			continue
+		}
+		route.Preference = strings.ToLower(route.Preference)
+	}
 }
 
 // ParseConfigJSON parses the JSON-encoded configuration from the reader. This
@@ -296,10 +1265,125 @@ func ParseConfigYAML(r io.Reader) (*Config, error) {
 // the given path. This function doesn't validate the configuration. The
 // configuration is validated when you pass it to the Daemon.
 func ParseConfigYAMLFile(path string) (*Config, error) {
-	f, err := os.Open(path)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ParseConfigYAML(bytes.NewReader(b))
+	if err != nil {
+		return nil, newConfigParseError(path, b, err)
+	}
+	return c, nil
+}
+
+// ConfigParseError is returned from ParseConfigYAMLFile when the file cannot
+// be parsed. Line, Column, and Snippet are filled when the YAML library
+// reports the location.
+type ConfigParseError struct {
+	// Path to the file
+	Path string
+
+	// 1-based line number of the error. Zero if unknown.
+	Line int
+
+	// 1-based column number of the error. Zero if unknown.
+	Column int
+
+	// The line of the file at Line. Empty if unknown.
+	Snippet string
+
+	// The original error
+	Err error
+}
+
+func (e *ConfigParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Path, e.Err)
+	}
+	loc := fmt.Sprintf("%s:%d", e.Path, e.Line)
+	if e.Column != 0 {
+		loc += fmt.Sprintf(":%d", e.Column)
+	}
+	return fmt.Sprintf("%s: %s (near %q)", loc, e.Err, e.Snippet)
+}
+
+func (e *ConfigParseError) Unwrap() error {
+	return e.Err
+}
+
+// The YAML library reports the location only in the error message
+var yamlErrorLocationRegex = regexp.MustCompile(`line (\d+)(?:, column (\d+))?`)
+
+func newConfigParseError(path string, b []byte, err error) *ConfigParseError {
+	perr := &ConfigParseError{Path: path, Err: err}
+
+	msg := err.Error()
+
+	// Use the first error for the location when there are many
+	var terr *yaml.TypeError
+	if errors.As(err, &terr) && len(terr.Errors) > 0 {
+		msg = terr.Errors[0]
+	}
+
+	m := yamlErrorLocationRegex.FindStringSubmatch(msg)
+	if m == nil {
+		return perr
+	}
+
+	perr.Line, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		perr.Column, _ = strconv.Atoi(m[2])
+	}
+
+	lines := strings.Split(string(b), "\n")
+	if perr.Line > 0 && perr.Line <= len(lines) {
+		perr.Snippet = strings.TrimRight(lines[perr.Line-1], "\r")
+	}
+
+	return perr
+}
+
+// ParseConfigFromMap parses the configuration from the generic map. This is
+// useful when the configuration is embedded in a larger document which is
+// already decoded. This function doesn't validate the configuration. The
+// configuration is validated when you pass it to the Daemon.
+func ParseConfigFromMap(m map[string]any) (*Config, error) {
+	b, err := yaml.Marshal(m)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	return ParseConfigYAML(f)
+
+	var c Config
+
+	if err := yaml.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// ParseConfigYAMLWithKey parses the YAML-encoded configuration embedded in a
+// larger document from the reader. The key is a dot-separated path to the
+// configuration in the document (e.g. "app.networking.ra"). This function
+// doesn't validate the configuration. The configuration is validated when you
+// pass it to the Daemon.
+func ParseConfigYAMLWithKey(r io.Reader, key string) (*Config, error) {
+	var doc map[string]any
+
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	m := doc
+	for _, k := range strings.Split(key, ".") {
+		v, ok := m[k]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", key)
+		}
+		if m, ok = v.(map[string]any); !ok {
+			return nil, fmt.Errorf("value of %q is not a map", k)
+		}
+	}
+
+	return ParseConfigFromMap(m)
 }
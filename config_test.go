@@ -5,8 +5,11 @@ package ra
 
 import (
 	"bytes"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/require"
@@ -38,6 +41,34 @@ interfaces:
 		require.Equal(t, 1000, c.Interfaces[1].RAIntervalMilliseconds)
 	})
 
+	t.Run("ParseConfigYAMLFile with malformed YAML", func(t *testing.T) {
+		f, err := os.CreateTemp(".", "ra-test")
+		require.NoError(t, err)
+		defer os.Remove(f.Name())
+		_, err = f.Write([]byte("interfaces:\n  - name: net0\n    raIntervalMilliseconds: 1000: 2\n"))
+		require.NoError(t, err)
+		_, err = ParseConfigYAMLFile(f.Name())
+		var perr *ConfigParseError
+		require.ErrorAs(t, err, &perr)
+		require.Equal(t, f.Name(), perr.Path)
+		require.Equal(t, 3, perr.Line)
+		require.Equal(t, "    raIntervalMilliseconds: 1000: 2", perr.Snippet)
+		require.Contains(t, err.Error(), f.Name()+":3")
+	})
+
+	t.Run("ParseConfigYAMLFile with type mismatch", func(t *testing.T) {
+		f, err := os.CreateTemp(".", "ra-test")
+		require.NoError(t, err)
+		defer os.Remove(f.Name())
+		_, err = f.Write([]byte("interfaces:\n  - name: net0\n    raIntervalMilliseconds: foo\n"))
+		require.NoError(t, err)
+		_, err = ParseConfigYAMLFile(f.Name())
+		var perr *ConfigParseError
+		require.ErrorAs(t, err, &perr)
+		require.Equal(t, 3, perr.Line)
+		require.Equal(t, "    raIntervalMilliseconds: foo", perr.Snippet)
+	})
+
 	jsonConf := `
 {
 	"interfaces": [
@@ -64,6 +95,56 @@ interfaces:
 		require.Equal(t, 1000, c.Interfaces[1].RAIntervalMilliseconds)
 	})
 
+	embeddedYAMLConf := `
+app:
+  name: foo
+  networking:
+    mtu: 1500
+    ra:
+      interfaces:
+        - name: net0
+          raIntervalMilliseconds: 1000
+        - name: net1
+          raIntervalMilliseconds: 1000
+`
+
+	t.Run("ParseConfigYAMLWithKey", func(t *testing.T) {
+		c, err := ParseConfigYAMLWithKey(bytes.NewBuffer([]byte(embeddedYAMLConf)), "app.networking.ra")
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		require.Len(t, c.Interfaces, 2)
+		require.Equal(t, "net0", c.Interfaces[0].Name)
+		require.Equal(t, 1000, c.Interfaces[0].RAIntervalMilliseconds)
+		require.Equal(t, "net1", c.Interfaces[1].Name)
+		require.Equal(t, 1000, c.Interfaces[1].RAIntervalMilliseconds)
+		require.NoError(t, c.defaultAndValidate())
+	})
+
+	t.Run("ParseConfigYAMLWithKey with missing key", func(t *testing.T) {
+		_, err := ParseConfigYAMLWithKey(bytes.NewBuffer([]byte(embeddedYAMLConf)), "app.networking.radv")
+		require.Error(t, err)
+	})
+
+	t.Run("ParseConfigYAMLWithKey with non-map value", func(t *testing.T) {
+		_, err := ParseConfigYAMLWithKey(bytes.NewBuffer([]byte(embeddedYAMLConf)), "app.name")
+		require.Error(t, err)
+	})
+
+	t.Run("ParseConfigFromMap", func(t *testing.T) {
+		c, err := ParseConfigFromMap(map[string]any{
+			"interfaces": []any{
+				map[string]any{
+					"name":                   "net0",
+					"raIntervalMilliseconds": 1000,
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		require.Len(t, c.Interfaces, 1)
+		require.Equal(t, "net0", c.Interfaces[0].Name)
+		require.Equal(t, 1000, c.Interfaces[0].RAIntervalMilliseconds)
+	})
 }
 
 func TestConfigValidation(t *testing.T) {
@@ -302,6 +383,47 @@ func TestConfigValidation(t *testing.T) {
 			errorField:  "MTU",
 			errorTag:    "lte",
 		},
+		{
+			name: "MTU = 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						MTU:                    0,
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "MTU = 1280",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						MTU:                    1280,
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "MTU < 1280",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						MTU:                    1000,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "MTU",
+			errorTag:    "ipv6_min_mtu",
+		},
 
 		// PrefixConfig
 		{
@@ -386,6 +508,146 @@ func TestConfigValidation(t *testing.T) {
 			errorField:  "Prefixes",
 			errorTag:    "non_overlapping_prefix",
 		},
+		{
+			name: "SubnetID within /56",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix:   "2001:db8:0:100::/56",
+								SubnetID: ptr.To(255),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Non-overlapping SubnetIDs in the same Prefix",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix:   "2001:db8:0:100::/56",
+								SubnetID: ptr.To(1),
+							},
+							{
+								Prefix:   "2001:db8:0:100::/56",
+								SubnetID: ptr.To(2),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SubnetID overlapping with another Prefix",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix:   "2001:db8:0:100::/56",
+								SubnetID: ptr.To(1),
+							},
+							{
+								Prefix: "2001:db8:0:100::/60",
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Prefixes",
+			errorTag:    "non_overlapping_prefix",
+		},
+		{
+			name: "Duplicated prefixes",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix: "2001:db8::/64",
+							},
+							{
+								Prefix: "2001:db8::/64",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SubnetID doesn't fit /56",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix:   "2001:db8:0:100::/56",
+								SubnetID: ptr.To(256),
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SubnetID",
+			errorTag:    "subnet_id_fits",
+		},
+		{
+			name: "SubnetID with Prefix longer than /64",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix:   "2001:db8::/96",
+								SubnetID: ptr.To(0),
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SubnetID",
+			errorTag:    "subnet_id_fits",
+		},
+		{
+			name: "SubnetID < 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix:   "2001:db8:0:100::/56",
+								SubnetID: ptr.To(-1),
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SubnetID",
+			errorTag:    "gte",
+		},
 		{
 			name: "ValidLifetimeSeconds = 4294967295",
 			config: &Config{
@@ -569,151 +831,1479 @@ func TestConfigValidation(t *testing.T) {
 			},
 		},
 		{
-			name: "Preference foo && RouterLifetimeSeconds != 0",
+			name: "Valid PrefixRotation",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						Preference:             "foo",
-						RouterLifetimeSeconds:  1,
+						PrefixRotation: &PrefixRotationConfig{
+							Prefixes:        []string{"2001:db8:1::/64", "2001:db8:2::/64"},
+							IntervalSeconds: 60,
+						},
 					},
 				},
 			},
-			expectError: true,
-			errorField:  "Preference",
-			errorTag:    "oneof",
 		},
 		{
-			name: "Preference == low && RouterLifetimeSeconds == 0",
+			name: "PrefixRotation overlapping with Prefixes",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						Preference:             "low",
-						RouterLifetimeSeconds:  0,
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix: "2001:db8:1::/48",
+							},
+						},
+						PrefixRotation: &PrefixRotationConfig{
+							Prefixes:        []string{"2001:db8:1::/64", "2001:db8:2::/64"},
+							IntervalSeconds: 60,
+						},
 					},
 				},
 			},
 			expectError: true,
-			errorField:  "Preference",
-			errorTag:    "eq_if medium RouterLifetimeSeconds 0",
+			errorField:  "PrefixRotation",
+			errorTag:    "non_overlapping_rotation",
 		},
 		{
-			name: "Preference == <empty> && RouterLifetimeSeconds == 0",
+			name: "PrefixRotation with a single prefix",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						RouterLifetimeSeconds:  0,
+						PrefixRotation: &PrefixRotationConfig{
+							Prefixes:        []string{"2001:db8:1::/64"},
+							IntervalSeconds: 60,
+						},
 					},
 				},
 			},
+			expectError: true,
+			errorField:  "Prefixes",
+			errorTag:    "min",
 		},
-
-		// RouteConfig
 		{
-			name: "Nil RouteConfig",
+			name: "PrefixRotation with invalid prefix",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						Routes:                 nil,
+						PrefixRotation: &PrefixRotationConfig{
+							Prefixes:        []string{"2001:db8:1::/64", "192.0.2.0/24"},
+							IntervalSeconds: 60,
+						},
 					},
 				},
 			},
-			expectError: false,
+			expectError: true,
+			errorField:  "Prefixes[1]",
+			errorTag:    "cidrv6",
 		},
 		{
-			name: "Empty RouteConfig",
+			name: "PrefixRotation without interval",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						Routes:                 []*RouteConfig{},
+						PrefixRotation: &PrefixRotationConfig{
+							Prefixes: []string{"2001:db8:1::/64", "2001:db8:2::/64"},
+						},
 					},
 				},
 			},
-			expectError: false,
+			expectError: true,
+			errorField:  "IntervalSeconds",
+			errorTag:    "required",
 		},
 		{
-			name: "Nil RouteConfig Element",
+			name: "Valid Interface Name",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
-						Name:                   "net0",
+						Name:                   "eth0.100",
 						RAIntervalMilliseconds: 1000,
-						Routes:                 []*RouteConfig{nil},
 					},
 				},
 			},
-			expectError: true,
-			errorField:  "Prefix",
-			errorTag:    "required",
 		},
 		{
-			name: "No Prefix",
+			name: "Interface Name Too Long",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
-						Name:                   "net0",
+						Name:                   "abcdefghijklmnop",
 						RAIntervalMilliseconds: 1000,
-						Routes: []*RouteConfig{
-							{},
-						},
 					},
 				},
 			},
 			expectError: true,
-			errorField:  "Prefix",
-			errorTag:    "required",
+			errorField:  "Name",
+			errorTag:    "ifname",
 		},
 		{
-			name: "No LifetimeSeconds",
+			name: "Interface Name With Space",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
-						Name:                   "net0",
+						Name:                   "net 0",
 						RAIntervalMilliseconds: 1000,
-						Routes: []*RouteConfig{
-							{
-								Prefix: "2001:db8::/64",
-							},
-						},
 					},
 				},
 			},
 			expectError: true,
-			errorField:  "LifetimeSeconds",
-			errorTag:    "required",
+			errorField:  "Name",
+			errorTag:    "ifname",
 		},
 		{
-			name: "Duplicated Prefix",
+			name: "Interface Name With Slash",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
-						Name:                   "net0",
+						Name:                   "net/0",
 						RAIntervalMilliseconds: 1000,
-						Routes: []*RouteConfig{
-							{
-								Prefix:          "2001:db8::/64",
-								LifetimeSeconds: 100,
-							},
-							{
-								Prefix:          "2001:db8::/64",
-								LifetimeSeconds: 100,
-							},
-						},
 					},
 				},
 			},
 			expectError: true,
-			errorField:  "Routes",
-			errorTag:    "unique",
+			errorField:  "Name",
+			errorTag:    "ifname",
+		},
+		{
+			name: "Existing NetnsPath",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						NetnsPath:              "/proc/self/ns/net",
+					},
+				},
+			},
+		},
+		{
+			name: "Non-existing NetnsPath",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						NetnsPath:              "/var/run/netns/nonexistent",
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "NetnsPath",
+			errorTag:    "file",
+		},
+		{
+			name: "Valid SEND Option",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SENDOptions: []*SENDOptionConfig{
+							{
+								Type: 11,
+								Data: "000102030405",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SEND Option With Unsupported Type",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SENDOptions: []*SENDOptionConfig{
+							{
+								Type: 14,
+								Data: "000102030405",
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Type",
+			errorTag:    "oneof",
+		},
+		{
+			name: "SEND Option With Unaligned Data",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SENDOptions: []*SENDOptionConfig{
+							{
+								Type: 12,
+								Data: "0001020304",
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Data",
+			errorTag:    "send_option_len",
+		},
+		{
+			name: "SEND Option Too Long",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SENDOptions: []*SENDOptionConfig{
+							{
+								Type: 12,
+								Data: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Data",
+			errorTag:    "send_option_len",
+		},
+		{
+			name: "JitterPercent 50",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						JitterPercent:          50,
+					},
+				},
+			},
+		},
+		{
+			name: "JitterPercent > 50",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						JitterPercent:          51,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "JitterPercent",
+			errorTag:    "lte",
+		},
+		{
+			name: "JitterPercent < 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						JitterPercent:          -1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "JitterPercent",
+			errorTag:    "gte",
+		},
+		{
+			name: "RDNSS With Too Many Addresses",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RDNSSes: []*RDNSSConfig{
+							{
+								LifetimeSeconds: 1800,
+								Addresses: func() []string {
+									addrs := []string{}
+									for i := 1; i <= 128; i++ {
+										addrs = append(addrs, fmt.Sprintf("2001:db8::%x", i))
+									}
+									return addrs
+								}(),
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Addresses",
+			errorTag:    "max",
+		},
+		{
+			name: "Valid SolicitorOverrides",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SolicitorOverrides: map[string]*SolicitorOverrideConfig{
+							"fe80::1": {
+								Prefixes: []*PrefixConfig{{Prefix: "2001:db8::/64"}},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Non-IPv6 SolicitorOverrides key",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SolicitorOverrides: map[string]*SolicitorOverrideConfig{
+							"192.0.2.1": {},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitorOverrides[192.0.2.1]",
+			errorTag:    "ipv6",
+		},
+		{
+			name: "Nil SolicitorOverrides value",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SolicitorOverrides: map[string]*SolicitorOverrideConfig{
+							"fe80::1": nil,
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitorOverrides[fe80::1]",
+			errorTag:    "required",
+		},
+		{
+			name: "PreferredValidRatio > 1",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						PreferredValidRatio:    1.5,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "PreferredValidRatio",
+			errorTag:    "lte",
+		},
+		{
+			name: "PreferredValidRatio < 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						PreferredValidRatio:    -0.5,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "PreferredValidRatio",
+			errorTag:    "gt",
+		},
+		{
+			name: "SendRetries > 5",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SendRetries:            ptr.To(6),
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SendRetries",
+			errorTag:    "lte",
+		},
+		{
+			name: "Empty alias name",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Aliases: map[string]*AliasConfig{
+							"": {},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Aliases[]",
+			errorTag:    "required",
+		},
+		{
+			name: "Overlapping alias prefixes",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Aliases: map[string]*AliasConfig{
+							"blue": {
+								Prefixes: []*PrefixConfig{
+									{Prefix: "2001:db8::/64"},
+									{Prefix: "2001:db8::/48"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Prefixes",
+			errorTag:    "non_overlapping_prefix",
+		},
+		{
+			name: "RSSilenceWindowSeconds < 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RSSilenceWindowSeconds: -1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "RSSilenceWindowSeconds",
+			errorTag:    "gte",
+		},
+		{
+			name: "Case-insensitive DisabledOptions",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						DisabledOptions:        []string{"RDNSS", "dnssl"},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Unknown DisabledOptions",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						DisabledOptions:        []string{"foo"},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "DisabledOptions[0]",
+			errorTag:    "oneof",
+		},
+		{
+			name: "Duplicated DisabledOptions",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						DisabledOptions:        []string{"mtu", "MTU"},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "DisabledOptions",
+			errorTag:    "unique",
+		},
+		{
+			name: "Negative SolicitedRateLimit",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SolicitedRateLimit:     -1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitedRateLimit",
+			errorTag:    "gte",
+		},
+		{
+			name: "SolicitedRateLimitWindowSeconds > 3600",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                            "net0",
+						RAIntervalMilliseconds:          1000,
+						SolicitedRateLimit:              10,
+						SolicitedRateLimitWindowSeconds: 3601,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitedRateLimitWindowSeconds",
+			errorTag:    "lte",
+		},
+		{
+			name: "PrefixSource with invalid URL",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						PrefixSource: &PrefixSourceConfig{
+							URL: "ipam.example",
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "URL",
+			errorTag:    "http_url",
+		},
+		{
+			name: "ReservedBits without AllowReservedBits",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						ReservedBits:           1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "ReservedBits",
+			errorTag:    "excluded_unless",
+		},
+		{
+			name: "ReservedBits with AllowReservedBits",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						ReservedBits:           1,
+						AllowReservedBits:      true,
+					},
+				},
+			},
+		},
+		{
+			name: "ReservedBits > 3",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						ReservedBits:           4,
+						AllowReservedBits:      true,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "ReservedBits",
+			errorTag:    "lte",
+		},
+		{
+			name: "InitialRACount > 3",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						InitialRACount:         4,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "InitialRACount",
+			errorTag:    "lte",
+		},
+		{
+			name: "Negative AdvertiseEveryN",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix:          "2001:db8::/64",
+								AdvertiseEveryN: -1,
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "AdvertiseEveryN",
+			errorTag:    "gte",
+		},
+		{
+			name: "RouterLifetimeSeconds == 0 with Routes",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RouterLifetimeSeconds:  0,
+						Routes: []*RouteConfig{
+							{
+								Prefix:          "2001:db8::/48",
+								LifetimeSeconds: 100,
+								Preference:      "high",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SolicitedReachableTimeMilliseconds == 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                               "net0",
+						RAIntervalMilliseconds:             1000,
+						SolicitedReachableTimeMilliseconds: ptr.To(0),
+					},
+				},
+			},
+		},
+		{
+			name: "SolicitedReachableTimeMilliseconds < 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                               "net0",
+						RAIntervalMilliseconds:             1000,
+						SolicitedReachableTimeMilliseconds: ptr.To(-1),
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitedReachableTimeMilliseconds",
+			errorTag:    "gte",
+		},
+		{
+			name: "SolicitedReachableTimeMilliseconds > 4294967295",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                               "net0",
+						RAIntervalMilliseconds:             1000,
+						SolicitedReachableTimeMilliseconds: ptr.To(4294967296),
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitedReachableTimeMilliseconds",
+			errorTag:    "lte",
+		},
+		{
+			name: "SolicitedRetransmitTimeMilliseconds == 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                                "net0",
+						RAIntervalMilliseconds:              1000,
+						SolicitedRetransmitTimeMilliseconds: ptr.To(0),
+					},
+				},
+			},
+		},
+		{
+			name: "SolicitedRetransmitTimeMilliseconds < 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                                "net0",
+						RAIntervalMilliseconds:              1000,
+						SolicitedRetransmitTimeMilliseconds: ptr.To(-1),
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitedRetransmitTimeMilliseconds",
+			errorTag:    "gte",
+		},
+		{
+			name: "SolicitedRetransmitTimeMilliseconds > 4294967295",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                                "net0",
+						RAIntervalMilliseconds:              1000,
+						SolicitedRetransmitTimeMilliseconds: ptr.To(4294967296),
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitedRetransmitTimeMilliseconds",
+			errorTag:    "lte",
+		},
+		{
+			name: "InterfaceMACPrefixes 00:11:22/24",
+			config: &Config{
+				InterfaceMACPrefixes: []string{"00:11:22/24"},
+			},
+		},
+		{
+			name: "InterfaceMACPrefixes 00:11:22:30/28",
+			config: &Config{
+				InterfaceMACPrefixes: []string{"00:11:22:30/28"},
+			},
+		},
+		{
+			name: "InterfaceMACPrefixes 00:11:22:33:44:55/48",
+			config: &Config{
+				InterfaceMACPrefixes: []string{"00:11:22:33:44:55/48"},
+			},
+		},
+		{
+			name: "InterfaceMACPrefixes without length",
+			config: &Config{
+				InterfaceMACPrefixes: []string{"00:11:22"},
+			},
+			expectError: true,
+			errorField:  "InterfaceMACPrefixes[0]",
+			errorTag:    "mac_prefix",
+		},
+		{
+			name: "InterfaceMACPrefixes invalid octet",
+			config: &Config{
+				InterfaceMACPrefixes: []string{"00:11:2g/24"},
+			},
+			expectError: true,
+			errorField:  "InterfaceMACPrefixes[0]",
+			errorTag:    "mac_prefix",
+		},
+		{
+			name: "InterfaceMACPrefixes too short for length",
+			config: &Config{
+				InterfaceMACPrefixes: []string{"00:11/24"},
+			},
+			expectError: true,
+			errorField:  "InterfaceMACPrefixes[0]",
+			errorTag:    "mac_prefix",
+		},
+		{
+			name: "InterfaceMACPrefixes non-zero bits after length",
+			config: &Config{
+				InterfaceMACPrefixes: []string{"00:11:22:33/24"},
+			},
+			expectError: true,
+			errorField:  "InterfaceMACPrefixes[0]",
+			errorTag:    "mac_prefix",
+		},
+		{
+			name: "InterfaceMACPrefixes length out of range",
+			config: &Config{
+				InterfaceMACPrefixes: []string{"00:11:22/49"},
+			},
+			expectError: true,
+			errorField:  "InterfaceMACPrefixes[0]",
+			errorTag:    "mac_prefix",
+		},
+		{
+			name: "ExcludeInterfaces with name and pattern",
+			config: &Config{
+				ExcludeInterfaces: []string{"eth0", "mgmt*", "veth[0-9]"},
+			},
+		},
+		{
+			name: "ExcludeInterfaces with malformed pattern",
+			config: &Config{
+				ExcludeInterfaces: []string{"eth[0-"},
+			},
+			expectError: true,
+			errorField:  "ExcludeInterfaces[0]",
+			errorTag:    "ifname_pattern",
+		},
+		{
+			name: "ExcludeInterfaces with empty pattern",
+			config: &Config{
+				ExcludeInterfaces: []string{""},
+			},
+			expectError: true,
+			errorField:  "ExcludeInterfaces[0]",
+			errorTag:    "ifname_pattern",
+		},
+		{
+			name: "ExcludeInterfaces with slash",
+			config: &Config{
+				ExcludeInterfaces: []string{"eth/0"},
+			},
+			expectError: true,
+			errorField:  "ExcludeInterfaces[0]",
+			errorTag:    "ifname_pattern",
+		},
+		{
+			name: "ExcludeInterfaces duplicated",
+			config: &Config{
+				ExcludeInterfaces: []string{"eth0", "eth0"},
+			},
+			expectError: true,
+			errorField:  "ExcludeInterfaces",
+			errorTag:    "unique",
+		},
+		{
+			name: "Link-local SourceAddress",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SourceAddress:          "fe80::1",
+					},
+				},
+			},
+		},
+		{
+			name: "Non-link-local SourceAddress without AllowNonLinkLocalSource",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SourceAddress:          "2001:db8::1",
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SourceAddress",
+			errorTag:    "link_local_source",
+		},
+		{
+			name: "Non-link-local SourceAddress with AllowNonLinkLocalSource",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                    "net0",
+						RAIntervalMilliseconds:  1000,
+						SourceAddress:           "2001:db8::1",
+						AllowNonLinkLocalSource: true,
+					},
+				},
+			},
+		},
+		{
+			name: "Multicast SourceAddress with AllowNonLinkLocalSource",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                    "net0",
+						RAIntervalMilliseconds:  1000,
+						SourceAddress:           "ff02::1",
+						AllowNonLinkLocalSource: true,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SourceAddress",
+			errorTag:    "link_local_source",
+		},
+		{
+			name: "IPv4 SourceAddress",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SourceAddress:          "192.0.2.1",
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SourceAddress",
+			errorTag:    "ipv6",
+		},
+		{
+			name: "Index > 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Index:                  1,
+					},
+				},
+			},
+		},
+		{
+			name: "Index < 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Index:                  -1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Index",
+			errorTag:    "gt",
+		},
+		{
+			name: "PadToBytes < 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						PadToBytes:             -1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "PadToBytes",
+			errorTag:    "gte",
+		},
+		{
+			name: "SplitDNSOptions with PadToBytes",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						PadToBytes:             512,
+						SplitDNSOptions:        true,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SplitDNSOptions",
+			errorTag:    "excluded_with",
+		},
+		{
+			name: "WithdrawalRepeat < 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						WithdrawalRepeat:       -1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "WithdrawalRepeat",
+			errorTag:    "gte",
+		},
+		{
+			name: "MinDelayBetweenRAsMilliseconds < 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                           "net0",
+						RAIntervalMilliseconds:         1000,
+						MinDelayBetweenRAsMilliseconds: -1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "MinDelayBetweenRAsMilliseconds",
+			errorTag:    "gte",
+		},
+		{
+			name: "MinDelayBetweenRAsMilliseconds > 60000",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                           "net0",
+						RAIntervalMilliseconds:         1000,
+						MinDelayBetweenRAsMilliseconds: 60001,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "MinDelayBetweenRAsMilliseconds",
+			errorTag:    "lte",
+		},
+		{
+			name: "MTUFromInterfaces with MTU",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						MTU:                    1500,
+						MTUFromInterfaces:      []string{"eth0"},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "MTUFromInterfaces",
+			errorTag:    "excluded_with",
+		},
+		{
+			name: "MTUFromInterfaces includes the interface itself",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						MTUFromInterfaces:      []string{"eth0", "net0"},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "MTUFromInterfaces",
+			errorTag:    "excludes_self",
+		},
+		{
+			name: "MTUFromInterfaces with invalid name",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						MTUFromInterfaces:      []string{"eth/0"},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "MTUFromInterfaces[0]",
+			errorTag:    "ifname",
+		},
+		{
+			name: "Duplicated MTUFromInterfaces",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						MTUFromInterfaces:      []string{"eth0", "eth0"},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "MTUFromInterfaces",
+			errorTag:    "unique",
+		},
+		{
+			name: "SLLAInSolicitedOnly with slla disabled",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SLLAInSolicitedOnly:    true,
+						DisabledOptions:        []string{"SLLA"},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SLLAInSolicitedOnly",
+			errorTag:    "slla_not_disabled",
+		},
+		{
+			name: "SLLAInSolicitedOnly with other options disabled",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SLLAInSolicitedOnly:    true,
+						DisabledOptions:        []string{"mtu"},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Preference High && RouterLifetimeSeconds != 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Preference:             "High",
+						RouterLifetimeSeconds:  1,
+					},
+				},
+			},
+		},
+		{
+			name: "Alias route Preference High",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Aliases: map[string]*AliasConfig{
+							"maintenance": {
+								Routes: []*RouteConfig{
+									{
+										Prefix:          "2001:db8::/48",
+										LifetimeSeconds: 1800,
+										Preference:      "High",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Preference MEDIUM && RouterLifetimeSeconds == 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Preference:             "MEDIUM",
+						RouterLifetimeSeconds:  0,
+					},
+				},
+			},
+		},
+		{
+			name: "Preference foo && RouterLifetimeSeconds != 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Preference:             "foo",
+						RouterLifetimeSeconds:  1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Preference",
+			errorTag:    "oneof",
+		},
+		{
+			name: "Preference == low && RouterLifetimeSeconds == 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Preference:             "low",
+						RouterLifetimeSeconds:  0,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Preference",
+			errorTag:    "eq_if medium RouterLifetimeSeconds 0",
+		},
+		{
+			name: "Preference == <empty> && RouterLifetimeSeconds == 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RouterLifetimeSeconds:  0,
+					},
+				},
+			},
+		},
+		{
+			name: "SolicitedPreference high && RouterLifetimeSeconds != 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SolicitedPreference:    "high",
+						RouterLifetimeSeconds:  1,
+					},
+				},
+			},
+		},
+		{
+			name: "SolicitedPreference foo && RouterLifetimeSeconds != 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SolicitedPreference:    "foo",
+						RouterLifetimeSeconds:  1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitedPreference",
+			errorTag:    "oneof",
+		},
+		{
+			name: "SolicitedPreference == high && RouterLifetimeSeconds == 0",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SolicitedPreference:    "high",
+						RouterLifetimeSeconds:  0,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitedPreference",
+			errorTag:    "eq_if medium RouterLifetimeSeconds 0",
+		},
+
+		{
+			name: "LogLevel debug",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						LogLevel:               "debug",
+					},
+				},
+			},
+		},
+		{
+			name: "LogLevel foo",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						LogLevel:               "foo",
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "LogLevel",
+			errorTag:    "oneof",
+		},
+		{
+			name: "SolicitedRARepeat 5",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SolicitedRARepeat:      5,
+					},
+				},
+			},
+		},
+		{
+			name: "SolicitedRARepeat 6",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SolicitedRARepeat:      6,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitedRARepeat",
+			errorTag:    "lte",
+		},
+		{
+			name: "SolicitedRARepeat -1",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						SolicitedRARepeat:      -1,
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "SolicitedRARepeat",
+			errorTag:    "gte",
+		},
+
+		// RouteConfig
+		{
+			name: "Nil RouteConfig",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Routes:                 nil,
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Empty RouteConfig",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Routes:                 []*RouteConfig{},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Nil RouteConfig Element",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Routes:                 []*RouteConfig{nil},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Prefix",
+			errorTag:    "required",
+		},
+		{
+			name: "No Prefix",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Routes: []*RouteConfig{
+							{},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Prefix",
+			errorTag:    "required",
+		},
+		{
+			name: "No LifetimeSeconds",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Routes: []*RouteConfig{
+							{
+								Prefix: "2001:db8::/64",
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "LifetimeSeconds",
+			errorTag:    "required",
+		},
+		{
+			name: "Duplicated Prefix",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Routes: []*RouteConfig{
+							{
+								Prefix:          "2001:db8::/64",
+								LifetimeSeconds: 100,
+							},
+							{
+								Prefix:          "2001:db8::/64",
+								LifetimeSeconds: 100,
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Routes",
+			errorTag:    "unique",
+		},
+		{
+			name: "Route Prefix /128",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Routes: []*RouteConfig{
+							{
+								Prefix:          "2001:db8::1/128",
+								LifetimeSeconds: 100,
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Route Prefix /48",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Routes: []*RouteConfig{
+							{
+								Prefix:          "2001:db8:1::/48",
+								LifetimeSeconds: 100,
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Route Prefix with non-zero host bits",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						Routes: []*RouteConfig{
+							{
+								Prefix:          "2001:db8:1::1/48",
+								LifetimeSeconds: 100,
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Prefix",
+			errorTag:    "masked_prefix",
 		},
 
 		// RDNSSConfig
@@ -762,39 +2352,217 @@ func TestConfigValidation(t *testing.T) {
 			},
 		},
 		{
-			name: "Nil RDNSSConfig",
+			name: "Duplicated address across RDNSSConfig",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RDNSSes: []*RDNSSConfig{
+							{
+								LifetimeSeconds: 100,
+								Addresses: []string{
+									"fd00::1",
+									"fd00::2",
+								},
+							},
+							{
+								LifetimeSeconds: 200,
+								Addresses: []string{
+									"fd00:0::1",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "RDNSSes",
+			errorTag:    "unique_rdnss_address",
+		},
+		{
+			name: "Nil RDNSSConfig",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RDNSSes:                nil,
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Empty RDNSSConfig",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RDNSSes:                []*RDNSSConfig{},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Nil RDNSSConfig Element",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RDNSSes:                []*RDNSSConfig{nil},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "LifetimeSeconds",
+			errorTag:    "required",
+		},
+		{
+			name: "No Addresses",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RDNSSes: []*RDNSSConfig{
+							{
+								LifetimeSeconds: 100,
+								Addresses:       []string{},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Addresses",
+			errorTag:    "min",
+		},
+		{
+			name: "Duplicated Addresses",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RDNSSes: []*RDNSSConfig{
+							{
+								LifetimeSeconds: 100,
+								Addresses: []string{
+									"fd00::1",
+									"fd00::1",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Addresses",
+			errorTag:    "unique",
+		},
+		{
+			name: "Invalid Address",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						RDNSSes: []*RDNSSConfig{
+							{
+								LifetimeSeconds: 100,
+								Addresses: []string{
+									"10.0.0.1",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "Addresses[0]",
+			errorTag:    "ipv6",
+		},
+
+		// DNSSLConfig
+		{
+			name: "Valid DNSSLConfig",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						DNSSLs: []*DNSSLConfig{
+							{
+								LifetimeSeconds: 100,
+								DomainNames: []string{
+									"example.com",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Multiple DNSSLConfig",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						DNSSLs: []*DNSSLConfig{
+							{
+								LifetimeSeconds: 100,
+								DomainNames: []string{
+									"example.com",
+									"foo.example.com",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Nil DNSSLConfig",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						RDNSSes:                nil,
+						DNSSLs:                 nil,
 					},
 				},
 			},
 			expectError: false,
 		},
 		{
-			name: "Empty RDNSSConfig",
+			name: "Empty DNSSLConfig",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						RDNSSes:                []*RDNSSConfig{},
+						DNSSLs:                 []*DNSSLConfig{},
 					},
 				},
 			},
 			expectError: false,
 		},
 		{
-			name: "Nil RDNSSConfig Element",
+			name: "Nil DNSSLConfig Element",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						RDNSSes:                []*RDNSSConfig{nil},
+						DNSSLs:                 []*DNSSLConfig{nil},
 					},
 				},
 			},
@@ -803,38 +2571,38 @@ func TestConfigValidation(t *testing.T) {
 			errorTag:    "required",
 		},
 		{
-			name: "No Addresses",
+			name: "No DomainNames",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						RDNSSes: []*RDNSSConfig{
+						DNSSLs: []*DNSSLConfig{
 							{
 								LifetimeSeconds: 100,
-								Addresses:       []string{},
+								DomainNames:     []string{},
 							},
 						},
 					},
 				},
 			},
 			expectError: true,
-			errorField:  "Addresses",
+			errorField:  "DomainNames",
 			errorTag:    "min",
 		},
 		{
-			name: "Duplicated Addresses",
+			name: "Duplicated DomainNames",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						RDNSSes: []*RDNSSConfig{
+						DNSSLs: []*DNSSLConfig{
 							{
 								LifetimeSeconds: 100,
-								Addresses: []string{
-									"fd00::1",
-									"fd00::1",
+								DomainNames: []string{
+									"example.com",
+									"example.com",
 								},
 							},
 						},
@@ -842,21 +2610,22 @@ func TestConfigValidation(t *testing.T) {
 				},
 			},
 			expectError: true,
-			errorField:  "Addresses",
+			errorField:  "DomainNames",
 			errorTag:    "unique",
 		},
 		{
-			name: "Invalid Address",
+			name: "Qualified DomainName",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						RDNSSes: []*RDNSSConfig{
+						DNSSLs: []*DNSSLConfig{
 							{
 								LifetimeSeconds: 100,
-								Addresses: []string{
-									"10.0.0.1",
+								DomainNames: []string{
+									// Shouldn't be qualified.
+									"example.com.",
 								},
 							},
 						},
@@ -864,13 +2633,11 @@ func TestConfigValidation(t *testing.T) {
 				},
 			},
 			expectError: true,
-			errorField:  "Addresses[0]",
-			errorTag:    "ipv6",
+			errorField:  "DomainNames[0]",
+			errorTag:    "domain",
 		},
-
-		// DNSSLConfig
 		{
-			name: "Valid DNSSLConfig",
+			name: "IP address DomainName",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
@@ -880,17 +2647,19 @@ func TestConfigValidation(t *testing.T) {
 							{
 								LifetimeSeconds: 100,
 								DomainNames: []string{
-									"example.com",
+									"10.0.0.0",
 								},
 							},
 						},
 					},
 				},
 			},
-			expectError: false,
+			expectError: true,
+			errorField:  "DomainNames[0]",
+			errorTag:    "domain",
 		},
 		{
-			name: "Multiple DNSSLConfig",
+			name: "A-label DomainName",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
@@ -900,8 +2669,7 @@ func TestConfigValidation(t *testing.T) {
 							{
 								LifetimeSeconds: 100,
 								DomainNames: []string{
-									"example.com",
-									"foo.example.com",
+									"xn--bcher-kva.example",
 								},
 							},
 						},
@@ -911,133 +2679,212 @@ func TestConfigValidation(t *testing.T) {
 			expectError: false,
 		},
 		{
-			name: "Nil DNSSLConfig",
+			name: "Invalid punycode DomainName",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						DNSSLs:                 nil,
+						DNSSLs: []*DNSSLConfig{
+							{
+								LifetimeSeconds: 100,
+								DomainNames: []string{
+									"xn--abc-.example",
+								},
+							},
+						},
 					},
 				},
 			},
-			expectError: false,
+			expectError: true,
+			errorField:  "DomainNames[0]",
+			errorTag:    "domain",
 		},
 		{
-			name: "Empty DNSSLConfig",
+			name: "Raw Unicode DomainName",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						DNSSLs:                 []*DNSSLConfig{},
+						DNSSLs: []*DNSSLConfig{
+							{
+								LifetimeSeconds: 100,
+								DomainNames: []string{
+									"bücher.example",
+								},
+							},
+						},
 					},
 				},
 			},
-			expectError: false,
+			expectError: true,
+			errorField:  "DomainNames[0]",
+			errorTag:    "domain",
 		},
+
+		// VendorOptionConfig
 		{
-			name: "Nil DNSSLConfig Element",
+			name: "Valid VendorOptionConfig",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						DNSSLs:                 []*DNSSLConfig{nil},
+						VendorOptions: []*VendorOptionConfig{
+							{
+								Type:             253,
+								EnterpriseNumber: 32473,
+								Payload:          "deadbeef",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "VendorOptionConfig with max payload",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						VendorOptions: []*VendorOptionConfig{
+							{
+								Type:             254,
+								EnterpriseNumber: 32473,
+								Payload:          strings.Repeat("ff", 240),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "VendorOptionConfig with too long payload",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						VendorOptions: []*VendorOptionConfig{
+							{
+								Type:             254,
+								EnterpriseNumber: 32473,
+								Payload:          strings.Repeat("ff", 241),
+							},
+						},
 					},
 				},
 			},
 			expectError: true,
-			errorField:  "LifetimeSeconds",
-			errorTag:    "required",
+			errorField:  "Payload",
+			errorTag:    "vendor_payload_len",
 		},
 		{
-			name: "No DomainNames",
+			name: "VendorOptionConfig with non-hex payload",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						DNSSLs: []*DNSSLConfig{
+						VendorOptions: []*VendorOptionConfig{
 							{
-								LifetimeSeconds: 100,
-								DomainNames:     []string{},
+								Type:             253,
+								EnterpriseNumber: 32473,
+								Payload:          "xyz",
 							},
 						},
 					},
 				},
 			},
 			expectError: true,
-			errorField:  "DomainNames",
-			errorTag:    "min",
+			errorField:  "Payload",
+			errorTag:    "hexadecimal",
 		},
 		{
-			name: "Duplicated DomainNames",
+			name: "VendorOptionConfig with known type",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						DNSSLs: []*DNSSLConfig{
+						VendorOptions: []*VendorOptionConfig{
 							{
-								LifetimeSeconds: 100,
-								DomainNames: []string{
-									"example.com",
-									"example.com",
-								},
+								Type:             3,
+								EnterpriseNumber: 32473,
+								Payload:          "deadbeef",
 							},
 						},
 					},
 				},
 			},
 			expectError: true,
-			errorField:  "DomainNames",
-			errorTag:    "unique",
+			errorField:  "Type",
+			errorTag:    "unknown_option_type",
 		},
 		{
-			name: "Qualified DomainName",
+			name: "VendorOptionConfig with type 256",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						DNSSLs: []*DNSSLConfig{
+						VendorOptions: []*VendorOptionConfig{
 							{
-								LifetimeSeconds: 100,
-								DomainNames: []string{
-									// Shouldn't be qualified.
-									"example.com.",
-								},
+								Type:             256,
+								EnterpriseNumber: 32473,
+								Payload:          "deadbeef",
 							},
 						},
 					},
 				},
 			},
 			expectError: true,
-			errorField:  "DomainNames[0]",
-			errorTag:    "domain",
+			errorField:  "Type",
+			errorTag:    "lte",
 		},
 		{
-			name: "IP address DomainName",
+			name: "VendorOptionConfig with zero enterprise number",
 			config: &Config{
 				Interfaces: []*InterfaceConfig{
 					{
 						Name:                   "net0",
 						RAIntervalMilliseconds: 1000,
-						DNSSLs: []*DNSSLConfig{
+						VendorOptions: []*VendorOptionConfig{
 							{
-								LifetimeSeconds: 100,
-								DomainNames: []string{
-									"10.0.0.0",
-								},
+								Type:             253,
+								EnterpriseNumber: 0,
+								Payload:          "deadbeef",
 							},
 						},
 					},
 				},
 			},
 			expectError: true,
-			errorField:  "DomainNames[0]",
-			errorTag:    "domain",
+			errorField:  "EnterpriseNumber",
+			errorTag:    "required",
+		},
+		{
+			name: "VendorOptionConfig with too large enterprise number",
+			config: &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 1000,
+						VendorOptions: []*VendorOptionConfig{
+							{
+								Type:             253,
+								EnterpriseNumber: 4294967296,
+								Payload:          "deadbeef",
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "EnterpriseNumber",
+			errorTag:    "lte",
 		},
 
 		// NAT64PrefixConfig
@@ -1223,3 +3070,232 @@ func TestConfigValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestInterfaceConfigDeepCopy(t *testing.T) {
+	config := &InterfaceConfig{
+		Name: "net0",
+		NAT64Prefixes: []*NAT64PrefixConfig{
+			{
+				Prefix:          "64:ff9b::/96",
+				LifetimeSeconds: ptr.To(1800),
+			},
+		},
+	}
+
+	cp := config.deepCopy()
+
+	// The copy must not share the NAT64 prefixes with the original, so
+	// that the transformations of the copy (e.g. solicitedOnlyConfig
+	// clearing SolicitedOnly) don't leak into the running configuration.
+	*cp.NAT64Prefixes[0].LifetimeSeconds = 0
+	cp.NAT64Prefixes[0].SolicitedOnly = true
+	require.Equal(t, 1800, *config.NAT64Prefixes[0].LifetimeSeconds)
+	require.False(t, config.NAT64Prefixes[0].SolicitedOnly)
+}
+
+func TestValidateInterfaceConfig(t *testing.T) {
+	t.Run("Ensure valid interface config passes without other interfaces", func(t *testing.T) {
+		ic := &InterfaceConfig{
+			Name:                   "net0",
+			RAIntervalMilliseconds: 1000,
+		}
+		require.NoError(t, ValidateInterfaceConfig(ic))
+
+		// The defaults must not be applied to the original
+		require.Empty(t, ic.Preference)
+	})
+
+	t.Run("Ensure out-of-range interval is caught", func(t *testing.T) {
+		err := ValidateInterfaceConfig(&InterfaceConfig{
+			Name:                   "net0",
+			RAIntervalMilliseconds: 1,
+		})
+
+		var verr validator.ValidationErrors
+		require.ErrorAs(t, err, &verr)
+		require.Len(t, verr, 1)
+		require.Equal(t, "RAIntervalMilliseconds", verr[0].Field())
+		require.Equal(t, "gte", verr[0].Tag())
+	})
+
+	t.Run("Ensure nil interface config is rejected", func(t *testing.T) {
+		require.Error(t, ValidateInterfaceConfig(nil))
+	})
+}
+
+func TestPreferredValidRatio(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 1000,
+				PreferredValidRatio:    0.5,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:               "2001:db8::/64",
+						ValidLifetimeSeconds: ptr.To(200),
+					},
+					{
+						Prefix:                   "2001:db8:1::/64",
+						ValidLifetimeSeconds:     ptr.To(200),
+						PreferredLifetimeSeconds: ptr.To(150),
+					},
+					{
+						Prefix: "2001:db8:2::/64",
+					},
+				},
+				SolicitorOverrides: map[string]*SolicitorOverrideConfig{
+					"fe80::1": {
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix:               "2001:db8:3::/64",
+								ValidLifetimeSeconds: ptr.To(300),
+							},
+						},
+					},
+				},
+				Aliases: map[string]*AliasConfig{
+					"alias0": {
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix:               "2001:db8:4::/64",
+								ValidLifetimeSeconds: ptr.To(400),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, config.defaultAndValidate())
+
+	// The prefixes of the overrides and the aliases are computed as well
+	require.Equal(t, 150, *config.Interfaces[0].SolicitorOverrides["fe80::1"].Prefixes[0].PreferredLifetimeSeconds)
+	require.Equal(t, 200, *config.Interfaces[0].Aliases["alias0"].Prefixes[0].PreferredLifetimeSeconds)
+
+	prefixes := config.Interfaces[0].Prefixes
+
+	// Computed from the ratio
+	require.Equal(t, 100, *prefixes[0].PreferredLifetimeSeconds)
+
+	// The explicit preferred lifetime is kept
+	require.Equal(t, 150, *prefixes[1].PreferredLifetimeSeconds)
+
+	// Both lifetimes are defaulted when the valid lifetime is omitted
+	require.Equal(t, 2592000, *prefixes[2].ValidLifetimeSeconds)
+	require.Equal(t, 604800, *prefixes[2].PreferredLifetimeSeconds)
+}
+
+func TestPrefixesOverlap(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		overlap bool
+	}{
+		{name: "/32 contains /64", a: "2001:db8::/32", b: "2001:db8:1::/64", overlap: true},
+		{name: "/64 in /32", a: "2001:db8:1::/64", b: "2001:db8::/32", overlap: true},
+		{name: "Disjoint", a: "2001:db8::/64", b: "2001:db8:1::/64", overlap: false},
+		{name: "Identical", a: "2001:db8::/64", b: "2001:db8::/64", overlap: true},
+		{name: "Host bits are ignored", a: "2001:db8::1/64", b: "2001:db8::2/64", overlap: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlap, err := PrefixesOverlap(tt.a, tt.b)
+			require.NoError(t, err)
+			require.Equal(t, tt.overlap, overlap)
+		})
+	}
+
+	t.Run("Invalid prefix", func(t *testing.T) {
+		_, err := PrefixesOverlap("2001:db8::/129", "2001:db8::/64")
+		require.Error(t, err)
+	})
+
+	t.Run("IPv4 prefix", func(t *testing.T) {
+		_, err := PrefixesOverlap("2001:db8::/64", "10.0.0.0/8")
+		require.Error(t, err)
+	})
+}
+
+func TestEffectiveLifetimesAt(t *testing.T) {
+	loadedAt := time.Unix(1000, 0)
+
+	newPrefix := func(preferred, valid int) *PrefixConfig {
+		return &PrefixConfig{
+			Prefix:                   "2001:db8::/64",
+			PreferredLifetimeSeconds: ptr.To(preferred),
+			ValidLifetimeSeconds:     ptr.To(valid),
+			DecrementLifetimes:       true,
+		}
+	}
+
+	tests := []struct {
+		name              string
+		prefix            *PrefixConfig
+		now               time.Time
+		expectedPreferred uint32
+		expectedValid     uint32
+	}{
+		{
+			name:              "Before the load",
+			prefix:            newPrefix(100, 200),
+			now:               loadedAt.Add(-time.Second * 10),
+			expectedPreferred: 100,
+			expectedValid:     200,
+		},
+		{
+			name:              "At the load",
+			prefix:            newPrefix(100, 200),
+			now:               loadedAt,
+			expectedPreferred: 100,
+			expectedValid:     200,
+		},
+		{
+			name:              "During the lifetime",
+			prefix:            newPrefix(100, 200),
+			now:               loadedAt.Add(time.Second*30 + time.Millisecond*999),
+			expectedPreferred: 70,
+			expectedValid:     170,
+		},
+		{
+			name:              "After the preferred lifetime",
+			prefix:            newPrefix(100, 200),
+			now:               loadedAt.Add(time.Second * 150),
+			expectedPreferred: 0,
+			expectedValid:     50,
+		},
+		{
+			name:              "After the valid lifetime",
+			prefix:            newPrefix(100, 200),
+			now:               loadedAt.Add(time.Hour),
+			expectedPreferred: 0,
+			expectedValid:     0,
+		},
+		{
+			name:              "Infinite lifetime",
+			prefix:            newPrefix(100, 4294967295),
+			now:               loadedAt.Add(time.Hour),
+			expectedPreferred: 0,
+			expectedValid:     4294967295,
+		},
+		{
+			name: "DecrementLifetimes unset",
+			prefix: &PrefixConfig{
+				Prefix:                   "2001:db8::/64",
+				PreferredLifetimeSeconds: ptr.To(100),
+				ValidLifetimeSeconds:     ptr.To(200),
+			},
+			now:               loadedAt.Add(time.Hour),
+			expectedPreferred: 100,
+			expectedValid:     200,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preferred, valid := EffectiveLifetimesAt(tt.prefix, loadedAt, tt.now)
+			require.Equal(t, tt.expectedPreferred, preferred)
+			require.Equal(t, tt.expectedValid, valid)
+		})
+	}
+}
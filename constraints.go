@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldConstraint describes the constraints the validator enforces on a
+// configuration field. It is derived from the struct tags, so that the
+// external tools (e.g. UIs or documentation generators) can stay in sync with
+// the code.
+type FieldConstraint struct {
+	// Dot-separated path of the field from the Config in the YAML/JSON
+	// keys (e.g. "interfaces.raIntervalMilliseconds"). The slices are
+	// traversed transparently.
+	Path string
+
+	// Whether the field is required
+	Required bool
+
+	// Minimum value (inclusive) of the numeric field. Nil if unbounded.
+	Min *int64
+
+	// Maximum value (inclusive) of the numeric field. Nil if unbounded.
+	Max *int64
+
+	// Allowed values of the field. Empty if any value is allowed.
+	Enum []string
+
+	// Default value of the field. Empty if there's no default.
+	Default string
+
+	// Other validation rules applied to the field (e.g. "cidrv6")
+	Rules []string
+
+	// Constraints applied to each element of the slice or the map field.
+	// Nil if the elements are not validated.
+	Elem *FieldConstraint
+
+	// Constraints applied to each key of the map field. Nil if the keys
+	// are not validated.
+	Keys *FieldConstraint
+}
+
+// ConfigConstraints returns the constraints of all configuration fields in
+// the order of the field definitions.
+func ConfigConstraints() []FieldConstraint {
+	return collectConstraints(reflect.TypeOf(Config{}), "")
+}
+
+func collectConstraints(t reflect.Type, prefix string) []FieldConstraint {
+	ret := []FieldConstraint{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		c := parseConstraint(path, f.Tag.Get("validate"))
+		c.Default = f.Tag.Get("default")
+
+		// Don't report the collection-level default for the nested
+		// structs. It's always an empty slice.
+		elem := f.Type
+		for elem.Kind() == reflect.Pointer || elem.Kind() == reflect.Slice {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Struct {
+			c.Default = ""
+		}
+
+		ret = append(ret, c)
+
+		if elem.Kind() == reflect.Struct {
+			ret = append(ret, collectConstraints(elem, path)...)
+		}
+	}
+
+	return ret
+}
+
+func parseConstraint(path, tag string) FieldConstraint {
+	c := FieldConstraint{Path: path}
+
+	if tag == "" {
+		return c
+	}
+
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		// The rules after dive are applied to the elements (and the
+		// keys between keys and endkeys for the maps), not to the field
+		// itself.
+		if rule == "dive" {
+			parseElemConstraint(&c, rules[i+1:])
+			break
+		}
+		c.addRule(rule)
+	}
+
+	return c
+}
+
+func parseElemConstraint(c *FieldConstraint, rules []string) {
+	if len(rules) > 0 && rules[0] == "keys" {
+		keys := FieldConstraint{Path: c.Path}
+		for i, rule := range rules[1:] {
+			if rule == "endkeys" {
+				rules = rules[i+2:]
+				break
+			}
+			keys.addRule(rule)
+		}
+		c.Keys = &keys
+	}
+
+	if len(rules) == 0 {
+		return
+	}
+
+	elem := parseConstraint(c.Path, strings.Join(rules, ","))
+	c.Elem = &elem
+}
+
+func (c *FieldConstraint) addRule(rule string) {
+	name, param, _ := strings.Cut(rule, "=")
+	switch name {
+	case "omitempty":
+	case "required":
+		c.Required = true
+	case "gte":
+		if v, err := strconv.ParseInt(param, 10, 64); err == nil {
+			c.Min = &v
+		} else {
+			c.Rules = append(c.Rules, rule)
+		}
+	case "lte":
+		if v, err := strconv.ParseInt(param, 10, 64); err == nil {
+			c.Max = &v
+		} else {
+			c.Rules = append(c.Rules, rule)
+		}
+	case "oneof":
+		c.Enum = strings.Fields(param)
+	default:
+		c.Rules = append(c.Rules, rule)
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestConfigConstraints(t *testing.T) {
+	constraints := map[string]FieldConstraint{}
+	for _, c := range ConfigConstraints() {
+		constraints[c.Path] = c
+	}
+
+	t.Run("RAIntervalMilliseconds", func(t *testing.T) {
+		c, ok := constraints["interfaces.raIntervalMilliseconds"]
+		require.True(t, ok)
+		require.True(t, c.Required)
+		require.Equal(t, ptr.To[int64](70), c.Min)
+		require.Equal(t, ptr.To[int64](1800000), c.Max)
+		require.Equal(t, "600000", c.Default)
+	})
+
+	t.Run("Preference", func(t *testing.T) {
+		c, ok := constraints["interfaces.preference"]
+		require.True(t, ok)
+		require.Equal(t, []string{"low", "medium", "high"}, c.Enum)
+		require.Contains(t, c.Rules, "eq_if medium RouterLifetimeSeconds 0")
+		require.Equal(t, "medium", c.Default)
+	})
+
+	t.Run("Nested fields", func(t *testing.T) {
+		c, ok := constraints["interfaces.prefixes.prefix"]
+		require.True(t, ok)
+		require.True(t, c.Required)
+		require.Equal(t, []string{"cidrv6"}, c.Rules)
+
+		c, ok = constraints["interfaces.prefixes.preferredLifetimeSeconds"]
+		require.True(t, ok)
+		require.Equal(t, ptr.To[int64](0), c.Min)
+		require.Nil(t, c.Max)
+		require.Equal(t, []string{"ltefield=ValidLifetimeSeconds"}, c.Rules)
+	})
+
+	t.Run("Rules after dive", func(t *testing.T) {
+		c, ok := constraints["interfaces.rdnsses.addresses"]
+		require.True(t, ok)
+		require.True(t, c.Required)
+		require.Equal(t, []string{"unique", "min=1", "max=127"}, c.Rules)
+		require.NotNil(t, c.Elem)
+		require.Equal(t, []string{"ipv6"}, c.Elem.Rules)
+
+		c, ok = constraints["interfaces.disabledOptions"]
+		require.True(t, ok)
+		require.Empty(t, c.Enum)
+		require.Equal(t, []string{"unique"}, c.Rules)
+		require.NotNil(t, c.Elem)
+		require.Contains(t, c.Elem.Enum, "rdnss")
+	})
+
+	t.Run("Map keys", func(t *testing.T) {
+		c, ok := constraints["interfaces.solicitorOverrides"]
+		require.True(t, ok)
+		require.NotNil(t, c.Keys)
+		require.Equal(t, []string{"ipv6"}, c.Keys.Rules)
+		require.NotNil(t, c.Elem)
+		require.True(t, c.Elem.Required)
+	})
+
+	// The reported constraints must be the ones the validator actually
+	// evaluates on the field and on the elements respectively
+	t.Run("Rules are enforced", func(t *testing.T) {
+		c := constraints["interfaces.disabledOptions"]
+
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					DisabledOptions:        []string{c.Elem.Enum[0], c.Elem.Enum[0]},
+				},
+			},
+		}
+		var verr validator.ValidationErrors
+		require.ErrorAs(t, config.defaultAndValidate(), &verr)
+		require.Equal(t, "DisabledOptions", verr[0].Field())
+		require.Equal(t, "unique", verr[0].Tag())
+
+		config.Interfaces[0].DisabledOptions = []string{"foo"}
+		require.ErrorAs(t, config.defaultAndValidate(), &verr)
+		require.Equal(t, "DisabledOptions[0]", verr[0].Field())
+		require.Equal(t, "oneof", verr[0].Tag())
+	})
+}
@@ -5,69 +5,294 @@ package ra
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
+	"net/netip"
 	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/creasty/defaults"
+	"github.com/mdlayher/ndp"
 )
 
 // Daemon is the main struct for the ra daemon
 type Daemon struct {
-	initialConfig     *Config
-	reloadCh          chan *Config
-	logger            *slog.Logger
-	socketConstructor socketCtor
-	deviceWatcher     deviceWatcher
+	initialConfig       *Config
+	reloadCh            chan *reloadRequest
+	logger              *slog.Logger
+	socketConstructor   socketCtor
+	deviceWatcher       deviceWatcher
+	flapGrace           time.Duration
+	rsHandler           RSHandler
+	dnsHealthCheck      DNSHealthCheck
+	prefixChangeHandler PrefixChangeHandler
+	malformedRSPolicy   MalformedRSPolicy
+	minInterval         time.Duration
+	sysctl              sysctl
+	httpClient          *http.Client
+	clock               clock
+	recvBufferSize      int
+	nonBlockingRecv     bool
+	sendStagger         time.Duration
+	statusInterval      time.Duration
+	failFastDeadline    time.Duration
+	validationHook      ValidationHook
+
+	// Selector and template for the dynamically discovered interfaces
+	interfaceSelector InterfaceSelector
+	interfaceTemplate *InterfaceConfig
+
+	// Generation of the latest configuration. Protected by advertisersLock.
+	generation int
+
+	// Whether the advertisement is paused on all interfaces. Protected by
+	// advertisersLock.
+	paused bool
+
+	// Whether the advertisement is held until Release is called.
+	// Protected by advertisersLock.
+	held bool
+
+	// Error of the device watcher for the interface discovery and the
+	// number of its restarts. Protected by advertisersLock.
+	discoveryError    string
+	discoveryRestarts int
 
 	advertisers     map[string]*advertiser
 	advertisersLock sync.RWMutex
+
+	// The running configuration with the defaults, but without the
+	// generated ULA prefixes. The base of Patch. configLock also
+	// serializes Reload and Patch.
+	config     *Config
+	configLock sync.Mutex
+
+	// Metrics about the reloads
+	reloadMetrics *reloadMetrics
+
+	// Subscribers of the Status changes. statusChangedCh notifies the
+	// publisher about the change.
+	subscribers     map[int]chan Status
+	nextSubscriber  int
+	subscribersLock sync.Mutex
+	statusChangedCh chan any
 }
 
+// ErrReloadRolledBack is returned from Reload when the new configuration
+// couldn't be applied to some interface and the daemon is rolled back to the
+// previous configuration
+var ErrReloadRolledBack = errors.New("reload rolled back")
+
+// ErrStartupFailed is returned from Run when some interface isn't running by
+// the deadline of WithFailFast
+var ErrStartupFailed = errors.New("startup failed")
+
+// ErrValidationHook is returned from NewDaemon and Reload when the hook given
+// by WithValidationHook rejects the configuration. The error of the hook is
+// wrapped as well.
+var ErrValidationHook = errors.New("validation hook rejected the configuration")
+
+// reloadRequest is the new configuration passed from Reload to Run. Run
+// replies the result of applying it to errCh.
+type reloadRequest struct {
+	config *Config
+	errCh  chan error
+}
+
+// Capacity of the channel returned by Daemon.Subscribe
+const subscriberBufferSize = 16
+
+// Timeout of the default HTTP client
+const defaultHTTPTimeout = 10 * time.Second
+
 // NewDaemon creates a new Daemon instance with the provided configuration and
-// options. It returns ValidationErrors if the configuration is invalid.
+// options. It returns ValidationErrors if the configuration is invalid. It also
+// returns ErrSelfTest if the RA message built from the configuration cannot be
+// marshaled or doesn't fit into the MTU, and ErrValidationHook if the hook
+// given by WithValidationHook rejects the configuration.
 func NewDaemon(config *Config, opts ...DaemonOption) (*Daemon, error) {
 	// Take a copy of the new configuration. c.validate() will modify it to
 	// set default values.
 	c := config.deepCopy()
 
 	// Validate the configuration first
-	if err := c.defaultAndValidate(); err != nil {
+	warnings, err := c.defaultAndValidateWithWarnings()
+	if err != nil {
+		return nil, err
+	}
+
+	running := c.deepCopy()
+
+	if err := c.applyAutoULA(); err != nil {
+		return nil, err
+	}
+
+	if err := selfTest(c); err != nil {
 		return nil, err
 	}
 
 	d := &Daemon{
 		initialConfig:     c,
-		reloadCh:          make(chan *Config),
+		config:            running,
+		reloadCh:          make(chan *reloadRequest),
 		logger:            slog.Default(),
 		socketConstructor: newSocket,
 		deviceWatcher:     newDeviceWatcher(),
+		malformedRSPolicy: MalformedRSCount,
+		sysctl:            procSysctl{},
+		httpClient:        &http.Client{Timeout: defaultHTTPTimeout},
+		clock:             realClock{},
 		advertisers:       map[string]*advertiser{},
+		reloadMetrics:     newReloadMetrics(),
+		subscribers:       map[int]chan Status{},
+		statusChangedCh:   make(chan any, 1),
 	}
 
 	for _, opt := range opts {
 		opt(d)
 	}
 
+	if w, ok := d.deviceWatcher.(*pollingDeviceWatcher); ok && w.interval <= 0 {
+		return nil, fmt.Errorf("device poll interval must be positive")
+	}
+
+	if d.minInterval < 0 {
+		return nil, fmt.Errorf("minimum effective interval must not be negative")
+	}
+
+	if d.httpClient == nil {
+		return nil, fmt.Errorf("HTTP client must not be nil")
+	}
+
+	if d.recvBufferSize < 0 {
+		return nil, fmt.Errorf("socket receive buffer size must not be negative")
+	}
+
+	if d.sendStagger < 0 {
+		return nil, fmt.Errorf("send stagger window must not be negative")
+	}
+
+	if d.statusInterval < 0 {
+		return nil, fmt.Errorf("status notification interval must not be negative")
+	}
+
+	if d.failFastDeadline < 0 {
+		return nil, fmt.Errorf("fail-fast deadline must not be negative")
+	}
+
+	if err := d.runValidationHook(running); err != nil {
+		return nil, err
+	}
+
+	switch d.malformedRSPolicy {
+	case MalformedRSDrop, MalformedRSCount, MalformedRSLog:
+	default:
+		return nil, fmt.Errorf("unknown malformed RS policy %q", d.malformedRSPolicy)
+	}
+
+	if d.interfaceTemplate != nil {
+		// The Name field is filled for each discovered interface. Put a
+		// placeholder here to validate the rest of the template.
+		t := d.interfaceTemplate
+		t.Name = "template"
+		tc := &Config{Interfaces: []*InterfaceConfig{t}}
+		templateWarnings, err := tc.defaultAndValidateWithWarnings()
+		if err != nil {
+			return nil, err
+		}
+		if err := selfTest(tc); err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, templateWarnings...)
+	}
+
+	d.logWarnings(warnings)
+
 	return d, nil
 }
 
-// Run starts the daemon and blocks until the context is cancelled
-func (d *Daemon) Run(ctx context.Context) {
+// Run starts the daemon and blocks until the context is cancelled. It returns
+// nil when the context is cancelled. With WithFailFast, it stops the daemon and
+// returns ErrStartupFailed if any interface isn't running by the deadline.
+func (d *Daemon) Run(ctx context.Context) error {
 	d.logger.Info("Starting daemon")
 
-	// Current desired configuration
+	// Stop everything started here when returning
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Fires at the startup deadline of WithFailFast
+	var failFastCh <-chan time.Time
+	if d.failFastDeadline > 0 {
+		failFastCh = time.After(d.failFastDeadline)
+	}
+
+	// Current desired configuration and its generation
 	config := d.initialConfig
+	generation := 1
+
+	// The last configuration applied successfully. The failed reload rolls
+	// back to it.
+	appliedConfig := config
+	appliedIfaceConfigs := map[string]*InterfaceConfig{}
+
+	// The reload request being applied. Nil when the iteration is
+	// triggered by the device event.
+	var pending *reloadRequest
+
+	// Publish the Status changes to the subscribers
+	go d.publishStatus(ctx)
+
+	// Devices on the system and the set of the dynamically discovered
+	// interfaces matching the selector, AllInterfaces, or
+	// InterfaceMACPrefixes
+	devices := map[string]InterfaceInfo{}
+	discovered := map[string]struct{}{}
+
+	// Watch the devices only when any of them is set. Otherwise,
+	// devEventCh stays nil and never fires.
+	var devEventCh <-chan deviceEvent
+	watching := false
 
 reload:
 	// Main loop
 	for {
+		if !watching && (d.interfaceSelector != nil || config.AllInterfaces || len(config.InterfaceMACPrefixes) > 0) {
+			watching = true
+			ch, err := watchAllWithRestart(ctx, d.deviceWatcher, d.setDiscoveryError)
+			if err != nil {
+				d.logger.Error("Failed to watch devices. Interface discovery is disabled.", "error", err.Error())
+				d.advertisersLock.Lock()
+				d.discoveryError = err.Error()
+				d.advertisersLock.Unlock()
+				d.notifyStatus()
+			} else {
+				devEventCh = ch
+			}
+		}
+
+		// AllInterfaces or InterfaceMACPrefixes may have been changed
+		// by the reload
+		discovered = map[string]struct{}{}
+		for name, info := range devices {
+			if d.selectInterface(config, info) {
+				discovered[name] = struct{}{}
+			}
+		}
+
 		var (
 			toAdd    []*InterfaceConfig
 			toUpdate []*advertiser
 			toRemove []*advertiser
 		)
 
-		// We may modify the advertiser map from now
+		// Only this loop modifies the advertiser map. Hold the lock
+		// anyway not to race with the readers.
 		d.advertisersLock.Lock()
 
 		// Cache the interface => config mapping for later use
@@ -77,88 +302,374 @@ reload:
 		for _, c := range config.Interfaces {
 			if advertiser, ok := d.advertisers[c.Name]; !ok {
 				toAdd = append(toAdd, c)
+			} else if advertiser.initialConfig.Index != c.Index || advertiser.initialConfig.NetnsPath != c.NetnsPath {
+				// The device to watch has changed. Replace
+				// the advertiser.
+				toRemove = append(toRemove, advertiser)
+				toAdd = append(toAdd, c)
 			} else {
 				toUpdate = append(toUpdate, advertiser)
 			}
 			ifaceConfigs[c.Name] = c
 		}
+		for name := range discovered {
+			// Explicit configuration takes precedence over the template
+			if _, ok := ifaceConfigs[name]; ok {
+				continue
+			}
+			c := d.templateConfig(name)
+			if err := c.applyAutoULA(); err != nil {
+				d.logger.Error("Failed to generate ULA prefix", slog.String("interface", name), "error", err.Error())
+			}
+			if advertiser, ok := d.advertisers[name]; !ok {
+				toAdd = append(toAdd, c)
+			} else {
+				toUpdate = append(toUpdate, advertiser)
+			}
+			ifaceConfigs[name] = c
+		}
 		for name, advertiser := range d.advertisers {
 			if _, ok := ifaceConfigs[name]; !ok {
 				toRemove = append(toRemove, advertiser)
 			}
 		}
 
-		// Add new per-interface jobs
-		for _, c := range toAdd {
-			d.logger.Info("Adding new RA sender", slog.String("interface", c.Name))
-			advertiser := newAdvertiser(c, d.socketConstructor, d.deviceWatcher, d.logger)
-			go advertiser.run(ctx)
-			d.advertisers[c.Name] = advertiser
-		}
+		d.advertisersLock.Unlock()
 
-		// Update (reload) existing workers
+		// Update (reload) existing workers first, so that the failure
+		// can be rolled back before adding or removing any worker. This
+		// may block until the timeout, so do it without holding the
+		// lock not to block Status. Only this loop removes the
+		// advertisers from the map, so they're still valid here. Each
+		// advertiser reports its own AppliedGeneration once it applies
+		// the configuration.
+		var applyErr error
 		for _, advertiser := range toUpdate {
 			iface := advertiser.initialConfig.Name
 			d.logger.Info("Updating RA sender", slog.String("interface", iface))
 			// Set timeout to guarantee progress
 			timeout, cancelTimeout := context.WithTimeout(ctx, time.Second*3)
-			advertiser.reload(timeout, ifaceConfigs[iface])
+			err := advertiser.reload(timeout, ifaceConfigs[iface], generation)
 			cancelTimeout()
+			if err != nil {
+				applyErr = fmt.Errorf("%w: interface %s: %w", ErrReloadRolledBack, iface, err)
+				d.logger.Error("Failed to update RA sender. Rolling back.", slog.String("interface", iface), "error", err.Error())
+				// Roll back with the new generation. The
+				// failed one may have been applied to some
+				// interfaces, so reusing it would make the
+				// same generation mean two configurations.
+				generation++
+				d.rollback(ctx, advertiser, appliedIfaceConfigs, generation)
+				break
+			}
 		}
 
-		// Remove unnecessary workers
-		for _, advertiser := range toRemove {
-			iface := advertiser.initialConfig.Name
-			d.logger.Info("Deleting RA sender", slog.String("interface", iface))
-			advertiser.stop()
-			delete(d.advertisers, iface)
+		if applyErr != nil {
+			d.advertisersLock.Lock()
+			d.generation = generation
+			d.advertisersLock.Unlock()
+
+			d.notifyStatus()
+
+			config = appliedConfig
+		} else {
+			d.advertisersLock.Lock()
+
+			d.generation = generation
+
+			// Remove unnecessary workers. Do this before adding the new
+			// ones since the replaced advertiser has the same name.
+			for _, advertiser := range toRemove {
+				iface := advertiser.initialConfig.Name
+				d.logger.Info("Deleting RA sender", slog.String("interface", iface))
+				advertiser.stop()
+				delete(d.advertisers, iface)
+			}
+
+			// Add new per-interface jobs
+			for _, c := range toAdd {
+				d.logger.Info("Adding new RA sender", slog.String("interface", c.Name))
+				advertiser := newAdvertiser(c, generation, d.advertiserOptions())
+				if d.paused || d.held {
+					advertiser.setPaused(true)
+				}
+				go advertiser.run(ctx)
+				d.advertisers[c.Name] = advertiser
+			}
+
+			if d.sendStagger > 0 {
+				d.staggerSends()
+			}
+
+			// The set of the advertisers and the generation are
+			// updated atomically. Status never observes the
+			// half-applied set.
+			d.advertisersLock.Unlock()
+
+			d.notifyStatus()
+
+			appliedConfig = config
+			appliedIfaceConfigs = ifaceConfigs
 		}
 
-		d.advertisersLock.Unlock()
+		if pending != nil {
+			pending.errCh <- applyErr
+			pending = nil
+		}
 
 		// Wait for the events
 		for {
 			select {
-			case newConfig := <-d.reloadCh:
+			case req := <-d.reloadCh:
 				d.logger.Info("Reloading configuration")
-				config = newConfig
+				config = req.config
+				pending = req
+				generation++
 				continue reload
+			case ev := <-devEventCh:
+				name := ev.info.Name
+				if ev.deleted {
+					delete(devices, name)
+				} else {
+					devices[name] = ev.info
+				}
+				_, wasSelected := discovered[name]
+				selected := !ev.deleted && d.selectInterface(config, ev.info)
+				if selected == wasSelected {
+					continue
+				}
+				if selected {
+					d.logger.Info("Discovered interface matching the selector", slog.String("interface", name))
+					discovered[name] = struct{}{}
+				} else {
+					d.logger.Info("Interface no longer matches the selector", slog.String("interface", name))
+					delete(discovered, name)
+				}
+				continue reload
+			case <-failFastCh:
+				failFastCh = nil
+				if err := d.checkStartup(); err != nil {
+					d.logger.Error("Interfaces failed to start. Shutting down daemon.", "error", err.Error())
+					return err
+				}
 			case <-ctx.Done():
 				d.logger.Info("Shutting down daemon")
-				return
+				return nil
 			}
 		}
 	}
 }
 
+// advertiserOptions returns the daemon-wide settings for the new advertiser
+func (d *Daemon) advertiserOptions() *advertiserOptions {
+	return &advertiserOptions{
+		socketCtor:          d.newSocket,
+		deviceWatcher:       d.deviceWatcher,
+		flapGrace:           d.flapGrace,
+		rsHandler:           d.rsHandler,
+		dnsHealthCheck:      d.dnsHealthCheck,
+		prefixChangeHandler: d.prefixChangeHandler,
+		malformedRSPolicy:   d.malformedRSPolicy,
+		minInterval:         d.minInterval,
+		sysctl:              d.sysctl,
+		httpClient:          d.httpClient,
+		notifyStatus:        d.notifyStatus,
+		clock:               d.clock,
+		logger:              d.logger,
+	}
+}
+
+// checkStartup returns ErrStartupFailed if any interface isn't running
+func (d *Daemon) checkStartup() error {
+	failed := []string{}
+	for _, iface := range d.Status().Interfaces {
+		if iface.State != Running {
+			failed = append(failed, iface.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", ErrStartupFailed, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
 // Reload reloads the configuration of the daemon. The context passed to this
-// function is used to cancel the potentially long-running operations during
-// the reload process. Currently, the result of the unsucecssful or cancelled
-// reload is undefined and the daemon may be running with either the old or the
-// new configuration or both. It returns ValidationErrors if the configuration
-// is invalid.
+// function is used to cancel the reload before the new configuration is handed
+// over to the daemon. Once handed over, Reload waits for the result. When the
+// new configuration cannot be applied to some interface, the interfaces are
+// rolled back to the previous configuration with a new generation and
+// ErrReloadRolledBack is returned. The daemon keeps running with the previous
+// configuration in that case. It returns ValidationErrors if the configuration
+// is invalid. Same as NewDaemon, it also returns ErrSelfTest if the RA message
+// built from the configuration cannot be marshaled or doesn't fit into the MTU,
+// and ErrValidationHook if the hook rejects the configuration.
 func (d *Daemon) Reload(ctx context.Context, newConfig *Config) error {
+	start := time.Now()
+
+	d.configLock.Lock()
+	err := d.reload(ctx, newConfig)
+	d.configLock.Unlock()
+
+	d.reloadMetrics.observe(start, reloadFailureReason(err))
+
+	return err
+}
+
+// Patch applies the partial update to the running configuration and reloads
+// the daemon with the result. The running configuration includes the default
+// values, but not the ULA prefixes generated by AutoULA. It returns
+// ErrInvalidPatch if the patch cannot be applied (e.g. unknown field). The
+// other errors are the same as Reload.
+func (d *Daemon) Patch(ctx context.Context, patch *ConfigPatch) error {
+	start := time.Now()
+
+	d.configLock.Lock()
+	err := d.patch(ctx, patch)
+	d.configLock.Unlock()
+
+	d.reloadMetrics.observe(start, reloadFailureReason(err))
+
+	return err
+}
+
+func (d *Daemon) patch(ctx context.Context, patch *ConfigPatch) error {
+	c := d.config.deepCopy()
+	if err := patch.apply(c); err != nil {
+		return err
+	}
+	return d.reload(ctx, c)
+}
+
+// reload is the body of Reload. The caller must hold configLock.
+func (d *Daemon) reload(ctx context.Context, newConfig *Config) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// Take a copy of the new configuration. c.validate() will modify it to
 	// set default values.
 	c := newConfig.deepCopy()
 
-	if err := c.defaultAndValidate(); err != nil {
+	warnings, err := c.defaultAndValidateWithWarnings()
+	if err != nil {
+		return err
+	}
+
+	if err := d.runValidationHook(c); err != nil {
 		return err
 	}
 
+	running := c.deepCopy()
+
+	if err := c.applyAutoULA(); err != nil {
+		return err
+	}
+
+	if err := selfTest(c); err != nil {
+		return err
+	}
+
+	d.logWarnings(warnings)
+
+	req := &reloadRequest{config: c, errCh: make(chan error, 1)}
 	select {
-	case d.reloadCh <- c:
+	case d.reloadCh <- req:
 	case <-ctx.Done():
 		return ctx.Err()
 	}
 
+	// Run always replies once it receives the request
+	if err := <-req.errCh; err != nil {
+		return err
+	}
+
+	d.config = running
+
 	return nil
 }
 
+// rollback reloads all advertisers except the failed one with the previously
+// applied configuration. The failed advertiser didn't receive the new
+// configuration, so it's still running the previous one.
+func (d *Daemon) rollback(ctx context.Context, failed *advertiser, configs map[string]*InterfaceConfig, generation int) {
+	d.advertisersLock.RLock()
+	advertisers := []*advertiser{}
+	for _, advertiser := range d.advertisers {
+		if advertiser != failed {
+			advertisers = append(advertisers, advertiser)
+		}
+	}
+	d.advertisersLock.RUnlock()
+
+	for _, advertiser := range advertisers {
+		iface := advertiser.initialConfig.Name
+		c, ok := configs[iface]
+		if !ok {
+			continue
+		}
+		d.logger.Info("Rolling back RA sender", slog.String("interface", iface))
+		timeout, cancelTimeout := context.WithTimeout(ctx, time.Second*3)
+		if err := advertiser.reload(timeout, c, generation); err != nil {
+			d.logger.Error("Failed to roll back RA sender", slog.String("interface", iface), "error", err.Error())
+		}
+		cancelTimeout()
+	}
+}
+
+// runValidationHook runs the hook of WithValidationHook on the copy of the
+// validated configuration
+func (d *Daemon) runValidationHook(c *Config) error {
+	if d.validationHook == nil {
+		return nil
+	}
+	if err := d.validationHook(c.deepCopy()); err != nil {
+		return fmt.Errorf("%w: %w", ErrValidationHook, err)
+	}
+	return nil
+}
+
+func (d *Daemon) logWarnings(warnings []Warning) {
+	for _, w := range warnings {
+		d.logger.Warn("Configuration warning", slog.String("interface", w.Interface), "field", w.Field, "message", w.Message)
+	}
+}
+
+// ReloadYAML parses the YAML-encoded configuration from the reader and reloads
+// the daemon with it. It returns the parse error or ValidationErrors without
+// touching the running configuration. See Reload for more details.
+func (d *Daemon) ReloadYAML(ctx context.Context, r io.Reader) error {
+	start := time.Now()
+	c, err := ParseConfigYAML(r)
+	if err != nil {
+		d.reloadMetrics.observe(start, ReloadFailureParse)
+		return err
+	}
+	return d.Reload(ctx, c)
+}
+
+// ReloadJSON parses the JSON-encoded configuration from the reader and reloads
+// the daemon with it. It returns the parse error or ValidationErrors without
+// touching the running configuration. See Reload for more details.
+func (d *Daemon) ReloadJSON(ctx context.Context, r io.Reader) error {
+	start := time.Now()
+	c, err := ParseConfigJSON(r)
+	if err != nil {
+		d.reloadMetrics.observe(start, ReloadFailureParse)
+		return err
+	}
+	return d.Reload(ctx, c)
+}
+
 // Status returns the current status of the daemon
 func (d *Daemon) Status() *Status {
 	d.advertisersLock.RLock()
 
+	generation := d.generation
+	paused := d.paused
+	held := d.held
+	discoveryError := d.discoveryError
+	discoveryRestarts := d.discoveryRestarts
+
 	ifaceStatus := []*InterfaceStatus{}
 	for _, advertiser := range d.advertisers {
 		ifaceStatus = append(ifaceStatus, advertiser.status())
@@ -170,7 +681,338 @@ func (d *Daemon) Status() *Status {
 		return ifaceStatus[i].Name < ifaceStatus[j].Name
 	})
 
-	return &Status{Interfaces: ifaceStatus}
+	return &Status{
+		Generation:              generation,
+		Paused:                  paused,
+		Held:                    held,
+		DeviceDiscoveryError:    discoveryError,
+		DeviceDiscoveryRestarts: discoveryRestarts,
+		Interfaces:              ifaceStatus,
+	}
+}
+
+// setDiscoveryError reports the failure of the device watcher for the
+// interface discovery. The nil error means the watcher is restarted.
+func (d *Daemon) setDiscoveryError(err error) {
+	d.advertisersLock.Lock()
+	if err != nil {
+		d.discoveryError = err.Error()
+	} else {
+		d.discoveryError = ""
+		d.discoveryRestarts++
+	}
+	d.advertisersLock.Unlock()
+
+	if err != nil {
+		d.logger.Warn("Device discovery failed. Restarting.", "error", err.Error())
+	} else {
+		d.logger.Info("Device discovery restarted")
+	}
+
+	d.notifyStatus()
+}
+
+// PauseAll pauses the advertisement on all interfaces for the coordinated
+// maintenance. Each interface sends a final RA with zero router lifetime and
+// stops sending both unsolicited and solicited RAs until ResumeAll is called.
+// The interfaces added while paused start paused. The configuration is not
+// modified.
+func (d *Daemon) PauseAll(ctx context.Context) error {
+	return d.setPaused(ctx, true)
+}
+
+// ResumeAll resumes the advertisement paused by PauseAll. Each interface sends
+// an RA immediately and restarts the initial burst of InitialRACount RAs.
+func (d *Daemon) ResumeAll(ctx context.Context) error {
+	return d.setPaused(ctx, false)
+}
+
+func (d *Daemon) setPaused(ctx context.Context, paused bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.advertisersLock.Lock()
+	defer d.advertisersLock.Unlock()
+
+	if d.paused == paused {
+		return nil
+	}
+
+	if paused {
+		d.logger.Info("Pausing advertisement on all interfaces")
+	} else {
+		d.logger.Info("Resuming advertisement on all interfaces")
+	}
+
+	d.paused = paused
+	for _, advertiser := range d.advertisers {
+		advertiser.setPaused(paused || d.held)
+	}
+
+	d.notifyStatus()
+
+	return nil
+}
+
+// Release starts the advertisement held by WithStartHeld. Each interface
+// starts the initial burst of the unsolicited RAs unless the advertisement is
+// paused by PauseAll. It does nothing if the advertisement is not held.
+func (d *Daemon) Release(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.advertisersLock.Lock()
+	defer d.advertisersLock.Unlock()
+
+	if !d.held {
+		return nil
+	}
+
+	d.logger.Info("Releasing the held advertisement")
+
+	d.held = false
+	for _, advertiser := range d.advertisers {
+		advertiser.setPaused(d.paused)
+	}
+
+	d.notifyStatus()
+
+	return nil
+}
+
+// Subscribe returns a channel that receives a new Status snapshot whenever
+// the state of the interfaces changes (e.g. running, failing, reloading, or
+// stopped), the set of the interfaces changes, or the advertisement is paused
+// or resumed. The snapshots are published while the daemon is running. The
+// channel is bounded and the oldest snapshot is dropped when the subscriber
+// is slow. The returned function unsubscribes and closes the channel. It is
+// safe to call it multiple times.
+func (d *Daemon) Subscribe() (<-chan Status, func()) {
+	ch := make(chan Status, subscriberBufferSize)
+
+	d.subscribersLock.Lock()
+	id := d.nextSubscriber
+	d.nextSubscriber++
+	d.subscribers[id] = ch
+	d.subscribersLock.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			d.subscribersLock.Lock()
+			defer d.subscribersLock.Unlock()
+			delete(d.subscribers, id)
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// notifyStatus notifies the publisher about the Status change. It never
+// blocks. The notifications coalesce while the publisher is busy.
+func (d *Daemon) notifyStatus() {
+	select {
+	case d.statusChangedCh <- struct{}{}:
+	default:
+	}
+}
+
+// publishStatus sends the Status snapshot to the subscribers on each
+// notification until the context is cancelled. The notifications during the
+// status notification interval coalesce into the next snapshot.
+func (d *Daemon) publishStatus(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.statusChangedCh:
+		}
+
+		status := d.Status()
+
+		d.subscribersLock.Lock()
+		for _, ch := range d.subscribers {
+			select {
+			case ch <- *status:
+				continue
+			default:
+			}
+			// The subscriber is slow. Drop the oldest one to
+			// make room. This is the only sender, so the send
+			// below never blocks.
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- *status
+		}
+		d.subscribersLock.Unlock()
+
+		// Throttle the snapshots. The change during the wait is
+		// published after it with the latest Status.
+		if d.statusInterval > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(d.statusInterval):
+			}
+		}
+	}
+}
+
+// PoisonInterface makes the interface advertise zero router lifetime and zero
+// prefix lifetimes for the given duration. The hosts on the link immediately
+// stop using the router as a default router and deprecate the addresses of
+// the prefixes. However, the addresses are not removed immediately. RFC4862
+// Section 5.5.3(e) doesn't let the unauthenticated RA reduce the remaining
+// valid lifetime below two hours, so the addresses stay valid for up to two
+// hours. The advertisement reverts to the normal one after the duration.
+// Calling it again during the poisoning period overrides the end of the
+// period. The configuration is not modified. It returns an error if the
+// interface is not found.
+func (d *Daemon) PoisonInterface(iface string, duration time.Duration) error {
+	if duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+
+	d.advertisersLock.RLock()
+	defer d.advertisersLock.RUnlock()
+
+	advertiser, ok := d.advertisers[iface]
+	if !ok {
+		return fmt.Errorf("interface %s not found", iface)
+	}
+
+	advertiser.poison(duration)
+
+	return nil
+}
+
+// ActivateAlias switches the options advertised on the interface to the ones
+// of the alias in InterfaceConfig.Aliases without reloading the
+// configuration. The RA with the new options is sent immediately. An empty
+// alias switches back to the options of the interface itself. It returns an
+// error if the interface or the alias is not found. If a later reload removes
+// the active alias, the interface falls back to its own options.
+func (d *Daemon) ActivateAlias(iface, alias string) error {
+	d.advertisersLock.RLock()
+	defer d.advertisersLock.RUnlock()
+
+	advertiser, ok := d.advertisers[iface]
+	if !ok {
+		return fmt.Errorf("interface %s not found", iface)
+	}
+
+	if alias != "" && !advertiser.hasAlias(alias) {
+		return fmt.Errorf("alias %s not found on interface %s", alias, iface)
+	}
+
+	advertiser.setAlias(alias)
+
+	return nil
+}
+
+// TimeToNextRA returns the time until the next unsolicited RA is sent on the
+// interface. It returns an error if the interface is not found or no RA is
+// scheduled (e.g. the device is down or the advertisement is paused). It
+// covers the unsolicited RAs only. See RateLimiterState for the multicast
+// reply to the RS delayed by MinDelayBetweenRAsMilliseconds.
+func (d *Daemon) TimeToNextRA(iface string) (time.Duration, error) {
+	d.advertisersLock.RLock()
+	defer d.advertisersLock.RUnlock()
+
+	advertiser, ok := d.advertisers[iface]
+	if !ok {
+		return 0, fmt.Errorf("interface %s not found", iface)
+	}
+
+	return advertiser.timeToNextRA()
+}
+
+// RLState is the state of the rate limiting of the multicast RAs on the
+// interface returned by Daemon.RateLimiterState
+type RLState struct {
+	// Time of the last multicast RA, either unsolicited or in reply to
+	// the RS. Zero if no multicast RA has been sent.
+	LastMulticastRA time.Time
+
+	// The earliest time the next multicast reply to the RS can be sent.
+	// Zero if MinDelayBetweenRAsMilliseconds is not set.
+	NextMulticastAllowed time.Time
+
+	// Time the delayed multicast reply to the RS is scheduled at. Zero if
+	// no reply is pending.
+	PendingReplyAt time.Time
+}
+
+// RateLimiterState returns the state of the rate limiting of the multicast
+// RAs on the interface (see MinDelayBetweenRAsMilliseconds). This is useful
+// to debug why the reply to the RS is delayed. It returns an error if the
+// interface is not found.
+func (d *Daemon) RateLimiterState(iface string) (*RLState, error) {
+	d.advertisersLock.RLock()
+	defer d.advertisersLock.RUnlock()
+
+	advertiser, ok := d.advertisers[iface]
+	if !ok {
+		return nil, fmt.Errorf("interface %s not found", iface)
+	}
+
+	return advertiser.rateLimiterState(), nil
+}
+
+// selectInterface returns true when the discovered interface should be
+// advertised with the template
+func (d *Daemon) selectInterface(config *Config, info InterfaceInfo) bool {
+	if config.excludesInterface(info.Name) {
+		return false
+	}
+	if config.AllInterfaces && info.Up && !info.Loopback {
+		return true
+	}
+	if info.Up && matchesMACPrefixes(config.InterfaceMACPrefixes, info.HardwareAddr) {
+		return true
+	}
+	return d.interfaceSelector != nil && d.interfaceSelector(info)
+}
+
+// templateConfig returns the configuration of the discovered interface. The
+// defaults are used when there's no template.
+func (d *Daemon) templateConfig(name string) *InterfaceConfig {
+	if d.interfaceTemplate != nil {
+		c := d.interfaceTemplate.deepCopy()
+		c.Name = name
+		return c
+	}
+	c := &Config{Interfaces: []*InterfaceConfig{{Name: name}}}
+	if err := defaults.Set(c); err != nil {
+		panic("BUG (Please report 🙏): Defaulting failed: " + err.Error())
+	}
+	return c.Interfaces[0]
+}
+
+// staggerSends spreads the unsolicited RAs of the advertisers evenly over the
+// stagger window in the order of the interface name. The caller must hold
+// advertisersLock.
+func (d *Daemon) staggerSends() {
+	names := make([]string, 0, len(d.advertisers))
+	for name := range d.advertisers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		d.advertisers[name].setSendDelay(d.sendStagger * time.Duration(i) / time.Duration(len(names)))
+	}
+}
+
+// newSocket creates the socket with the daemon-wide socket options
+func (d *Daemon) newSocket(name string, opts socketOpts) (socket, error) {
+	opts.recvBufferSize = d.recvBufferSize
+	opts.nonBlockingRecv = d.nonBlockingRecv
+	return d.socketConstructor(name, opts)
 }
 
 // DaemonOption is an optional parameter for the Daemon constructor
@@ -183,6 +1025,244 @@ func WithLogger(l *slog.Logger) DaemonOption {
 	}
 }
 
+// WithFlapGrace sets the grace period for the interface flaps. When the
+// interface goes down, the daemon stops advertising on the interface, but
+// keeps the socket until the grace period expires. If the interface comes
+// back up within the grace period, the advertisement resumes without
+// recreating the socket. By default, the socket is torn down immediately.
+func WithFlapGrace(grace time.Duration) DaemonOption {
+	return func(d *Daemon) {
+		d.flapGrace = grace
+	}
+}
+
+// WithMinEffectiveInterval raises the RAIntervalMilliseconds and
+// InitialRAIntervalMilliseconds of all interfaces below the given interval to
+// it. This guards the CPU against the intervals close to the validation floor
+// (70ms) configured on many interfaces. The clamp is logged once per
+// interface. It doesn't affect the validation, and the short intervals are
+// reported as the warnings regardless of the clamp. By default, the
+// configured interval is used as is.
+func WithMinEffectiveInterval(interval time.Duration) DaemonOption {
+	return func(d *Daemon) {
+		d.minInterval = interval
+	}
+}
+
+// RSHandler is a callback invoked for each Router Solicitation received on
+// the interface. Returning false suppresses the RA sent in reply to the RS,
+// so that the handler can handle it by itself.
+type RSHandler func(iface string, rs *ndp.RouterSolicitation, from netip.Addr) bool
+
+// WithRSHandler sets the handler called for each received RS. The handler is
+// called synchronously from the advertisement loop of the interface, so it
+// must not block.
+func WithRSHandler(h RSHandler) DaemonOption {
+	return func(d *Daemon) {
+		d.rsHandler = h
+	}
+}
+
+// DNSHealthCheck is a callback to check whether the DNS resolvers advertised
+// on the interface are healthy (e.g. reachable via a healthy upstream).
+type DNSHealthCheck func(iface string) bool
+
+// WithDNSHealthCheck sets the health check of the DNS resolvers. It's
+// consulted every second independently from the RA interval. While the check
+// reports unhealthy, the RDNSS and DNSSL options are advertised with zero
+// lifetime to withdraw the resolvers in both unsolicited and solicited RAs.
+// When the result changes, the RA is sent immediately. The check is called
+// synchronously from the advertisement loop of the interface, so it must not
+// block.
+func WithDNSHealthCheck(check DNSHealthCheck) DaemonOption {
+	return func(d *Daemon) {
+		d.dnsHealthCheck = check
+	}
+}
+
+// MalformedRSPolicy controls how the RS which cannot be parsed is handled
+type MalformedRSPolicy string
+
+// Possible malformed RS policies
+const (
+	// MalformedRSDrop drops the malformed RS silently
+	MalformedRSDrop MalformedRSPolicy = "drop-silent"
+	// MalformedRSCount drops the malformed RS and counts it in
+	// InterfaceStatus.RxMalformedRS
+	MalformedRSCount MalformedRSPolicy = "count"
+	// MalformedRSLog drops the malformed RS, counts it, and logs it with
+	// the source address
+	MalformedRSLog MalformedRSPolicy = "log"
+)
+
+// WithMalformedRSPolicy sets the policy to handle the malformed RS. The
+// malformed RS is never replied regardless of the policy. By default, it's
+// MalformedRSCount. NewDaemon returns an error for the unknown policy.
+func WithMalformedRSPolicy(policy MalformedRSPolicy) DaemonOption {
+	return func(d *Daemon) {
+		d.malformedRSPolicy = policy
+	}
+}
+
+// PrefixChangeEvent is passed to the PrefixChangeHandler when the prefixes
+// advertised on the interface change
+type PrefixChangeEvent struct {
+	// Name of the interface
+	Interface string
+
+	// Prefixes advertised before and after the change
+	Before []AdvertisedPrefix
+	After  []AdvertisedPrefix
+}
+
+// AdvertisedPrefix is the prefix advertised on the interface
+type AdvertisedPrefix struct {
+	Prefix                   string
+	OnLink                   bool
+	Autonomous               bool
+	ValidLifetimeSeconds     int
+	PreferredLifetimeSeconds int
+}
+
+// PrefixChangeHandler is a callback invoked when the prefixes advertised on
+// the interface change (e.g. renumbering). Adding or removing the prefix and
+// changing its flags or configured lifetimes are the changes. It covers the
+// prefixes from the reload, PrefixSource, PrefixRotation, poisoning, and
+// WithdrawalRepeat. The lifetimes decremented by DecrementLifetimes are not.
+type PrefixChangeHandler func(event PrefixChangeEvent)
+
+// WithPrefixChangeHandler sets the handler called when the advertised
+// prefixes change. The changes are also logged and counted in
+// InterfaceStatus.PrefixChanges. The handler is called synchronously from the
+// advertisement loop of the interface, so it must not block.
+func WithPrefixChangeHandler(h PrefixChangeHandler) DaemonOption {
+	return func(d *Daemon) {
+		d.prefixChangeHandler = h
+	}
+}
+
+// InterfaceSelector is a predicate to select the interfaces to advertise
+// dynamically. It is called every time the interface appears or changes.
+type InterfaceSelector func(info InterfaceInfo) bool
+
+// WithInterfaceTemplate enables the dynamic interface discovery. The daemon
+// watches the interfaces on the system and starts advertising on the
+// interfaces matching the selector with the template configuration. The
+// advertisement is stopped when the interface disappears or no longer
+// matches the selector. The Name field of the template is ignored. The
+// interfaces explicitly configured in Config take precedence over the
+// template. The template is not affected by Reload. NewDaemon returns
+// ValidationErrors if the template is invalid.
+func WithInterfaceTemplate(selector InterfaceSelector, template *InterfaceConfig) DaemonOption {
+	return func(d *Daemon) {
+		d.interfaceSelector = selector
+		d.interfaceTemplate = template.deepCopy()
+	}
+}
+
+// WithDevicePollInterval makes the daemon poll the device state with the
+// given interval instead of watching the netlink events. This is useful in
+// the environment where the netlink events are not available (e.g. some
+// sandboxed containers). The changes of the devices are noticed with the
+// delay of up to the interval. By default, the netlink events are watched.
+// NewDaemon returns an error if the interval is not positive.
+func WithDevicePollInterval(interval time.Duration) DaemonOption {
+	return func(d *Daemon) {
+		d.deviceWatcher = newPollingDeviceWatcher(interval, &netDeviceReader{})
+	}
+}
+
+// WithSocketReceiveBuffer sets the size of the kernel receive buffer of the
+// sockets in bytes. Enlarge it on the links with many hosts soliciting at
+// once (e.g. after the power outage) to avoid dropping the RSs. The kernel
+// may clamp the size (see net.core.rmem_max). By default, the kernel default
+// is used. NewDaemon returns an error if the size is negative.
+func WithSocketReceiveBuffer(bytes int) DaemonOption {
+	return func(d *Daemon) {
+		d.recvBufferSize = bytes
+	}
+}
+
+// WithNonBlockingReceive makes the sockets read the received messages in the
+// background and queue them, so that the kernel receive buffer keeps being
+// drained while the RS is handled. Combine it with WithSocketReceiveBuffer on
+// the links with the high RS volume.
+func WithNonBlockingReceive() DaemonOption {
+	return func(d *Daemon) {
+		d.nonBlockingRecv = true
+	}
+}
+
+// WithSendStagger spreads the unsolicited RAs of the interfaces over the
+// given window instead of sending them all at the tick boundary. The daemon
+// assigns each interface an evenly spaced delay within the window in the
+// order of the interface name, so that the sends don't spike the CPU on the
+// system with many interfaces. The delay is reassigned when the interfaces
+// are added or removed. Unlike JitterPercent, the delay doesn't change the
+// interval. The interface whose interval is not longer than its delay is
+// not staggered. By default, there's no stagger. NewDaemon returns an error
+// if the window is negative.
+func WithSendStagger(window time.Duration) DaemonOption {
+	return func(d *Daemon) {
+		d.sendStagger = window
+	}
+}
+
+// WithStatusNotifyInterval throttles the snapshots sent to the subscribers
+// of Subscribe to at most one per interval. The changes within the interval
+// (e.g. the interface flapping) coalesce into a single snapshot sent at the
+// end of the interval, so that the subscribers always receive the latest
+// Status eventually. By default, the snapshot is sent on every change.
+// NewDaemon returns an error if the interval is negative.
+func WithStatusNotifyInterval(interval time.Duration) DaemonOption {
+	return func(d *Daemon) {
+		d.statusInterval = interval
+	}
+}
+
+// WithFailFast makes Run return ErrStartupFailed if any interface isn't
+// running within the given deadline after the start (e.g. the socket cannot
+// be created or the device doesn't exist). This is useful for the supervised
+// environments (e.g. systemd or Kubernetes) to restart or alert on the
+// failure instead of running with the degraded state. The interfaces are not
+// checked after the deadline. By default, Run keeps running and retries.
+// NewDaemon returns an error if the deadline is negative.
+func WithFailFast(deadline time.Duration) DaemonOption {
+	return func(d *Daemon) {
+		d.failFastDeadline = deadline
+	}
+}
+
+// WithStartHeld makes the daemon hold the advertisement until Release is
+// called. Run sets up the interfaces as usual (e.g. the sockets), but they
+// don't send any RA, including the replies to the RSes, while held. This is
+// useful to start advertising only after the other components are ready
+// (e.g. the control plane). Unlike PauseAll, no RA with zero router lifetime
+// is sent since nothing has been advertised yet.
+func WithStartHeld() DaemonOption {
+	return func(d *Daemon) {
+		d.held = true
+	}
+}
+
+// ValidationHook is a callback to check the configuration against the rules
+// beyond the built-in validation (e.g. the prefixes allowed by the
+// organization's policy). It receives a copy of the configuration with the
+// default values, but without the prefixes generated by AutoULA. The non-nil
+// error rejects the configuration.
+type ValidationHook func(config *Config) error
+
+// WithValidationHook sets the hook called after the built-in validation in
+// NewDaemon, Reload, and Patch. When the hook returns an error, the
+// configuration is not applied and ErrValidationHook is returned with the
+// error of the hook. The hook is not called for the interfaces created from
+// the template of WithInterfaceTemplate.
+func WithValidationHook(hook ValidationHook) DaemonOption {
+	return func(d *Daemon) {
+		d.validationHook = hook
+	}
+}
+
 // withSocketConstructor overrides the default socket constructor with the
 // provided one. For testing purposes only.
 func withSocketConstructor(c socketCtor) DaemonOption {
@@ -198,3 +1278,28 @@ func withDeviceWatcher(w deviceWatcher) DaemonOption {
 		d.deviceWatcher = w
 	}
 }
+
+// WithHTTPClient sets the HTTP client used to poll the prefix sources (see
+// PrefixSourceConfig). This is useful to customize the TLS configuration or
+// the proxy. The default client times out after 10 seconds.
+func WithHTTPClient(client *http.Client) DaemonOption {
+	return func(d *Daemon) {
+		d.httpClient = client
+	}
+}
+
+// withSysctl overrides the default sysctl with the provided one. For testing
+// purposes only.
+func withSysctl(s sysctl) DaemonOption {
+	return func(d *Daemon) {
+		d.sysctl = s
+	}
+}
+
+// withClock overrides the default clock with the provided one. For testing
+// purposes only.
+func withClock(c clock) DaemonOption {
+	return func(d *Daemon) {
+		d.clock = c
+	}
+}
@@ -4,16 +4,29 @@
 package ra
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net"
+	"net/http"
 	"net/netip"
+	"os"
+	"path/filepath"
 	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/mdlayher/ndp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/ipv6"
+	"golang.org/x/sys/unix"
 	"k8s.io/utils/ptr"
 )
 
@@ -50,6 +63,47 @@ outer:
 	return assert.InDelta(ct, interval, diff0, mergin) && assert.InDelta(ct, interval, diff1, mergin)
 }
 
+// assertReservedFieldsZero parses the marshaled RA and ensures the reserved
+// fields and the flag bits we never set are zero in the header and in each
+// option we advertise.
+func assertReservedFieldsZero(t *testing.T, b []byte) {
+	t.Helper()
+
+	// ICMPv6 header (4 bytes) + RA header (12 bytes)
+	require.GreaterOrEqual(t, len(b), 16, "RA is too short")
+	require.Equal(t, uint8(ipv6.ICMPTypeRouterAdvertisement), b[0], "Invalid ICMPv6 type")
+	require.Zero(t, b[1], "ICMPv6 code must be zero")
+
+	// M(7) O(6) H(5) Prf(4-3) P(2) Reserved(1-0). We never set H and P.
+	require.Zero(t, b[5]&0b00100111, "H, P and reserved bits in RA header must be zero")
+	require.NotEqual(t, uint8(0b10), (b[5]>>3)&0b11, "Router preference must not be the reserved value")
+
+	opts := b[16:]
+	for len(opts) > 0 {
+		require.GreaterOrEqual(t, len(opts), 2, "Truncated option")
+
+		typ, length := opts[0], int(opts[1])*8
+		require.NotZero(t, length, "Option length must not be zero")
+		require.GreaterOrEqual(t, len(opts), length, "Truncated option")
+
+		opt := opts[:length]
+		switch typ {
+		case 3: // Prefix Information
+			require.Zero(t, opt[3]&0b00111111, "Reserved1 in Prefix Information option must be zero")
+			require.Equal(t, []byte{0, 0, 0, 0}, opt[12:16], "Reserved2 in Prefix Information option must be zero")
+		case 5: // MTU
+			require.Equal(t, []byte{0, 0}, opt[2:4], "Reserved in MTU option must be zero")
+		case 24: // Route Information
+			require.Zero(t, opt[3]&0b11100111, "Reserved bits in Route Information option must be zero")
+			require.NotEqual(t, uint8(0b10), (opt[3]>>3)&0b11, "Route preference must not be the reserved value")
+		case 25, 31: // RDNSS, DNSSL
+			require.Equal(t, []byte{0, 0}, opt[2:4], "Reserved in RDNSS/DNSSL option must be zero")
+		}
+
+		opts = opts[length:]
+	}
+}
+
 func TestDaemonHappyPath(t *testing.T) {
 	config := &Config{
 		Interfaces: []*InterfaceConfig{
@@ -115,8 +169,8 @@ func TestDaemonHappyPath(t *testing.T) {
 
 	// Create a fake device watcher and inject an initial device state
 	devWatcher := newFakeDeviceWatcher("net0", "net1")
-	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77}})
-	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}})
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee}})
 
 	d, err := NewDaemon(
 		config,
@@ -195,7 +249,7 @@ func TestDaemonHappyPath(t *testing.T) {
 			}
 		}
 		require.NotNil(t, slaOption, "Source Link-Layer Address option is not advertised")
-		require.Equal(t, net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77}, slaOption.Addr)
+		require.Equal(t, net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}, slaOption.Addr)
 
 		// Find and check Prefix Information options
 		prefixOptions := map[netip.Addr]*ndp.PrefixInformation{}
@@ -271,6 +325,19 @@ func TestDaemonHappyPath(t *testing.T) {
 		require.Equal(t, time.Second*1800, nat64prefixInfo.Lifetime)
 	})
 
+	t.Run("Ensure reserved fields are zero in the marshaled RA", func(t *testing.T) {
+		sock, err := reg.getSock("net0")
+		require.NoError(t, err)
+
+		// Sampling one RA
+		ra := <-sock.txMulticastCh()
+
+		b, err := ndp.MarshalMessage(ra.msg)
+		require.NoError(t, err)
+
+		assertReservedFieldsZero(t, b)
+	})
+
 	t.Run("Ensure the status is running and the result is ordered by name", func(t *testing.T) {
 		status := d.Status()
 		require.NoError(t, err)
@@ -283,7 +350,7 @@ func TestDaemonHappyPath(t *testing.T) {
 
 	t.Run("Ensure Source Link Layer Address option is updated after device MAC address change", func(t *testing.T) {
 		// Update the MAC address of net0
-		devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x78}})
+		devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x67}})
 
 		sock, err := reg.getSock("net0")
 		require.NoError(t, err)
@@ -303,7 +370,7 @@ func TestDaemonHappyPath(t *testing.T) {
 
 			require.NotNil(t, slaOption, "Source Link-Layer Address option is not advertised")
 
-			return slices.Equal(net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x78}, slaOption.Addr)
+			return slices.Equal(net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x67}, slaOption.Addr)
 		})
 	})
 
@@ -393,3 +460,5568 @@ func TestDaemonHappyPath(t *testing.T) {
 		})
 	})
 }
+
+func TestDaemonDevicePolling(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	reader := newFakeDeviceReader()
+	reader.set("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(newPollingDeviceWatcher(time.Millisecond*500, reader)),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	getSLLA := func(ra fakeRA) net.HardwareAddr {
+		for _, option := range ra.msg.Options {
+			if opt, ok := option.(*ndp.LinkLayerAddress); ok {
+				return opt.Addr
+			}
+		}
+		return nil
+	}
+
+	t.Run("Ensure MAC address change is observed after a poll tick", func(t *testing.T) {
+		// Drain the stale notification and change the MAC address
+		// right after the poll.
+		select {
+		case <-reader.polled():
+		default:
+		}
+		<-reader.polled()
+		reader.set("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x67}})
+
+		// The change is not observed until the next poll
+		ra := <-sock.txMulticastCh()
+		require.Equal(t, net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}, getSLLA(ra))
+
+		eventully(t, func() bool {
+			return slices.Equal(net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x67}, getSLLA(<-sock.txMulticastCh()))
+		})
+	})
+}
+
+func TestDaemonInvalidDevicePollInterval(t *testing.T) {
+	_, err := NewDaemon(&Config{}, WithDevicePollInterval(0))
+	require.Error(t, err)
+
+	_, err = NewDaemon(&Config{}, WithDevicePollInterval(-time.Second))
+	require.Error(t, err)
+
+	_, err = NewDaemon(&Config{}, WithDevicePollInterval(time.Second))
+	require.NoError(t, err)
+}
+
+func TestDaemonVRF(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				VRF:                    "blue",
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure the configured VRF reaches the socket constructor", func(t *testing.T) {
+		require.Equal(t, "blue", sock.opts.vrf)
+	})
+
+	t.Run("Ensure the socket is recreated on VRF change", func(t *testing.T) {
+		config.Interfaces[0].VRF = "red"
+
+		timeout, cancelTimeout := context.WithTimeout(ctx, time.Second)
+		require.NoError(t, d.Reload(timeout, config))
+		cancelTimeout()
+
+		eventully(t, func() bool {
+			newSock, err := reg.getSock("net0")
+			return err == nil && newSock.opts.vrf == "red"
+		})
+		require.True(t, sock.isClosed())
+
+		newSock, err := reg.getSock("net0")
+		require.NoError(t, err)
+		eventully(t, func() bool {
+			_, ok := <-newSock.txMulticastCh()
+			return ok
+		})
+	})
+}
+
+func TestDaemonPointToPoint(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "ppp0",
+				RAIntervalMilliseconds: 100,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:     "2001:db8::/64",
+						Autonomous: true,
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("ppp0")
+	devWatcher.update("ppp0", deviceState{isUp: true, isPointToPoint: true, v6LLAddrAssigned: true})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("ppp0")
+		return err == nil
+	})
+
+	assertNoSLLA := func(t *testing.T, ra fakeRA) {
+		for _, option := range ra.msg.Options {
+			_, ok := option.(*ndp.LinkLayerAddress)
+			require.False(t, ok, "Source Link-Layer Address option is advertised on point-to-point device")
+		}
+		_, err := ndp.MarshalMessage(ra.msg)
+		require.NoError(t, err)
+	}
+
+	t.Run("Ensure unsolicited RA doesn't have SLLA", func(t *testing.T) {
+		ra := <-sock.txMulticastCh()
+		assertNoSLLA(t, ra)
+		require.Len(t, ra.msg.Options, 1)
+	})
+
+	t.Run("Ensure solicited RA doesn't have SLLA", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%ppp0")
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		select {
+		case ra := <-sock.txLLUnicastCh():
+			assertNoSLLA(t, ra)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+}
+
+func TestDaemonLinkLocalStatus(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	eventully(t, func() bool {
+		_, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure status reflects the missing link-local address", func(t *testing.T) {
+		status := d.Status()
+		require.Len(t, status.Interfaces, 1)
+		require.False(t, status.Interfaces[0].HasLinkLocal)
+		require.Empty(t, status.Interfaces[0].LinkLocalAddress)
+	})
+
+	t.Run("Ensure status reflects the assigned link-local address", func(t *testing.T) {
+		devWatcher.update("net0", deviceState{
+			isUp:             true,
+			addr:             net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66},
+			v6LLAddrAssigned: true,
+			v6LLAddr:         netip.MustParseAddr("fe80::1"),
+		})
+
+		eventully(t, func() bool {
+			status := d.Status()
+			return status.Interfaces[0].HasLinkLocal && status.Interfaces[0].LinkLocalAddress == "fe80::1"
+		})
+	})
+
+	t.Run("Ensure status reflects the removed link-local address", func(t *testing.T) {
+		devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+		eventully(t, func() bool {
+			status := d.Status()
+			return !status.Interfaces[0].HasLinkLocal && status.Interfaces[0].LinkLocalAddress == ""
+		})
+	})
+}
+
+func TestDaemonInterfaceIndex(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				// Name doesn't match the device. Only for display.
+				Name:                   "lan",
+				Index:                  7,
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.setIndex("net0", 7)
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("lan")
+		return err == nil
+	})
+
+	t.Run("Ensure the socket binds to the configured index", func(t *testing.T) {
+		require.Equal(t, 7, sock.opts.index)
+	})
+
+	t.Run("Ensure RA is sent", func(t *testing.T) {
+		eventully(t, func() bool {
+			_, ok := <-sock.txMulticastCh()
+			return ok
+		})
+		require.Equal(t, "lan", d.Status().Interfaces[0].Name)
+	})
+}
+
+func TestDaemonNetns(t *testing.T) {
+	// The fake socket constructor doesn't enter the namespace. Any
+	// existing file passes the validation.
+	netnsPath := filepath.Join(t.TempDir(), "router")
+	require.NoError(t, os.WriteFile(netnsPath, nil, 0o644))
+
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				NetnsPath:              netnsPath,
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure the socket is created in the namespace", func(t *testing.T) {
+		require.Equal(t, netnsPath, sock.opts.netnsPath)
+	})
+}
+
+func TestDaemonReloadGeneration(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+			{
+				Name:                   "net1",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	assertGeneration := func(t *testing.T, generation int) {
+		require.EventuallyWithT(t, func(ct *assert.CollectT) {
+			status := d.Status()
+			assert.Equal(ct, generation, status.Generation)
+			if !assert.Len(ct, status.Interfaces, 2) {
+				return
+			}
+			for _, iface := range status.Interfaces {
+				assert.Equal(ct, generation, iface.AppliedGeneration, iface.Name)
+			}
+		}, time.Second*1, time.Millisecond*10)
+	}
+
+	t.Run("Ensure the initial generation is applied", func(t *testing.T) {
+		assertGeneration(t, 1)
+	})
+
+	t.Run("Ensure the generation advances after reload", func(t *testing.T) {
+		config.Interfaces[0].RAIntervalMilliseconds = 200
+
+		timeout, cancelTimeout := context.WithTimeout(context.Background(), time.Second*1)
+		err := d.Reload(timeout, config)
+		cancelTimeout()
+		require.NoError(t, err)
+
+		assertGeneration(t, 2)
+	})
+
+	t.Run("Ensure the generation advances even if the configuration is the same", func(t *testing.T) {
+		timeout, cancelTimeout := context.WithTimeout(context.Background(), time.Second*1)
+		err := d.Reload(timeout, config)
+		cancelTimeout()
+		require.NoError(t, err)
+
+		assertGeneration(t, 3)
+	})
+
+	t.Run("Ensure the generation doesn't advance after invalid reload", func(t *testing.T) {
+		invalid := config.deepCopy()
+		invalid.Interfaces[0].RAIntervalMilliseconds = 69
+
+		timeout, cancelTimeout := context.WithTimeout(context.Background(), time.Second*1)
+		err := d.Reload(timeout, invalid)
+		cancelTimeout()
+		require.Error(t, err)
+
+		assertGeneration(t, 3)
+	})
+}
+
+func TestDaemonStatusDuringReload(t *testing.T) {
+	// Odd generations have net0 and net1, even generations have net0 and
+	// net2
+	newConfig := func(generation int) *Config {
+		other := "net1"
+		if generation%2 == 0 {
+			other = "net2"
+		}
+		return &Config{
+			Interfaces: []*InterfaceConfig{
+				{Name: "net0", RAIntervalMilliseconds: 100},
+				{Name: other, RAIntervalMilliseconds: 100},
+			},
+		}
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1", "net2")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		newConfig(1),
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	eventully(t, func() bool {
+		_, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	// Hammer Status while reloading
+	stopCh := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		for {
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+
+			status := d.Status()
+			if status.Generation == 0 {
+				continue
+			}
+
+			names := []string{}
+			for _, iface := range status.Interfaces {
+				names = append(names, iface.Name)
+			}
+
+			expected := []string{}
+			for _, iface := range newConfig(status.Generation).Interfaces {
+				expected = append(expected, iface.Name)
+			}
+
+			if !slices.Equal(expected, names) {
+				errCh <- fmt.Errorf("generation %d: expected %v, got %v", status.Generation, expected, names)
+				return
+			}
+		}
+	}()
+
+	for generation := 2; generation <= 20; generation++ {
+		timeout, cancelTimeout := context.WithTimeout(ctx, time.Second)
+		require.NoError(t, d.Reload(timeout, newConfig(generation)))
+		cancelTimeout()
+	}
+
+	eventully(t, func() bool {
+		return d.Status().Generation == 20
+	})
+
+	close(stopCh)
+	require.NoError(t, <-errCh)
+}
+
+func TestDaemonInterfaceTemplate(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	template := &InterfaceConfig{
+		RAIntervalMilliseconds: 100,
+		CurrentHopLimit:        64,
+	}
+
+	selector := func(info InterfaceInfo) bool {
+		return info.Labels["role"] == "downstream"
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1", "net2")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee}})
+	devWatcher.update("net2", deviceState{isUp: true, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xef}})
+
+	d, err := NewDaemon(
+		config,
+		WithInterfaceTemplate(selector, template),
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	t.Run("Ensure the matching interface starts advertising with the template", func(t *testing.T) {
+		devWatcher.add(InterfaceInfo{Name: "net1", Labels: map[string]string{"role": "downstream"}})
+
+		var sock *fakeSock
+		eventully(t, func() bool {
+			sock, err = reg.getSock("net1")
+			return err == nil
+		})
+
+		ra := <-sock.txMulticastCh()
+		require.Equal(t, uint8(64), ra.msg.CurrentHopLimit)
+	})
+
+	t.Run("Ensure the non-matching interface doesn't start advertising", func(t *testing.T) {
+		devWatcher.add(InterfaceInfo{Name: "net2", Labels: map[string]string{"role": "upstream"}})
+
+		require.Never(t, func() bool {
+			_, err := reg.getSock("net2")
+			return err == nil
+		}, time.Millisecond*300, time.Millisecond*10)
+	})
+
+	t.Run("Ensure the explicitly configured interface is not affected", func(t *testing.T) {
+		devWatcher.add(InterfaceInfo{Name: "net0", Labels: map[string]string{"role": "downstream"}})
+
+		sock, err := reg.getSock("net0")
+		require.NoError(t, err)
+
+		ra := <-sock.txMulticastCh()
+		require.Equal(t, uint8(0), ra.msg.CurrentHopLimit)
+	})
+
+	t.Run("Ensure the advertisement stops after the interface disappears", func(t *testing.T) {
+		devWatcher.delete("net1")
+
+		sock, err := reg.getSock("net1")
+		require.NoError(t, err)
+
+		eventully(t, func() bool {
+			return sock.isClosed()
+		})
+
+		status := d.Status()
+		require.Len(t, status.Interfaces, 1)
+		require.Equal(t, "net0", status.Interfaces[0].Name)
+	})
+}
+
+func TestDaemonAllInterfaces(t *testing.T) {
+	config := &Config{
+		AllInterfaces: true,
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1", "net2")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee}})
+	devWatcher.update("net2", deviceState{isUp: false, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xef}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	devWatcher.add(InterfaceInfo{Name: "net0", Up: true})
+	devWatcher.add(InterfaceInfo{Name: "net1", Up: true})
+	devWatcher.add(InterfaceInfo{Name: "net2", Up: false})
+
+	t.Run("Ensure the up interfaces start advertising", func(t *testing.T) {
+		eventully(t, func() bool {
+			s := d.Status()
+			return len(s.Interfaces) == 2 &&
+				s.Interfaces[0].State == Running &&
+				s.Interfaces[1].State == Running
+		})
+
+		for _, name := range []string{"net0", "net1"} {
+			_, err := reg.getSock(name)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Ensure the down interface doesn't start advertising", func(t *testing.T) {
+		require.Never(t, func() bool {
+			_, err := reg.getSock("net2")
+			return err == nil
+		}, time.Millisecond*300, time.Millisecond*10)
+
+		status := d.Status()
+		require.Len(t, status.Interfaces, 2)
+	})
+
+	t.Run("Ensure the advertisement stops after the interface goes down", func(t *testing.T) {
+		devWatcher.add(InterfaceInfo{Name: "net1", Up: false})
+
+		sock, err := reg.getSock("net1")
+		require.NoError(t, err)
+
+		eventully(t, func() bool {
+			return sock.isClosed()
+		})
+
+		status := d.Status()
+		require.Len(t, status.Interfaces, 1)
+		require.Equal(t, "net0", status.Interfaces[0].Name)
+	})
+}
+
+func TestDaemonExcludeInterfaces(t *testing.T) {
+	config := &Config{
+		AllInterfaces:     true,
+		ExcludeInterfaces: []string{"mgmt*"},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1", "mgmt0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee}})
+	devWatcher.update("mgmt0", deviceState{isUp: true, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xef}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	devWatcher.add(InterfaceInfo{Name: "net0", Up: true})
+	devWatcher.add(InterfaceInfo{Name: "net1", Up: true})
+	devWatcher.add(InterfaceInfo{Name: "mgmt0", Up: true})
+
+	t.Run("Ensure only the non-excluded interfaces advertise", func(t *testing.T) {
+		eventully(t, func() bool {
+			s := d.Status()
+			return len(s.Interfaces) == 2 &&
+				s.Interfaces[0].State == Running &&
+				s.Interfaces[1].State == Running
+		})
+
+		require.Never(t, func() bool {
+			_, err := reg.getSock("mgmt0")
+			return err == nil
+		}, time.Millisecond*300, time.Millisecond*10)
+
+		names := []string{}
+		for _, iface := range d.Status().Interfaces {
+			names = append(names, iface.Name)
+		}
+		require.Equal(t, []string{"net0", "net1"}, names)
+	})
+
+	t.Run("Ensure the reloaded exclusion stops the advertisement", func(t *testing.T) {
+		sock, err := reg.getSock("net1")
+		require.NoError(t, err)
+
+		newConfig := config.deepCopy()
+		newConfig.ExcludeInterfaces = append(newConfig.ExcludeInterfaces, "net1")
+		require.NoError(t, d.Reload(ctx, newConfig))
+
+		eventully(t, func() bool {
+			return sock.isClosed()
+		})
+
+		status := d.Status()
+		require.Len(t, status.Interfaces, 1)
+		require.Equal(t, "net0", status.Interfaces[0].Name)
+	})
+}
+
+func TestDaemonInterfaceMACPrefixes(t *testing.T) {
+	config := &Config{
+		InterfaceMACPrefixes: []string{"00:11:22/24"},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1", "net2")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x00, 0x11, 0x22, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x00, 0x11, 0x23, 0x44, 0x55, 0x66}})
+	devWatcher.update("net2", deviceState{isUp: true, addr: net.HardwareAddr{0x00, 0x11, 0x22, 0x77, 0x88, 0x99}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	devWatcher.add(InterfaceInfo{Name: "net0", Up: true, HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x22, 0x44, 0x55, 0x66}})
+	devWatcher.add(InterfaceInfo{Name: "net1", Up: true, HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x23, 0x44, 0x55, 0x66}})
+	devWatcher.add(InterfaceInfo{Name: "net2", Up: true, HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x22, 0x77, 0x88, 0x99}})
+
+	t.Run("Ensure only the interfaces with the OUI advertise", func(t *testing.T) {
+		eventully(t, func() bool {
+			s := d.Status()
+			return len(s.Interfaces) == 2 &&
+				s.Interfaces[0].State == Running &&
+				s.Interfaces[1].State == Running
+		})
+
+		status := d.Status()
+		names := []string{status.Interfaces[0].Name, status.Interfaces[1].Name}
+		require.ElementsMatch(t, []string{"net0", "net2"}, names)
+
+		require.Never(t, func() bool {
+			_, err := reg.getSock("net1")
+			return err == nil
+		}, time.Millisecond*300, time.Millisecond*10)
+	})
+}
+
+func TestDaemonInvalidInterfaceTemplate(t *testing.T) {
+	_, err := NewDaemon(
+		&Config{},
+		WithInterfaceTemplate(
+			func(InterfaceInfo) bool { return true },
+			&InterfaceConfig{RAIntervalMilliseconds: 69},
+		),
+	)
+	var verrs ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+}
+
+func TestDaemonFlapGrace(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	// Count the socket creation
+	var sockCount atomic.Int32
+	ctor := func(iface string, opts socketOpts) (socket, error) {
+		sockCount.Add(1)
+		return reg.newSock(iface, opts)
+	}
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		WithFlapGrace(time.Millisecond*500),
+		withSocketConstructor(ctor),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure the socket is not recreated after flap within the grace period", func(t *testing.T) {
+		devWatcher.update("net0", deviceState{isUp: false, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+		eventully(t, func() bool {
+			return d.Status().Interfaces[0].State == Failing
+		})
+
+		devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+		eventully(t, func() bool {
+			return d.Status().Interfaces[0].State == Running
+		})
+
+		// Wait until the grace period is surely expired
+		time.Sleep(time.Millisecond * 600)
+
+		require.Equal(t, int32(1), sockCount.Load())
+		require.False(t, sock.isClosed())
+		require.EventuallyWithT(t, func(ct *assert.CollectT) {
+			assertRAInterval(ct, sock, time.Millisecond*100)
+		}, time.Second*1, time.Millisecond*100)
+	})
+
+	t.Run("Ensure the MAC address changed within the grace period is advertised", func(t *testing.T) {
+		newMAC := net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x77}
+
+		// The address changes while the device is down
+		devWatcher.update("net0", deviceState{isUp: false, addr: newMAC})
+
+		eventully(t, func() bool {
+			return d.Status().Interfaces[0].State == Failing
+		})
+
+		devWatcher.update("net0", deviceState{isUp: true, addr: newMAC})
+
+		eventully(t, func() bool {
+			ra := <-sock.txMulticastCh()
+			for _, option := range ra.msg.Options {
+				if opt, ok := option.(*ndp.LinkLayerAddress); ok && opt.Direction == ndp.Source {
+					return slices.Equal(newMAC, opt.Addr)
+				}
+			}
+			return false
+		})
+		require.Equal(t, int32(1), sockCount.Load())
+	})
+
+	t.Run("Ensure the socket is torn down after the grace period", func(t *testing.T) {
+		devWatcher.update("net0", deviceState{isUp: false, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+		// Wait until the grace period is surely expired
+		time.Sleep(time.Millisecond * 600)
+
+		devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+		eventully(t, func() bool {
+			return sockCount.Load() > 1
+		})
+	})
+}
+
+func TestDaemonForceOffLink(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				ForceOffLink:           true,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:     "2001:db8:0:1::/64",
+						OnLink:     true,
+						Autonomous: true,
+					},
+					{
+						Prefix:     "2001:db8:0:2::/64",
+						Autonomous: true,
+					},
+				},
+				PrefixRotation: &PrefixRotationConfig{
+					Prefixes:        []string{"2001:db8:1::/64", "2001:db8:2::/64"},
+					IntervalSeconds: 3600,
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	ra := <-sock.txMulticastCh()
+
+	prefixes := 0
+	for _, opt := range ra.msg.Options {
+		if pi, ok := opt.(*ndp.PrefixInformation); ok {
+			require.False(t, pi.OnLink, "prefix %s has L flag", pi.Prefix)
+			require.True(t, pi.AutonomousAddressConfiguration)
+			prefixes++
+		}
+	}
+	require.Equal(t, 3, prefixes)
+}
+
+func TestDaemonSolicitedPreference(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				RouterLifetimeSeconds:  10,
+				Preference:             "medium",
+				SolicitedPreference:    "high",
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure the multicast RA uses Preference", func(t *testing.T) {
+		ra := <-sock.txMulticastCh()
+		require.Equal(t, ndp.Medium, ra.msg.RouterSelectionPreference)
+	})
+
+	t.Run("Ensure the RS reply uses SolicitedPreference", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%net0")
+
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		timeout, cancelTimeout := context.WithTimeout(context.Background(), time.Second*1)
+		defer cancelTimeout()
+
+		select {
+		case ra := <-sock.txLLUnicastCh():
+			require.Equal(t, from, ra.to)
+			require.Equal(t, ndp.High, ra.msg.RouterSelectionPreference)
+		case <-timeout.Done():
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+}
+
+func TestDaemonSLLAInSolicitedOnly(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				SLLAInSolicitedOnly:    true,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	hasSLLA := func(msg *ndp.RouterAdvertisement) bool {
+		return slices.ContainsFunc(msg.Options, func(opt ndp.Option) bool {
+			lla, ok := opt.(*ndp.LinkLayerAddress)
+			return ok && lla.Direction == ndp.Source
+		})
+	}
+
+	t.Run("Ensure the multicast RA doesn't have SLLA", func(t *testing.T) {
+		ra := <-sock.txMulticastCh()
+		require.False(t, hasSLLA(ra.msg))
+	})
+
+	t.Run("Ensure the RS reply has SLLA", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%net0")
+
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		timeout, cancelTimeout := context.WithTimeout(context.Background(), time.Second*1)
+		defer cancelTimeout()
+
+		select {
+		case ra := <-sock.txLLUnicastCh():
+			require.Equal(t, from, ra.to)
+			require.True(t, hasSLLA(ra.msg))
+		case <-timeout.Done():
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+}
+
+func TestDaemonNAT64PrefixSolicitedOnly(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				NAT64Prefixes: []*NAT64PrefixConfig{
+					{
+						Prefix: "64:ff9b::/96",
+					},
+					{
+						Prefix:        "2001:db8:64::/96",
+						SolicitedOnly: true,
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	pref64s := func(msg *ndp.RouterAdvertisement) []string {
+		prefixes := []string{}
+		for _, opt := range msg.Options {
+			if pref64, ok := opt.(*ndp.PREF64); ok {
+				prefixes = append(prefixes, pref64.Prefix.String())
+			}
+		}
+		return prefixes
+	}
+
+	t.Run("Ensure the multicast RA doesn't have the solicited-only PREF64", func(t *testing.T) {
+		ra := <-sock.txMulticastCh()
+		require.Equal(t, []string{"64:ff9b::/96"}, pref64s(ra.msg))
+	})
+
+	t.Run("Ensure the RS reply has the solicited-only PREF64", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%net0")
+
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		timeout, cancelTimeout := context.WithTimeout(context.Background(), time.Second*1)
+		defer cancelTimeout()
+
+		select {
+		case ra := <-sock.txLLUnicastCh():
+			require.Equal(t, from, ra.to)
+			require.Equal(t, []string{"64:ff9b::/96", "2001:db8:64::/96"}, pref64s(ra.msg))
+		case <-timeout.Done():
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+}
+
+func TestDaemonSolicitedNDTimers(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                                "net0",
+				RAIntervalMilliseconds:              100,
+				ReachableTimeMilliseconds:           30000,
+				RetransmitTimeMilliseconds:          1000,
+				SolicitedReachableTimeMilliseconds:  ptr.To(5000),
+				SolicitedRetransmitTimeMilliseconds: ptr.To(0),
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure the multicast RA uses the base timers", func(t *testing.T) {
+		ra := <-sock.txMulticastCh()
+		require.Equal(t, time.Millisecond*30000, ra.msg.ReachableTime)
+		require.Equal(t, time.Millisecond*1000, ra.msg.RetransmitTimer)
+	})
+
+	t.Run("Ensure the RS reply uses the solicited timers", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%net0")
+
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		timeout, cancelTimeout := context.WithTimeout(context.Background(), time.Second*1)
+		defer cancelTimeout()
+
+		select {
+		case ra := <-sock.txLLUnicastCh():
+			require.Equal(t, from, ra.to)
+			require.Equal(t, time.Millisecond*5000, ra.msg.ReachableTime)
+			require.Equal(t, time.Duration(0), ra.msg.RetransmitTimer)
+		case <-timeout.Done():
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+}
+
+func TestDaemonSelfTest(t *testing.T) {
+	// Build RDNSS options with 15 addresses each. 15 is the maximum number
+	// of addresses ndp can marshal into a single option.
+	newRDNSSes := func(n int) []*RDNSSConfig {
+		rdnsses := []*RDNSSConfig{}
+		for i := 0; i < n; i++ {
+			addresses := []string{}
+			for j := 0; j < 15; j++ {
+				addresses = append(addresses, netip.AddrFrom16([16]byte{0x20, 0x01, 0x0d, 0xb8, 14: byte(i), 15: byte(j)}).String())
+			}
+			rdnsses = append(rdnsses, &RDNSSConfig{LifetimeSeconds: 100, Addresses: addresses})
+		}
+		return rdnsses
+	}
+
+	// 6 RDNSS options are valid field-wise, but the RA doesn't fit into
+	// 1500 bytes of MTU.
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				MTU:                    1500,
+				RDNSSes:                newRDNSSes(6),
+			},
+		},
+	}
+
+	t.Run("Ensure NewDaemon fails with over-MTU RA", func(t *testing.T) {
+		_, err := NewDaemon(config)
+		require.ErrorIs(t, err, ErrSelfTest)
+	})
+
+	t.Run("Ensure Reload fails with over-MTU RA", func(t *testing.T) {
+		d, err := NewDaemon(&Config{})
+		require.NoError(t, err)
+
+		err = d.Reload(context.Background(), config)
+		require.ErrorIs(t, err, ErrSelfTest)
+	})
+
+	t.Run("Ensure IPv6 minimum MTU is used when MTU is not set", func(t *testing.T) {
+		c := config.deepCopy()
+		c.Interfaces[0].MTU = 0
+
+		c.Interfaces[0].RDNSSes = newRDNSSes(5)
+		_, err := NewDaemon(c)
+		require.ErrorIs(t, err, ErrSelfTest)
+
+		c.Interfaces[0].RDNSSes = newRDNSSes(4)
+		_, err = NewDaemon(c)
+		require.NoError(t, err)
+	})
+
+	t.Run("Ensure NewDaemon fails with un-marshalable RA", func(t *testing.T) {
+		c := config.deepCopy()
+		rdnss := newRDNSSes(2)
+		rdnss[0].Addresses = append(rdnss[0].Addresses, rdnss[1].Addresses[0])
+		c.Interfaces[0].RDNSSes = rdnss[:1]
+
+		_, err := NewDaemon(c)
+		require.ErrorIs(t, err, ErrSelfTest)
+	})
+
+	t.Run("Ensure SplitDNSOptions makes the over-MTU and un-marshalable RA valid", func(t *testing.T) {
+		c := config.deepCopy()
+		c.Interfaces[0].SplitDNSOptions = true
+		_, err := NewDaemon(c)
+		require.NoError(t, err)
+
+		rdnss := newRDNSSes(2)
+		rdnss[0].Addresses = append(rdnss[0].Addresses, rdnss[1].Addresses[0])
+		c.Interfaces[0].RDNSSes = rdnss[:1]
+		_, err = NewDaemon(c)
+		require.NoError(t, err)
+	})
+}
+
+func TestDaemonSplitDNSOptions(t *testing.T) {
+	// 200 addresses and 100 domain names never fit into a single RA
+	rdnsses := []*RDNSSConfig{}
+	for i := 0; i < 2; i++ {
+		addresses := []string{}
+		for j := 0; j < 100; j++ {
+			addresses = append(addresses, netip.AddrFrom16([16]byte{0x20, 0x01, 0x0d, 0xb8, 14: byte(i), 15: byte(j)}).String())
+		}
+		rdnsses = append(rdnsses, &RDNSSConfig{LifetimeSeconds: 100, Addresses: addresses})
+	}
+	domains := []string{}
+	for i := 0; i < 100; i++ {
+		domains = append(domains, fmt.Sprintf("domain%d.example.com", i))
+	}
+
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				SplitDNSOptions:        true,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix: "2001:db8::/64",
+					},
+				},
+				RDNSSes: rdnsses,
+				DNSSLs: []*DNSSLConfig{
+					{
+						LifetimeSeconds: 100,
+						DomainNames:     domains,
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	// Returns the addresses and domain names in the RAs and asserts
+	// each RA fits into the IPv6 minimum MTU
+	collect := func(t *testing.T, msgs []*ndp.RouterAdvertisement) ([]string, []string) {
+		addresses, domainNames := []string{}, []string{}
+		for _, msg := range msgs {
+			b, err := ndp.MarshalMessage(msg)
+			require.NoError(t, err)
+			require.LessOrEqual(t, ipv6.HeaderLen+len(b), ipv6MinMTU)
+			for _, opt := range msg.Options {
+				switch o := opt.(type) {
+				case *ndp.RecursiveDNSServer:
+					for _, addr := range o.Servers {
+						addresses = append(addresses, addr.String())
+					}
+				case *ndp.DNSSearchList:
+					domainNames = append(domainNames, o.DomainNames...)
+				}
+			}
+		}
+		return addresses, domainNames
+	}
+
+	t.Run("Ensure the unsolicited RA fits into the MTU", func(t *testing.T) {
+		ra := <-sock.txMulticastCh()
+		addresses, _ := collect(t, []*ndp.RouterAdvertisement{ra.msg})
+		require.NotEmpty(t, addresses)
+		require.Less(t, len(addresses), 200)
+
+		// Other options are kept
+		require.IsType(t, &ndp.LinkLayerAddress{}, ra.msg.Options[0])
+		require.IsType(t, &ndp.PrefixInformation{}, ra.msg.Options[1])
+	})
+
+	t.Run("Ensure the RS reply carries all DNS options in multiple RAs", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%net0")
+
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		// Collect the RAs until no more comes
+		msgs := []*ndp.RouterAdvertisement{}
+	outer:
+		for {
+			select {
+			case ra := <-sock.txLLUnicastCh():
+				require.Equal(t, from, ra.to)
+				msgs = append(msgs, ra.msg)
+			case <-time.After(time.Millisecond * 500):
+				break outer
+			}
+		}
+		require.Greater(t, len(msgs), 1)
+
+		rdnssCount := 0
+		for _, msg := range msgs {
+			for _, opt := range msg.Options {
+				if _, ok := opt.(*ndp.RecursiveDNSServer); ok {
+					rdnssCount++
+				}
+			}
+		}
+		require.Greater(t, rdnssCount, 2)
+
+		addresses, domainNames := collect(t, msgs)
+		expected := slices.Concat(rdnsses[0].Addresses, rdnsses[1].Addresses)
+		require.Equal(t, expected, addresses)
+		require.Equal(t, domains, domainNames)
+	})
+}
+
+func TestDaemonRSHandler(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 1000,
+			},
+		},
+	}
+
+	var (
+		allow    atomic.Bool
+		received = make(chan netip.Addr, 1)
+	)
+
+	handler := func(iface string, rs *ndp.RouterSolicitation, from netip.Addr) bool {
+		if iface == "net0" && rs != nil {
+			received <- from
+		}
+		return allow.Load()
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		WithRSHandler(handler),
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	from := netip.MustParseAddr("fe80::1%net0")
+
+	t.Run("Ensure the handler suppresses the reply", func(t *testing.T) {
+		allow.Store(false)
+
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+		require.Equal(t, from, <-received)
+
+		select {
+		case <-sock.txLLUnicastCh():
+			require.Fail(t, "RA is sent even though the handler suppressed it")
+		case <-time.After(time.Millisecond * 300):
+		}
+	})
+
+	t.Run("Ensure the handler allows the default reply", func(t *testing.T) {
+		allow.Store(true)
+
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+		require.Equal(t, from, <-received)
+
+		select {
+		case ra := <-sock.txLLUnicastCh():
+			require.Equal(t, from, ra.to)
+		case <-time.After(time.Second * 1):
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+}
+
+func TestDaemonSolicitedRARepeat(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 1000,
+				SolicitedRARepeat:      2,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure two solicited RAs follow a single RS", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%net0")
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		ras := []fakeRA{}
+		for i := 0; i < 2; i++ {
+			select {
+			case ra := <-sock.txLLUnicastCh():
+				require.Equal(t, from, ra.to)
+				ras = append(ras, ra)
+			case <-time.After(time.Millisecond * 500):
+				require.Fail(t, "timeout waiting for RA")
+			}
+		}
+		require.GreaterOrEqual(t, ras[1].tstamp.Sub(ras[0].tstamp), solicitedRARepeatInterval)
+
+		// No more repeat
+		select {
+		case <-sock.txLLUnicastCh():
+			require.Fail(t, "solicited RA is repeated more than configured")
+		case <-time.After(time.Millisecond * 300):
+		}
+
+		require.Equal(t, 2, d.Status().Interfaces[0].TxSolicitedRA)
+	})
+}
+
+func TestDaemonPoisonInterface(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				RouterLifetimeSeconds:  1800,
+				Preference:             "high",
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:                   "2001:db8::/64",
+						ValidLifetimeSeconds:     ptr.To(200),
+						PreferredLifetimeSeconds: ptr.To(100),
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	isPoisoned := func(ra fakeRA) bool {
+		if ra.msg.RouterLifetime != 0 || ra.msg.RouterSelectionPreference != ndp.Medium {
+			return false
+		}
+		for _, option := range ra.msg.Options {
+			if opt, ok := option.(*ndp.PrefixInformation); ok {
+				if opt.ValidLifetime != 0 || opt.PreferredLifetime != 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	t.Run("Ensure unknown interface is rejected", func(t *testing.T) {
+		require.Error(t, d.PoisonInterface("net1", time.Second))
+	})
+
+	t.Run("Ensure RAs carry zero lifetimes during the poisoning period", func(t *testing.T) {
+		require.False(t, isPoisoned(<-sock.txMulticastCh()))
+
+		require.NoError(t, d.PoisonInterface("net0", time.Millisecond*500))
+
+		eventully(t, func() bool {
+			return isPoisoned(<-sock.txMulticastCh())
+		})
+
+		// Sample a few more RAs within the poisoning period
+		for i := 0; i < 2; i++ {
+			require.True(t, isPoisoned(<-sock.txMulticastCh()))
+		}
+	})
+
+	t.Run("Ensure RAs carry normal lifetimes after the poisoning period", func(t *testing.T) {
+		eventully(t, func() bool {
+			return !isPoisoned(<-sock.txMulticastCh())
+		})
+
+		ra := <-sock.txMulticastCh()
+		require.Equal(t, time.Second*1800, ra.msg.RouterLifetime)
+		require.Equal(t, ndp.High, ra.msg.RouterSelectionPreference)
+	})
+}
+
+func TestDaemonPauseAll(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                          "net0",
+				RAIntervalMilliseconds:        500,
+				RouterLifetimeSeconds:         1800,
+				InitialRACount:                3,
+				InitialRAIntervalMilliseconds: 100,
+			},
+			{
+				Name:                          "net1",
+				RAIntervalMilliseconds:        500,
+				RouterLifetimeSeconds:         1800,
+				InitialRACount:                3,
+				InitialRAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x67}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	socks := []*fakeSock{}
+	for _, name := range []string{"net0", "net1"} {
+		var sock *fakeSock
+		eventully(t, func() bool {
+			sock, err = reg.getSock(name)
+			return err == nil
+		})
+		socks = append(socks, sock)
+	}
+
+	// Wait for the first unsolicited RA and the initial burst
+	for _, sock := range socks {
+		for i := 0; i <= config.Interfaces[0].InitialRACount; i++ {
+			ra := <-sock.txMulticastCh()
+			require.Equal(t, time.Second*1800, ra.msg.RouterLifetime)
+		}
+	}
+
+	t.Run("Ensure only the withdrawal RA is sent while paused", func(t *testing.T) {
+		require.NoError(t, d.PauseAll(ctx))
+		require.True(t, d.Status().Paused)
+
+		for _, sock := range socks {
+			select {
+			case ra := <-sock.txMulticastCh():
+				require.Equal(t, time.Duration(0), ra.msg.RouterLifetime)
+			case <-time.After(time.Millisecond * 300):
+				require.Fail(t, "timeout waiting for the withdrawal RA")
+			}
+		}
+
+		// Send RS as well. It shouldn't be replied.
+		from := netip.MustParseAddr("fe80::1%net0")
+		socks[0].rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		// Wait for the multiple RA intervals
+		select {
+		case <-socks[0].txMulticastCh():
+			require.Fail(t, "unsolicited RA is sent while paused")
+		case <-socks[1].txMulticastCh():
+			require.Fail(t, "unsolicited RA is sent while paused")
+		case <-socks[0].txLLUnicastCh():
+			require.Fail(t, "solicited RA is sent while paused")
+		case <-time.After(time.Millisecond * 1200):
+		}
+	})
+
+	t.Run("Ensure RAs restart immediately after resume", func(t *testing.T) {
+		require.NoError(t, d.ResumeAll(ctx))
+		require.False(t, d.Status().Paused)
+
+		// Shorter than the RA interval, so the RA must be sent on
+		// resume rather than the next tick.
+		for _, sock := range socks {
+			select {
+			case ra := <-sock.txMulticastCh():
+				require.Equal(t, time.Second*1800, ra.msg.RouterLifetime)
+			case <-time.After(time.Millisecond * 300):
+				require.Fail(t, "timeout waiting for RA after resume")
+			}
+		}
+
+		// The initial burst restarts
+		for _, sock := range socks {
+			for i := 0; i < config.Interfaces[0].InitialRACount; i++ {
+				select {
+				case <-sock.txMulticastCh():
+				case <-time.After(time.Millisecond * 250):
+					require.Fail(t, "timeout waiting for the initial burst")
+				}
+			}
+		}
+
+		// And falls back to the regular interval
+		require.Never(t, func() bool {
+			return len(socks[0].txMulticastCh()) > 0 || len(socks[1].txMulticastCh()) > 0
+		}, time.Millisecond*250, time.Millisecond*10, "RA is sent at the burst interval after the burst")
+		for _, sock := range socks {
+			select {
+			case <-sock.txMulticastCh():
+			case <-time.After(time.Millisecond * 700):
+				require.Fail(t, "timeout waiting for the next RA")
+			}
+		}
+	})
+}
+
+func TestDaemonStartHeld(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name: "net0",
+				// Set this to super long to see only the initial
+				// burst.
+				RAIntervalMilliseconds:        1800000,
+				InitialRACount:                3,
+				InitialRAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		WithStartHeld(),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure nothing is sent until Release", func(t *testing.T) {
+		require.True(t, d.Status().Held)
+
+		// RS shouldn't be replied either
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.MustParseAddr("fe80::1%net0")}
+
+		// Wait for the multiple initial RA intervals
+		select {
+		case <-sock.txMulticastCh():
+			require.Fail(t, "unsolicited RA is sent while held")
+		case <-sock.txLLUnicastCh():
+			require.Fail(t, "solicited RA is sent while held")
+		case <-time.After(time.Millisecond * 500):
+		}
+	})
+
+	t.Run("Ensure the initial burst starts on Release", func(t *testing.T) {
+		require.NoError(t, d.Release(ctx))
+		require.False(t, d.Status().Held)
+
+		// The RA on release and the rest of the burst
+		for i := 0; i < config.Interfaces[0].InitialRACount; i++ {
+			select {
+			case <-sock.txMulticastCh():
+			case <-time.After(time.Millisecond * 300):
+				require.Fail(t, "timeout waiting for the initial burst")
+			}
+		}
+
+		// Release again does nothing
+		require.NoError(t, d.Release(ctx))
+	})
+}
+
+func TestDaemonTimeToNextRA(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name: "net0",
+				// Set this to super long to avoid sending
+				// unsolicited RAs.
+				RAIntervalMilliseconds: 1800000,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	clk := newFakeClock()
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		withClock(clk),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	eventully(t, func() bool {
+		_, err = d.TimeToNextRA("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure unknown interface is rejected", func(t *testing.T) {
+		_, err := d.TimeToNextRA("net1")
+		require.Error(t, err)
+	})
+
+	t.Run("Ensure time to next RA follows the clock", func(t *testing.T) {
+		ttn, err := d.TimeToNextRA("net0")
+		require.NoError(t, err)
+		require.Equal(t, time.Second*1800, ttn)
+
+		clk.advance(time.Second * 1000)
+		ttn, err = d.TimeToNextRA("net0")
+		require.NoError(t, err)
+		require.Equal(t, time.Second*800, ttn)
+
+		// Wraps around to the next interval
+		clk.advance(time.Second * 900)
+		ttn, err = d.TimeToNextRA("net0")
+		require.NoError(t, err)
+		require.Equal(t, time.Second*1700, ttn)
+	})
+
+	t.Run("Ensure no RA is scheduled while paused", func(t *testing.T) {
+		require.NoError(t, d.PauseAll(ctx))
+		_, err := d.TimeToNextRA("net0")
+		require.Error(t, err)
+
+		require.NoError(t, d.ResumeAll(ctx))
+		_, err = d.TimeToNextRA("net0")
+		require.NoError(t, err)
+	})
+}
+
+func TestDaemonDNSHealthCheck(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name: "net0",
+				// Longer than the test except the first RA.
+				// The change must be reflected without
+				// waiting for the next RA.
+				RAIntervalMilliseconds:        1800000,
+				InitialRACount:                1,
+				InitialRAIntervalMilliseconds: 100,
+				RDNSSes: []*RDNSSConfig{
+					{
+						LifetimeSeconds: 300,
+						Addresses:       []string{"2001:db8::1"},
+					},
+				},
+				DNSSLs: []*DNSSLConfig{
+					{
+						LifetimeSeconds: 400,
+						DomainNames:     []string{"example.com"},
+					},
+				},
+			},
+		},
+	}
+
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	clk := newFakeClock()
+
+	d, err := NewDaemon(
+		config,
+		WithDNSHealthCheck(func(iface string) bool { return healthy.Load() }),
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		withClock(clk),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	dnsLifetimes := func(ra fakeRA) (time.Duration, time.Duration) {
+		var rdnss, dnssl time.Duration = -1, -1
+		for _, option := range ra.msg.Options {
+			switch opt := option.(type) {
+			case *ndp.RecursiveDNSServer:
+				rdnss = opt.Lifetime
+			case *ndp.DNSSearchList:
+				dnssl = opt.Lifetime
+			}
+		}
+		return rdnss, dnssl
+	}
+
+	t.Run("Ensure RDNSS and DNSSL are advertised while healthy", func(t *testing.T) {
+		rdnss, dnssl := dnsLifetimes(<-sock.txMulticastCh())
+		require.Equal(t, time.Second*300, rdnss)
+		require.Equal(t, time.Second*400, dnssl)
+	})
+
+	t.Run("Ensure RDNSS and DNSSL are withdrawn after becoming unhealthy", func(t *testing.T) {
+		healthy.Store(false)
+		clk.advance(dnsHealthCheckInterval)
+
+		eventully(t, func() bool {
+			rdnss, dnssl := dnsLifetimes(<-sock.txMulticastCh())
+			return rdnss == 0 && dnssl == 0
+		})
+	})
+
+	t.Run("Ensure the solicited RA withdraws RDNSS and DNSSL", func(t *testing.T) {
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.MustParseAddr("fe80::1")}
+
+		rdnss, dnssl := dnsLifetimes(<-sock.txLLUnicastCh())
+		require.Zero(t, rdnss)
+		require.Zero(t, dnssl)
+	})
+
+	t.Run("Ensure RDNSS and DNSSL are advertised again after recovery", func(t *testing.T) {
+		healthy.Store(true)
+		clk.advance(dnsHealthCheckInterval)
+
+		eventully(t, func() bool {
+			rdnss, dnssl := dnsLifetimes(<-sock.txMulticastCh())
+			return rdnss == time.Second*300 && dnssl == time.Second*400
+		})
+	})
+}
+
+// A thread-safe buffer to capture the logs
+type logBuffer struct {
+	buf  bytes.Buffer
+	lock sync.Mutex
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+// records returns the captured JSON log records
+func (b *logBuffer) records(t *testing.T) []map[string]any {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	records := []map[string]any{}
+	dec := json.NewDecoder(bytes.NewReader(b.buf.Bytes()))
+	for dec.More() {
+		var r map[string]any
+		require.NoError(t, dec.Decode(&r))
+		records = append(records, r)
+	}
+
+	return records
+}
+
+func TestDaemonInterfaceLogLevel(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				LogLevel:               "debug",
+			},
+			{
+				Name:                   "net1",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	logs := &logBuffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee}})
+
+	d, err := NewDaemon(
+		config,
+		WithLogger(logger),
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	debugRecords := func() map[string]int {
+		counts := map[string]int{}
+		for _, r := range logs.records(t) {
+			if r["level"] == slog.LevelDebug.String() {
+				iface, _ := r["interface"].(string)
+				counts[iface]++
+			}
+		}
+		return counts
+	}
+
+	t.Run("Ensure debug records only appear for the interface with debug level", func(t *testing.T) {
+		eventully(t, func() bool {
+			return debugRecords()["net0"] >= 3
+		})
+		require.NotContains(t, debugRecords(), "net1")
+		require.NotContains(t, debugRecords(), "")
+	})
+
+	t.Run("Ensure the log level is updated after reload", func(t *testing.T) {
+		config.Interfaces[0].LogLevel = ""
+		config.Interfaces[1].LogLevel = "debug"
+
+		timeout, cancelTimeout := context.WithTimeout(context.Background(), time.Second*1)
+		err := d.Reload(timeout, config)
+		cancelTimeout()
+		require.NoError(t, err)
+
+		eventully(t, func() bool {
+			return debugRecords()["net1"] >= 3
+		})
+
+		// No more debug records from net0
+		before := debugRecords()["net0"]
+		time.Sleep(time.Millisecond * 300)
+		require.Equal(t, before, debugRecords()["net0"])
+	})
+}
+
+func TestDaemonUnspecifiedSourceRS(t *testing.T) {
+	newConfig := func() *InterfaceConfig {
+		return &InterfaceConfig{
+			Name: "net0",
+			// Long enough not to send unsolicited RA during the test
+			RAIntervalMilliseconds: 1800000,
+			Preference:             "medium",
+			SolicitedPreference:    "high",
+			RouterLifetimeSeconds:  1800,
+			Prefixes: []*PrefixConfig{
+				{
+					Prefix:     "2001:db8::/64",
+					OnLink:     true,
+					Autonomous: true,
+				},
+			},
+			Routes: []*RouteConfig{
+				{
+					Prefix:          "2001:db8:1::/48",
+					LifetimeSeconds: 100,
+				},
+			},
+			RDNSSes: []*RDNSSConfig{
+				{
+					LifetimeSeconds: 100,
+					Addresses:       []string{"2001:db8::1"},
+				},
+			},
+			DNSSLs: []*DNSSLConfig{
+				{
+					LifetimeSeconds: 100,
+					DomainNames:     []string{"example.com"},
+				},
+			},
+		}
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		&Config{Interfaces: []*InterfaceConfig{newConfig()}},
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure RS from unspecified address is replied with the full multicast RA", func(t *testing.T) {
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.IPv6Unspecified()}
+
+		var ra fakeRA
+		select {
+		case ra = <-sock.txMulticastCh():
+		case <-time.After(time.Second * 1):
+			require.Fail(t, "timeout waiting for RA")
+		}
+		require.Equal(t, netip.IPv6LinkLocalAllNodes(), ra.to)
+
+		// The content must be identical to the unsolicited RA
+		expected, err := ndp.MarshalMessage(newTestRAMsg(t, newConfig()))
+		require.NoError(t, err)
+		actual, err := ndp.MarshalMessage(ra.msg)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+
+		eventully(t, func() bool {
+			return d.Status().Interfaces[0].TxSolicitedRA == 1
+		})
+	})
+}
+
+func TestDaemonUnspecifiedTimers(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                       "net0",
+				RAIntervalMilliseconds:     100,
+				ReachableTimeMilliseconds:  0,
+				RetransmitTimeMilliseconds: 0,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure zero Reachable Time and Retransmit Timer are transmitted as zero", func(t *testing.T) {
+		ra := <-sock.txMulticastCh()
+		require.Equal(t, time.Duration(0), ra.msg.ReachableTime)
+		require.Equal(t, time.Duration(0), ra.msg.RetransmitTimer)
+
+		// ICMPv6 header (4 bytes) + Cur Hop Limit, Flags, Router
+		// Lifetime (4 bytes) + Reachable Time (4 bytes) + Retrans
+		// Timer (4 bytes)
+		b, err := ndp.MarshalMessage(ra.msg)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0, 0, 0, 0}, b[8:12], "Reachable Time must be zero")
+		require.Equal(t, []byte{0, 0, 0, 0}, b[12:16], "Retrans Timer must be zero")
+	})
+}
+
+func TestDaemonReloadFromReader(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	reload := func(t *testing.T, f func(context.Context, io.Reader) error, conf string) error {
+		timeout, cancelTimeout := context.WithTimeout(context.Background(), time.Second*1)
+		defer cancelTimeout()
+		return f(timeout, bytes.NewBufferString(conf))
+	}
+
+	t.Run("Ensure ReloadYAML applies the new interval", func(t *testing.T) {
+		err := reload(t, d.ReloadYAML, `
+interfaces:
+  - name: net0
+    raIntervalMilliseconds: 200
+`)
+		require.NoError(t, err)
+
+		require.EventuallyWithT(t, func(ct *assert.CollectT) {
+			assertRAInterval(ct, sock, time.Millisecond*200)
+		}, time.Second*1, time.Millisecond*100)
+	})
+
+	t.Run("Ensure ReloadJSON applies the new interval", func(t *testing.T) {
+		err := reload(t, d.ReloadJSON, `{"interfaces": [{"name": "net0", "raIntervalMilliseconds": 100}]}`)
+		require.NoError(t, err)
+
+		require.EventuallyWithT(t, func(ct *assert.CollectT) {
+			assertRAInterval(ct, sock, time.Millisecond*100)
+		}, time.Second*1, time.Millisecond*100)
+	})
+
+	t.Run("Ensure parse and validation errors don't touch the running config", func(t *testing.T) {
+		generation := d.Status().Generation
+
+		err := reload(t, d.ReloadYAML, `interfaces: [`)
+		require.Error(t, err)
+
+		err = reload(t, d.ReloadJSON, `{"interfaces": [{"name": "net0", "raIntervalMilliseconds": 69}]}`)
+		var verrs ValidationErrors
+		require.ErrorAs(t, err, &verrs)
+
+		require.Equal(t, generation, d.Status().Generation)
+		require.EventuallyWithT(t, func(ct *assert.CollectT) {
+			assertRAInterval(ct, sock, time.Millisecond*100)
+		}, time.Second*1, time.Millisecond*100)
+	})
+}
+
+func TestDaemonPrefixRotation(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				PrefixRotation: &PrefixRotationConfig{
+					Prefixes:        []string{"2001:db8:1::/64", "2001:db8:2::/64"},
+					IntervalSeconds: 60,
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	clk := newFakeClock()
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		withClock(clk),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	prefixOptions := func(ra fakeRA) map[netip.Addr]*ndp.PrefixInformation {
+		opts := map[netip.Addr]*ndp.PrefixInformation{}
+		for _, option := range ra.msg.Options {
+			if opt, ok := option.(*ndp.PrefixInformation); ok {
+				opts[opt.Prefix] = opt
+			}
+		}
+		return opts
+	}
+
+	prefix1 := netip.MustParseAddr("2001:db8:1::")
+	prefix2 := netip.MustParseAddr("2001:db8:2::")
+
+	t.Run("Ensure the first prefix is advertised", func(t *testing.T) {
+		opts := prefixOptions(<-sock.txMulticastCh())
+		require.Len(t, opts, 1)
+		require.Contains(t, opts, prefix1)
+		require.True(t, opts[prefix1].AutonomousAddressConfiguration)
+		require.NotZero(t, opts[prefix1].ValidLifetime)
+		require.NotZero(t, opts[prefix1].PreferredLifetime)
+	})
+
+	t.Run("Ensure the prefix rotates after the interval", func(t *testing.T) {
+		clk.advance(61 * time.Second)
+
+		var opts map[netip.Addr]*ndp.PrefixInformation
+		eventully(t, func() bool {
+			opts = prefixOptions(<-sock.txMulticastCh())
+			return len(opts) == 2
+		})
+
+		require.Contains(t, opts, prefix2)
+		require.True(t, opts[prefix2].AutonomousAddressConfiguration)
+		require.NotZero(t, opts[prefix2].ValidLifetime)
+		require.NotZero(t, opts[prefix2].PreferredLifetime)
+
+		// The old prefix is withdrawn
+		require.Contains(t, opts, prefix1)
+		require.Zero(t, opts[prefix1].ValidLifetime)
+		require.Zero(t, opts[prefix1].PreferredLifetime)
+	})
+
+	t.Run("Ensure the rotation wraps around", func(t *testing.T) {
+		clk.advance(60 * time.Second)
+
+		eventully(t, func() bool {
+			opts := prefixOptions(<-sock.txMulticastCh())
+			return len(opts) == 2 &&
+				opts[prefix1].ValidLifetime != 0 &&
+				opts[prefix2].ValidLifetime == 0
+		})
+	})
+}
+
+func TestDaemonPrefixRotationSkippedSlots(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				PrefixRotation: &PrefixRotationConfig{
+					Prefixes:        []string{"2001:db8:1::/64", "2001:db8:2::/64", "2001:db8:3::/64"},
+					IntervalSeconds: 60,
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	clk := newFakeClock()
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		withClock(clk),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	prefix1 := netip.MustParseAddr("2001:db8:1::")
+	prefix2 := netip.MustParseAddr("2001:db8:2::")
+	prefix3 := netip.MustParseAddr("2001:db8:3::")
+
+	validLifetimes := func(ra fakeRA) map[netip.Addr]time.Duration {
+		lifetimes := map[netip.Addr]time.Duration{}
+		for _, option := range ra.msg.Options {
+			if opt, ok := option.(*ndp.PrefixInformation); ok {
+				lifetimes[opt.Prefix] = opt.ValidLifetime
+			}
+		}
+		return lifetimes
+	}
+
+	require.Contains(t, validLifetimes(<-sock.txMulticastCh()), prefix1)
+
+	t.Run("Ensure the last advertised prefix is withdrawn when the slots are skipped", func(t *testing.T) {
+		// Skip the slot of the second prefix
+		clk.advance(121 * time.Second)
+
+		var lifetimes map[netip.Addr]time.Duration
+		eventully(t, func() bool {
+			lifetimes = validLifetimes(<-sock.txMulticastCh())
+			return len(lifetimes) == 2
+		})
+
+		require.NotZero(t, lifetimes[prefix3])
+		require.Contains(t, lifetimes, prefix1)
+		require.Zero(t, lifetimes[prefix1])
+		require.NotContains(t, lifetimes, prefix2)
+	})
+}
+
+func TestDaemonSubscribe(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	statusCh, unsubscribe := d.Subscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	// Waits for the snapshot satisfying the condition
+	waitStatus := func(t *testing.T, cond func(Status) bool) {
+		timeout := time.After(3 * time.Second)
+		for {
+			select {
+			case status, ok := <-statusCh:
+				require.True(t, ok, "channel closed unexpectedly")
+				if cond(status) {
+					return
+				}
+			case <-timeout:
+				require.Fail(t, "timeout waiting for the status")
+			}
+		}
+	}
+
+	t.Run("Ensure the snapshot is published on start", func(t *testing.T) {
+		waitStatus(t, func(s Status) bool {
+			return len(s.Interfaces) == 1 && s.Interfaces[0].State == Running
+		})
+	})
+
+	t.Run("Ensure the snapshot is published on reload", func(t *testing.T) {
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].CurrentHopLimit = 10
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		require.NoError(t, d.Reload(ctx, newConfig))
+
+		waitStatus(t, func(s Status) bool {
+			return s.Generation == 2
+		})
+	})
+
+	t.Run("Ensure the channel is closed on unsubscribe", func(t *testing.T) {
+		unsubscribe()
+		unsubscribe()
+		eventully(t, func() bool {
+			_, ok := <-statusCh
+			return !ok
+		})
+	})
+}
+
+func TestDaemonPatch(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				CurrentHopLimit:        64,
+				MTU:                    1500,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:     "2001:db8::/64",
+						Autonomous: true,
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	mtuOf := func(msg *ndp.RouterAdvertisement) int {
+		for _, option := range msg.Options {
+			if opt, ok := option.(*ndp.MTU); ok {
+				return int(opt.MTU)
+			}
+		}
+		return 0
+	}
+
+	t.Run("Ensure only the MTU is patched", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		require.NoError(t, d.Patch(ctx, &ConfigPatch{
+			Interfaces: []*InterfaceConfigPatch{
+				{
+					Name:   "net0",
+					Values: &InterfaceConfig{MTU: 1400},
+					Set:    []string{"mtu"},
+				},
+			},
+		}))
+
+		var ra fakeRA
+		eventully(t, func() bool {
+			ra = <-sock.txMulticastCh()
+			return mtuOf(ra.msg) == 1400
+		})
+
+		require.Equal(t, uint8(64), ra.msg.CurrentHopLimit)
+		var prefixes []netip.Addr
+		for _, option := range ra.msg.Options {
+			if opt, ok := option.(*ndp.PrefixInformation); ok {
+				prefixes = append(prefixes, opt.Prefix)
+			}
+		}
+		require.Equal(t, []netip.Addr{netip.MustParseAddr("2001:db8::")}, prefixes)
+	})
+
+	t.Run("Ensure the patch is based on the latest configuration", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		require.NoError(t, d.Patch(ctx, &ConfigPatch{
+			Interfaces: []*InterfaceConfigPatch{
+				{
+					Name:   "net0",
+					Values: &InterfaceConfig{CurrentHopLimit: 32},
+					Set:    []string{"currentHopLimit"},
+				},
+			},
+		}))
+
+		eventully(t, func() bool {
+			ra := <-sock.txMulticastCh()
+			return ra.msg.CurrentHopLimit == 32 && mtuOf(ra.msg) == 1400
+		})
+	})
+
+	t.Run("Ensure the invalid result is rejected", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		err := d.Patch(ctx, &ConfigPatch{
+			Interfaces: []*InterfaceConfigPatch{
+				{
+					Name:   "net0",
+					Values: &InterfaceConfig{MTU: 1000},
+					Set:    []string{"mtu"},
+				},
+			},
+		})
+		var verrs ValidationErrors
+		require.ErrorAs(t, err, &verrs)
+	})
+}
+
+func TestDaemonIncludePrefixesInSolicited(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                       "net0",
+				RAIntervalMilliseconds:     100,
+				RouterLifetimeSeconds:      1800,
+				IncludePrefixesInSolicited: ptr.To(false),
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:     "2001:db8::/64",
+						OnLink:     true,
+						Autonomous: true,
+					},
+				},
+				RDNSSes: []*RDNSSConfig{
+					{
+						LifetimeSeconds: 1800,
+						Addresses:       []string{"2001:db8::53"},
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	countOptions := func(ra fakeRA) (prefixes, rdnsses int) {
+		for _, option := range ra.msg.Options {
+			switch option.(type) {
+			case *ndp.PrefixInformation:
+				prefixes++
+			case *ndp.RecursiveDNSServer:
+				rdnsses++
+			}
+		}
+		return
+	}
+
+	t.Run("Ensure multicast RA includes prefixes", func(t *testing.T) {
+		prefixes, rdnsses := countOptions(<-sock.txMulticastCh())
+		require.Equal(t, 1, prefixes)
+		require.Equal(t, 1, rdnsses)
+	})
+
+	t.Run("Ensure RS reply omits prefixes", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%net0")
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		select {
+		case ra := <-sock.txLLUnicastCh():
+			prefixes, rdnsses := countOptions(ra)
+			require.Zero(t, prefixes)
+			require.Equal(t, 1, rdnsses)
+			require.Equal(t, 1800*time.Second, ra.msg.RouterLifetime)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+}
+
+func TestDaemonSolicitorOverrides(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:     "2001:db8::/64",
+						OnLink:     true,
+						Autonomous: true,
+					},
+				},
+				SolicitorOverrides: map[string]*SolicitorOverrideConfig{
+					"fe80::1": {
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix:     "2001:db8:1::/64",
+								OnLink:     true,
+								Autonomous: true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	solicit := func(t *testing.T, from string) []netip.Addr {
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.MustParseAddr(from)}
+
+		// Skip the unsolicited RAs sent in the meantime
+		for {
+			select {
+			case ra := <-sock.txLLUnicastCh():
+				prefixes := []netip.Addr{}
+				for _, option := range ra.msg.Options {
+					if pi, ok := option.(*ndp.PrefixInformation); ok {
+						prefixes = append(prefixes, pi.Prefix)
+					}
+				}
+				return prefixes
+			case <-sock.txMulticastCh():
+			case <-time.After(time.Second):
+				require.Fail(t, "timeout waiting for RA")
+				return nil
+			}
+		}
+	}
+
+	t.Run("Ensure matching solicitor gets the overridden prefixes", func(t *testing.T) {
+		require.Equal(t, []netip.Addr{netip.MustParseAddr("2001:db8:1::")}, solicit(t, "fe80::1%net0"))
+	})
+
+	t.Run("Ensure other solicitors get the default prefixes", func(t *testing.T) {
+		require.Equal(t, []netip.Addr{netip.MustParseAddr("2001:db8::")}, solicit(t, "fe80::2%net0"))
+	})
+}
+
+func TestDaemonPreempted(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				RouterLifetimeSeconds:  1800,
+				Preference:             "medium",
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	preempted := func() bool {
+		status := d.Status()
+		return len(status.Interfaces) == 1 && status.Interfaces[0].Preempted
+	}
+
+	foreignRA := func(preference ndp.Preference, lifetime time.Duration) fakeForeignRA {
+		return fakeForeignRA{
+			msg: &ndp.RouterAdvertisement{
+				RouterSelectionPreference: preference,
+				RouterLifetime:            lifetime,
+				Options: []ndp.Option{
+					&ndp.LinkLayerAddress{
+						Direction: ndp.Source,
+						Addr:      net.HardwareAddr{0x66, 0x55, 0x44, 0x33, 0x22, 0x11},
+					},
+				},
+			},
+			from: netip.MustParseAddr("fe80::2%net0"),
+		}
+	}
+
+	t.Run("Ensure lower-preference router doesn't preempt", func(t *testing.T) {
+		sock.rxRACh() <- foreignRA(ndp.Low, 1800*time.Second)
+		time.Sleep(100 * time.Millisecond)
+		require.False(t, preempted())
+	})
+
+	t.Run("Ensure higher-preference router preempts", func(t *testing.T) {
+		sock.rxRACh() <- foreignRA(ndp.High, 1800*time.Second)
+		eventully(t, preempted)
+
+		var buf bytes.Buffer
+		require.NoError(t, d.WriteMetrics(&buf))
+		require.Contains(t, buf.String(), "go_ra_preempted{interface=\"net0\"} 1\n")
+	})
+
+	t.Run("Ensure our own RA is ignored", func(t *testing.T) {
+		sock.rxRACh() <- fakeForeignRA{
+			msg: &ndp.RouterAdvertisement{
+				RouterSelectionPreference: ndp.Low,
+				Options: []ndp.Option{
+					&ndp.LinkLayerAddress{
+						Direction: ndp.Source,
+						Addr:      sock.hardwareAddr(),
+					},
+				},
+			},
+			from: netip.MustParseAddr("fe80::1%net0"),
+		}
+		time.Sleep(100 * time.Millisecond)
+		require.True(t, preempted())
+	})
+
+	t.Run("Ensure the flag is cleared when the router withdraws", func(t *testing.T) {
+		sock.rxRACh() <- foreignRA(ndp.High, 0)
+		eventully(t, func() bool { return !preempted() })
+	})
+
+	t.Run("Ensure the flag is cleared when we raise our preference", func(t *testing.T) {
+		sock.rxRACh() <- foreignRA(ndp.High, 1800*time.Second)
+		eventully(t, preempted)
+
+		high := config.deepCopy()
+		high.Interfaces[0].Preference = "high"
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		require.NoError(t, d.Reload(ctx, high))
+		eventully(t, func() bool { return !preempted() })
+	})
+}
+
+func TestDaemonSendRetries(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name: "net0",
+				// Long enough not to send the unsolicited RA
+				// during the test
+				RAIntervalMilliseconds: 1800000,
+				SendRetries:            ptr.To(1),
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	status := func() *InterfaceStatus {
+		return d.Status().Interfaces[0]
+	}
+
+	eventully(t, func() bool {
+		return status().State == Running
+	})
+
+	solicit := func() {
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.MustParseAddr("fe80::1%net0")}
+	}
+
+	t.Run("Ensure the RA is sent after retrying the transient error", func(t *testing.T) {
+		sock.injectTxErrors(unix.ENOBUFS)
+		solicit()
+
+		select {
+		case <-sock.txLLUnicastCh():
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+
+		eventully(t, func() bool {
+			return status().TxRetries == 1 && status().TxSolicitedRA == 1
+		})
+		require.Zero(t, status().TxFailures)
+	})
+
+	t.Run("Ensure the failure is counted after exhausting the retries", func(t *testing.T) {
+		sock.injectTxErrors(unix.ENOBUFS, unix.EAGAIN)
+		solicit()
+
+		eventully(t, func() bool {
+			return status().TxFailures == 1
+		})
+		require.Equal(t, 2, status().TxRetries)
+		require.Equal(t, 1, status().TxSolicitedRA)
+	})
+
+	t.Run("Ensure the non-transient error is not retried", func(t *testing.T) {
+		sock.injectTxErrors(unix.EPERM)
+		solicit()
+
+		eventully(t, func() bool {
+			return status().TxFailures == 2
+		})
+		require.Equal(t, 2, status().TxRetries)
+	})
+}
+
+func TestDaemonActivateAlias(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix: "2001:db8::/64",
+					},
+				},
+				Aliases: map[string]*AliasConfig{
+					"blue": {
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix: "2001:db8:b::/64",
+							},
+						},
+					},
+					"green": {
+						Prefixes: []*PrefixConfig{
+							{
+								Prefix: "2001:db8:9::/64",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	prefixes := func(ra fakeRA) []netip.Addr {
+		ret := []netip.Addr{}
+		for _, option := range ra.msg.Options {
+			if pi, ok := option.(*ndp.PrefixInformation); ok {
+				ret = append(ret, pi.Prefix)
+			}
+		}
+		return ret
+	}
+
+	// Waits for the RA advertising the expected prefix. Every RA in
+	// between must carry exactly one of the option sets.
+	waitPrefix := func(t *testing.T, expected string) {
+		timeout := time.After(3 * time.Second)
+		for {
+			select {
+			case ra := <-sock.txMulticastCh():
+				p := prefixes(ra)
+				require.Len(t, p, 1)
+				if p[0] == netip.MustParseAddr(expected) {
+					return
+				}
+			case <-timeout:
+				require.Fail(t, "timeout waiting for RA")
+			}
+		}
+	}
+
+	t.Run("Ensure the interface options are advertised by default", func(t *testing.T) {
+		waitPrefix(t, "2001:db8::")
+		require.Empty(t, d.Status().Interfaces[0].ActiveAlias)
+	})
+
+	t.Run("Ensure activating the alias switches the options", func(t *testing.T) {
+		require.NoError(t, d.ActivateAlias("net0", "blue"))
+		waitPrefix(t, "2001:db8:b::")
+		eventully(t, func() bool {
+			return d.Status().Interfaces[0].ActiveAlias == "blue"
+		})
+
+		require.NoError(t, d.ActivateAlias("net0", "green"))
+		waitPrefix(t, "2001:db8:9::")
+		eventully(t, func() bool {
+			return d.Status().Interfaces[0].ActiveAlias == "green"
+		})
+	})
+
+	t.Run("Ensure empty alias switches back to the interface options", func(t *testing.T) {
+		require.NoError(t, d.ActivateAlias("net0", ""))
+		waitPrefix(t, "2001:db8::")
+	})
+
+	t.Run("Ensure unknown alias or interface is rejected", func(t *testing.T) {
+		require.Error(t, d.ActivateAlias("net0", "red"))
+		require.Error(t, d.ActivateAlias("net1", "blue"))
+	})
+
+	t.Run("Ensure the alias added by the reload can be activated", func(t *testing.T) {
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].Aliases["red"] = &AliasConfig{
+			Prefixes: []*PrefixConfig{
+				{
+					Prefix: "2001:db8:4::/64",
+				},
+			},
+		}
+		require.NoError(t, d.Reload(ctx, newConfig))
+
+		require.NoError(t, d.ActivateAlias("net0", "red"))
+		waitPrefix(t, "2001:db8:4::")
+	})
+
+	t.Run("Ensure activation doesn't wait for the in-flight reload", func(t *testing.T) {
+		// Reload holds the lock during the whole apply
+		d.configLock.Lock()
+		defer d.configLock.Unlock()
+
+		done := make(chan error)
+		go func() {
+			done <- d.ActivateAlias("net0", "blue")
+		}()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			require.Fail(t, "ActivateAlias is blocked by the configuration lock")
+		}
+	})
+}
+
+func TestDaemonRSSilence(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name: "net0",
+				// Set this to super long to ensure the
+				// silence is detected without the ticks.
+				RAIntervalMilliseconds: 1800000,
+				RSSilenceWindowSeconds: 60,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	clk := newFakeClock()
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		withClock(clk),
+	)
+	require.NoError(t, err)
+
+	statusCh, unsubscribe := d.Subscribe()
+	t.Cleanup(unsubscribe)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	rsSilent := func() bool {
+		status := d.Status()
+		return len(status.Interfaces) == 1 && status.Interfaces[0].RSSilent
+	}
+
+	// Waits for the status event with the expected RSSilent
+	waitEvent := func(t *testing.T, silent bool) {
+		timeout := time.After(3 * time.Second)
+		for {
+			select {
+			case status := <-statusCh:
+				if len(status.Interfaces) == 1 && status.Interfaces[0].RSSilent == silent {
+					return
+				}
+			case <-timeout:
+				require.Fail(t, "timeout waiting for status event")
+			}
+		}
+	}
+
+	// Sends RS and waits for the reply, so that the window restarts from
+	// the current time of the clock
+	solicit := func(t *testing.T) {
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.MustParseAddr("fe80::1%net0")}
+		select {
+		case <-sock.txLLUnicastCh():
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+	}
+
+	t.Run("Ensure not silent within the window", func(t *testing.T) {
+		solicit(t)
+		clk.advance(59 * time.Second)
+		require.Never(t, rsSilent, time.Millisecond*300, time.Millisecond*10)
+	})
+
+	t.Run("Ensure silent after the window without RS", func(t *testing.T) {
+		clk.advance(time.Second)
+		waitEvent(t, true)
+
+		var buf bytes.Buffer
+		require.NoError(t, d.WriteMetrics(&buf))
+		require.Contains(t, buf.String(), "go_ra_rs_silent{interface=\"net0\"} 1\n")
+	})
+
+	t.Run("Ensure reset when RS arrives", func(t *testing.T) {
+		solicit(t)
+		waitEvent(t, false)
+
+		// The window restarts from the RS
+		clk.advance(59 * time.Second)
+		require.Never(t, rsSilent, time.Millisecond*300, time.Millisecond*10)
+
+		clk.advance(time.Second)
+		waitEvent(t, true)
+	})
+}
+
+func TestDaemonDisabledOptions(t *testing.T) {
+	rdnsses := []*RDNSSConfig{
+		{
+			LifetimeSeconds: 1800,
+			Addresses:       []string{"2001:db8::53"},
+		},
+	}
+
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				RDNSSes:                rdnsses,
+				DisabledOptions:        []string{"rdnss"},
+			},
+			{
+				Name:                   "net1",
+				RAIntervalMilliseconds: 100,
+				RDNSSes:                rdnsses,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x67}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	countRDNSS := func(t *testing.T, name string) int {
+		var sock *fakeSock
+		eventully(t, func() bool {
+			sock, err = reg.getSock(name)
+			return err == nil
+		})
+
+		ra := <-sock.txMulticastCh()
+
+		// The other options must be kept
+		require.IsType(t, &ndp.LinkLayerAddress{}, ra.msg.Options[0])
+
+		n := 0
+		for _, option := range ra.msg.Options {
+			if _, ok := option.(*ndp.RecursiveDNSServer); ok {
+				n++
+			}
+		}
+		return n
+	}
+
+	t.Run("Ensure RDNSS is omitted on the interface disabling it", func(t *testing.T) {
+		require.Zero(t, countRDNSS(t, "net0"))
+	})
+
+	t.Run("Ensure RDNSS is advertised on the other interface", func(t *testing.T) {
+		require.Equal(t, 1, countRDNSS(t, "net1"))
+	})
+}
+
+func TestDaemonMalformedRSPolicy(t *testing.T) {
+	tests := []struct {
+		policy      MalformedRSPolicy
+		expectLog   bool
+		expectCount int
+	}{
+		{policy: MalformedRSDrop, expectLog: false, expectCount: 0},
+		{policy: MalformedRSCount, expectLog: false, expectCount: 1},
+		{policy: MalformedRSLog, expectLog: true, expectCount: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			config := &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                   "net0",
+						RAIntervalMilliseconds: 100,
+					},
+				},
+			}
+
+			logs := &logBuffer{}
+			logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+			reg := newFakeSockRegistry()
+
+			devWatcher := newFakeDeviceWatcher("net0")
+			devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+			d, err := NewDaemon(
+				config,
+				WithLogger(logger),
+				WithMalformedRSPolicy(tt.policy),
+				withSocketConstructor(reg.newSock),
+				withDeviceWatcher(devWatcher),
+			)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			go d.Run(ctx)
+			t.Cleanup(cancel)
+
+			var sock *fakeSock
+			eventully(t, func() bool {
+				sock, err = reg.getSock("net0")
+				return err == nil
+			})
+
+			sock.rxCh() <- fakeRS{from: netip.MustParseAddr("fe80::1%net0"), malformed: true}
+
+			// The valid RS after the malformed one is replied, so
+			// the malformed one must have been processed by then
+			sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.MustParseAddr("fe80::2%net0")}
+			select {
+			case ra := <-sock.txLLUnicastCh():
+				require.Equal(t, netip.MustParseAddr("fe80::2%net0"), ra.to)
+			case <-time.After(time.Second):
+				require.Fail(t, "timeout waiting for RA")
+			}
+
+			status := d.Status().Interfaces[0]
+			require.Equal(t, tt.expectCount, status.RxMalformedRS)
+			require.Equal(t, Running, status.State)
+
+			logged := false
+			for _, r := range logs.records(t) {
+				if r["msg"] == "Dropped malformed RS" {
+					logged = true
+				}
+			}
+			require.Equal(t, tt.expectLog, logged)
+		})
+	}
+
+	t.Run("Ensure unknown policy is rejected", func(t *testing.T) {
+		_, err := NewDaemon(
+			&Config{},
+			WithMalformedRSPolicy("foo"),
+			withSocketConstructor(newFakeSockRegistry().newSock),
+			withDeviceWatcher(newFakeDeviceWatcher()),
+		)
+		require.Error(t, err)
+	})
+}
+
+func TestDaemonRequireLocalAddress(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:              "2001:db8::/64",
+						OnLink:              true,
+						RequireLocalAddress: true,
+					},
+					{
+						Prefix: "2001:db8:1::/64",
+						OnLink: true,
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	prefix := netip.MustParseAddr("2001:db8::")
+
+	advertised := func() bool {
+		ra := <-sock.txMulticastCh()
+		for _, option := range ra.msg.Options {
+			if pi, ok := option.(*ndp.PrefixInformation); ok && pi.Prefix == prefix {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("Ensure the prefix is withheld without the local address", func(t *testing.T) {
+		require.False(t, advertised())
+	})
+
+	t.Run("Ensure the prefix is advertised once the local address appears", func(t *testing.T) {
+		devWatcher.update("net0", deviceState{
+			isUp:          true,
+			addr:          net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66},
+			v6GlobalAddrs: []netip.Addr{netip.MustParseAddr("2001:db8::1")},
+		})
+		eventully(t, advertised)
+	})
+
+	t.Run("Ensure the prefix is withheld again once the local address disappears", func(t *testing.T) {
+		devWatcher.update("net0", deviceState{
+			isUp: true,
+			addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66},
+			// The address in the other prefix doesn't count
+			v6GlobalAddrs: []netip.Addr{netip.MustParseAddr("2001:db8:1::1")},
+		})
+		eventully(t, func() bool { return !advertised() })
+	})
+}
+
+func TestDaemonMinEffectiveInterval(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                          "net0",
+				RAIntervalMilliseconds:        70,
+				InitialRACount:                3,
+				InitialRAIntervalMilliseconds: 70,
+			},
+		},
+	}
+
+	logs := &logBuffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		WithLogger(logger),
+		WithMinEffectiveInterval(200*time.Millisecond),
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure the RAs are sent with the clamped interval", func(t *testing.T) {
+		// The initial burst is clamped as well
+		prev := <-sock.txMulticastCh()
+		for i := 0; i < 5; i++ {
+			ra := <-sock.txMulticastCh()
+			require.InDelta(t, 200*time.Millisecond, ra.tstamp.Sub(prev.tstamp), float64(50*time.Millisecond))
+			prev = ra
+		}
+	})
+
+	t.Run("Ensure the clamp is logged once", func(t *testing.T) {
+		n := 0
+		for _, r := range logs.records(t) {
+			if r["msg"] == "RA interval is raised to the minimum effective interval" {
+				n++
+			}
+		}
+		require.Equal(t, 1, n)
+	})
+
+	t.Run("Ensure negative interval is rejected", func(t *testing.T) {
+		_, err := NewDaemon(
+			&Config{},
+			WithMinEffectiveInterval(-time.Second),
+			withSocketConstructor(newFakeSockRegistry().newSock),
+			withDeviceWatcher(newFakeDeviceWatcher()),
+		)
+		require.Error(t, err)
+	})
+}
+
+func TestDaemonAcceptRA(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+			{
+				Name:                   "net1",
+				RAIntervalMilliseconds: 100,
+				DisableAcceptRA:        true,
+			},
+		},
+	}
+
+	logs := &logBuffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	sysctl := newFakeSysctl()
+	require.NoError(t, setAcceptRA(sysctl, "", "net0", 1))
+	require.NoError(t, setAcceptRA(sysctl, "", "net1", 1))
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x67}})
+
+	d, err := NewDaemon(
+		config,
+		WithLogger(logger),
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		withSysctl(sysctl),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	eventully(t, func() bool {
+		_, err0 := reg.getSock("net0")
+		_, err1 := reg.getSock("net1")
+		return err0 == nil && err1 == nil
+	})
+
+	t.Run("Ensure the enabled accept_ra is warned", func(t *testing.T) {
+		eventully(t, func() bool {
+			for _, r := range logs.records(t) {
+				if r["msg"] == "accept_ra is enabled on the interface. The router may configure itself from the RAs." &&
+					r["level"] == "WARN" && r["interface"] == "net0" {
+					return true
+				}
+			}
+			return false
+		})
+
+		acceptRA, err := getAcceptRA(sysctl, "", "net0")
+		require.NoError(t, err)
+		require.Equal(t, 1, acceptRA)
+	})
+
+	t.Run("Ensure accept_ra is disabled with DisableAcceptRA", func(t *testing.T) {
+		eventully(t, func() bool {
+			acceptRA, err := getAcceptRA(sysctl, "", "net1")
+			return err == nil && acceptRA == 0
+		})
+	})
+}
+
+func TestDaemonSolicitedRateLimit(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name: "net0",
+				// Set this to super long to avoid sending
+				// unsolicited RAs.
+				RAIntervalMilliseconds:          1800000,
+				MinDelayBetweenRAsMilliseconds:  1000,
+				SolicitedRateLimit:              2,
+				SolicitedRateLimitWindowSeconds: 60,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	clk := newFakeClock()
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		withClock(clk),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	flooder := netip.MustParseAddr("fe80::1%net0")
+	other := netip.MustParseAddr("fe80::2%net0")
+
+	for i := 0; i < 5; i++ {
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: flooder}
+	}
+
+	// The RS from the other source is processed after the flood
+	sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: other}
+
+	t.Run("Ensure the replies to the same source are capped", func(t *testing.T) {
+		var tos []netip.Addr
+		for len(tos) < 3 {
+			select {
+			case ra := <-sock.txLLUnicastCh():
+				tos = append(tos, ra.to)
+			case <-time.After(time.Second):
+				require.Fail(t, "timeout waiting for RA")
+			}
+		}
+		require.Equal(t, []netip.Addr{flooder, flooder, other}, tos)
+
+		require.Never(t, func() bool {
+			return len(sock.txLLUnicastCh()) > 0
+		}, time.Millisecond*300, time.Millisecond*10)
+	})
+
+	t.Run("Ensure the suppressed replies are counted", func(t *testing.T) {
+		require.Equal(t, 3, d.Status().Interfaces[0].RxRateLimitedRS)
+	})
+
+	t.Run("Ensure the window and the multicast delay expire together", func(t *testing.T) {
+		// The first multicast reply is sent immediately and the
+		// next one is delayed
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.IPv6Unspecified()}
+		select {
+		case <-sock.txMulticastCh():
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.IPv6Unspecified()}
+		eventully(t, func() bool {
+			state, err := d.RateLimiterState("net0")
+			require.NoError(t, err)
+			return !state.PendingReplyAt.IsZero()
+		})
+
+		clk.advance(time.Second * 60)
+
+		select {
+		case <-sock.txMulticastCh():
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for the delayed reply")
+		}
+
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: flooder}
+		select {
+		case ra := <-sock.txLLUnicastCh():
+			require.Equal(t, flooder, ra.to)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+		require.Equal(t, 3, d.Status().Interfaces[0].RxRateLimitedRS)
+	})
+}
+
+func TestDaemonPrefixSource(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				PrefixSource: &PrefixSourceConfig{
+					URL:                 "http://ipam.example/prefixes",
+					PollIntervalSeconds: 1,
+				},
+			},
+		},
+	}
+
+	source := newFakePrefixSource("2001:db8:1::/64")
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		WithHTTPClient(&http.Client{Transport: source}),
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	// Returns the advertised prefixes and their valid lifetimes
+	advertised := func() map[string]time.Duration {
+		ra := <-sock.txMulticastCh()
+		prefixes := map[string]time.Duration{}
+		for _, option := range ra.msg.Options {
+			if pi, ok := option.(*ndp.PrefixInformation); ok {
+				prefixes[netip.PrefixFrom(pi.Prefix, int(pi.PrefixLength)).String()] = pi.ValidLifetime
+			}
+		}
+		return prefixes
+	}
+
+	t.Run("Ensure the prefixes from the source are advertised", func(t *testing.T) {
+		eventully(t, func() bool {
+			prefixes := advertised()
+			return len(prefixes) == 1 && prefixes["2001:db8:1::/64"] > 0
+		})
+	})
+
+	t.Run("Ensure the advertised prefixes follow the source", func(t *testing.T) {
+		source.set("2001:db8:2::/64")
+
+		// Wait for the next poll
+		require.Eventually(t, func() bool {
+			prefixes := advertised()
+			lifetime, ok := prefixes["2001:db8:1::/64"]
+			return len(prefixes) == 2 && prefixes["2001:db8:2::/64"] > 0 && ok && lifetime == 0
+		}, time.Second*3, time.Millisecond*10)
+	})
+
+	t.Run("Ensure the withdrawn prefix disappears after the next poll", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			prefixes := advertised()
+			return len(prefixes) == 1 && prefixes["2001:db8:2::/64"] > 0
+		}, time.Second*3, time.Millisecond*10)
+	})
+}
+
+func TestDaemonReservedBits(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				Managed:                true,
+				ReservedBits:           3,
+				AllowReservedBits:      true,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure the reserved bits are set in the header", func(t *testing.T) {
+		ra := <-sock.txMulticastCh()
+		require.NotNil(t, ra.raw)
+		require.Equal(t, byte(0x80|0x03), ra.raw[raFlagsOffset])
+		require.True(t, ra.msg.ManagedConfiguration)
+	})
+
+	t.Run("Ensure the RA is sent as usual without the reserved bits", func(t *testing.T) {
+		config.Interfaces[0].ReservedBits = 0
+		require.NoError(t, d.Reload(ctx, config))
+
+		require.Eventually(t, func() bool {
+			ra := <-sock.txMulticastCh()
+			return ra.raw == nil
+		}, time.Second*3, time.Millisecond*10)
+	})
+}
+
+func TestDaemonReloadDuringInitialBurst(t *testing.T) {
+	// Collects the intervals between the RAs after the first one until
+	// the one longer than the burst interval appears
+	burstIntervals := func(t *testing.T, sock *fakeSock, first fakeRA) ([]time.Duration, []fakeRA) {
+		intervals := []time.Duration{}
+		ras := []fakeRA{}
+		prev := first
+		for {
+			select {
+			case ra := <-sock.txMulticastCh():
+				intervals = append(intervals, ra.tstamp.Sub(prev.tstamp))
+				ras = append(ras, ra)
+				if ra.tstamp.Sub(prev.tstamp) > 500*time.Millisecond {
+					return intervals, ras
+				}
+				prev = ra
+			case <-time.After(3 * time.Second):
+				require.Fail(t, "timeout waiting for RA")
+			}
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change bool
+		// Number of the burst intervals after the reload
+		expectBurst int
+	}{
+		{name: "Ensure the burst continues when the configuration is unchanged", change: false, expectBurst: 2},
+		{name: "Ensure the burst restarts when the configuration is changed", change: true, expectBurst: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Interfaces: []*InterfaceConfig{
+					{
+						Name:                          "net0",
+						RAIntervalMilliseconds:        1000,
+						InitialRACount:                3,
+						InitialRAIntervalMilliseconds: 100,
+					},
+				},
+			}
+
+			reg := newFakeSockRegistry()
+
+			devWatcher := newFakeDeviceWatcher("net0")
+			devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+			d, err := NewDaemon(
+				config,
+				withSocketConstructor(reg.newSock),
+				withDeviceWatcher(devWatcher),
+			)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			go d.Run(ctx)
+			t.Cleanup(cancel)
+
+			var sock *fakeSock
+			eventully(t, func() bool {
+				sock, err = reg.getSock("net0")
+				return err == nil
+			})
+
+			// The first RA of the burst
+			first := <-sock.txMulticastCh()
+
+			// Reload right after the first RA, so that the next
+			// RA of the burst is sent after the reload
+			reloaded := config.deepCopy()
+			if tt.change {
+				reloaded.Interfaces[0].CurrentHopLimit = 64
+			}
+			timeout, cancelTimeout := context.WithTimeout(ctx, time.Second)
+			defer cancelTimeout()
+			require.NoError(t, d.Reload(timeout, reloaded))
+			reloadedAt := time.Now()
+
+			intervals, ras := burstIntervals(t, sock, first)
+
+			// The burst intervals followed by the regular one.
+			// There's no duplicate RA with the shorter interval.
+			require.Len(t, intervals, tt.expectBurst+1)
+			for _, interval := range intervals[:tt.expectBurst] {
+				require.InDelta(t, 100*time.Millisecond, interval, float64(60*time.Millisecond))
+			}
+			require.InDelta(t, time.Second, intervals[tt.expectBurst], float64(100*time.Millisecond))
+
+			if tt.change {
+				for _, ra := range ras {
+					require.Equal(t, uint8(64), ra.msg.CurrentHopLimit)
+				}
+				require.Less(t, ras[0].tstamp.Sub(reloadedAt), 150*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestDaemonReloadRollback(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				CurrentHopLimit:        10,
+			},
+			{
+				Name:                   "net1",
+				RAIntervalMilliseconds: 100,
+				CurrentHopLimit:        10,
+			},
+		},
+	}
+
+	// Blocks the advertisement loop of net1, so that it cannot accept
+	// the reload
+	block := make(chan any)
+	blocked := make(chan any)
+	t.Cleanup(func() { close(block) })
+	handler := func(iface string, _ *ndp.RouterSolicitation, _ netip.Addr) bool {
+		if iface == "net1" {
+			close(blocked)
+			<-block
+		}
+		return true
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x67}})
+
+	d, err := NewDaemon(
+		config,
+		WithRSHandler(handler),
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock0, sock1 *fakeSock
+	eventully(t, func() bool {
+		sock0, err = reg.getSock("net0")
+		if err != nil {
+			return false
+		}
+		sock1, err = reg.getSock("net1")
+		return err == nil
+	})
+
+	sock1.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.MustParseAddr("fe80::1%net1")}
+	<-blocked
+
+	t.Run("Ensure the reload failing on the second interface is rolled back", func(t *testing.T) {
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].CurrentHopLimit = 64
+		newConfig.Interfaces[1].CurrentHopLimit = 64
+
+		timeout, cancelTimeout := context.WithTimeout(ctx, time.Second*10)
+		defer cancelTimeout()
+		err := d.Reload(timeout, newConfig)
+		require.ErrorIs(t, err, ErrReloadRolledBack)
+		require.ErrorContains(t, err, "net1")
+	})
+
+	t.Run("Ensure the first interface reverts to the previous settings", func(t *testing.T) {
+		// Drain the RAs sent before the rollback
+		for len(sock0.txMulticastCh()) > 0 {
+			<-sock0.txMulticastCh()
+		}
+
+		eventully(t, func() bool {
+			ra := <-sock0.txMulticastCh()
+			return ra.msg.CurrentHopLimit == 10
+		})
+
+		// The rollback has a new generation. The failed generation 2
+		// must not be reused. net1 never received the configuration.
+		status := d.Status()
+		require.Equal(t, 3, status.Generation)
+		require.Equal(t, "net0", status.Interfaces[0].Name)
+		require.Equal(t, 3, status.Interfaces[0].AppliedGeneration)
+		require.Equal(t, "net1", status.Interfaces[1].Name)
+		require.Equal(t, 1, status.Interfaces[1].AppliedGeneration)
+	})
+}
+
+func TestDaemonAdvertiseEveryN(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix: "2001:db8:1::/64",
+					},
+					{
+						Prefix:          "2001:db8:2::/64",
+						AdvertiseEveryN: 2,
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	hasPrefix := func(ra fakeRA, prefix string) bool {
+		for _, option := range ra.msg.Options {
+			if pi, ok := option.(*ndp.PrefixInformation); ok && netip.PrefixFrom(pi.Prefix, int(pi.PrefixLength)).String() == prefix {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("Ensure the prefix appears in every other unsolicited RA", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			ra := <-sock.txMulticastCh()
+			require.True(t, hasPrefix(ra, "2001:db8:1::/64"))
+			require.Equal(t, i%2 == 0, hasPrefix(ra, "2001:db8:2::/64"), "RA #%d", i)
+		}
+	})
+
+	t.Run("Ensure the solicited RA always includes the prefix", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.MustParseAddr("fe80::1%net0")}
+			ra := <-sock.txLLUnicastCh()
+			require.True(t, hasPrefix(ra, "2001:db8:2::/64"))
+		}
+	})
+}
+
+func TestDaemonSocketReceiveOptions(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name: "net0",
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		WithSocketReceiveBuffer(4<<20),
+		WithNonBlockingReceive(),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	require.Equal(t, 4<<20, sock.opts.recvBufferSize)
+	require.True(t, sock.opts.nonBlockingRecv)
+
+	t.Run("Negative buffer size", func(t *testing.T) {
+		_, err := NewDaemon(config, WithSocketReceiveBuffer(-1))
+		require.Error(t, err)
+	})
+}
+
+func BenchmarkDaemonRSReceive(b *testing.B) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name: "net0",
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		WithNonBlockingReceive(),
+	)
+	require.NoError(b, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	b.Cleanup(cancel)
+
+	var sock *fakeSock
+	require.Eventually(b, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	}, time.Second*5, time.Millisecond*10)
+
+	// Drain the replies to keep the advertiser sending
+	go func() {
+		for {
+			select {
+			case <-sock.txLLUnicastCh():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	from := netip.MustParseAddr("fe80::1%net0")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+	}
+
+	// Wait for all RSs to be handled
+	for {
+		status := d.Status().Interfaces[0]
+		if status.TxSolicitedRA+status.TxFailures >= b.N {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDaemonRoutesWithoutDefaultRouter(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				RouterLifetimeSeconds:  0,
+				Routes: []*RouteConfig{
+					{
+						Prefix:          "2001:db8::/48",
+						LifetimeSeconds: 100,
+						Preference:      "high",
+					},
+					{
+						Prefix:          "2001:db8:1::/48",
+						LifetimeSeconds: 200,
+						Preference:      "low",
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	assertRA := func(t *testing.T, msg *ndp.RouterAdvertisement) {
+		// No default router signal
+		require.Zero(t, msg.RouterLifetime)
+		require.Equal(t, ndp.Medium, msg.RouterSelectionPreference)
+
+		routeOptions := map[netip.Addr]*ndp.RouteInformation{}
+		for _, option := range msg.Options {
+			if opt, ok := option.(*ndp.RouteInformation); ok {
+				routeOptions[opt.Prefix] = opt
+			}
+		}
+		require.Len(t, routeOptions, 2)
+		route0 := routeOptions[netip.MustParseAddr("2001:db8::")]
+		route1 := routeOptions[netip.MustParseAddr("2001:db8:1::")]
+		require.NotNil(t, route0)
+		require.NotNil(t, route1)
+		require.Equal(t, uint8(48), route0.PrefixLength)
+		require.Equal(t, ndp.High, route0.Preference)
+		require.Equal(t, time.Second*100, route0.RouteLifetime)
+		require.Equal(t, uint8(48), route1.PrefixLength)
+		require.Equal(t, ndp.Low, route1.Preference)
+		require.Equal(t, time.Second*200, route1.RouteLifetime)
+	}
+
+	t.Run("Ensure the unsolicited RA carries the routes", func(t *testing.T) {
+		select {
+		case ra := <-sock.txMulticastCh():
+			assertRA(t, ra.msg)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+
+	t.Run("Ensure the RS is replied with the routes", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%net0")
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		select {
+		case ra := <-sock.txLLUnicastCh():
+			require.Equal(t, from, ra.to)
+			assertRA(t, ra.msg)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+}
+
+func TestDaemonSendStagger(t *testing.T) {
+	names := []string{"net0", "net1", "net2", "net3"}
+
+	config := &Config{}
+	for _, name := range names {
+		config.Interfaces = append(config.Interfaces, &InterfaceConfig{
+			Name:                   name,
+			RAIntervalMilliseconds: 1000,
+		})
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher(names...)
+	for _, name := range names {
+		devWatcher.update(name, deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	}
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		WithSendStagger(time.Millisecond*400),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	socks := []*fakeSock{}
+	for _, name := range names {
+		var sock *fakeSock
+		eventully(t, func() bool {
+			sock, err = reg.getSock(name)
+			return err == nil
+		})
+		socks = append(socks, sock)
+	}
+
+	t.Run("Ensure the sends within an interval are spread over the window", func(t *testing.T) {
+		tstamps := []time.Time{}
+		for i, sock := range socks {
+			select {
+			case ra := <-sock.txMulticastCh():
+				tstamps = append(tstamps, ra.tstamp)
+			case <-time.After(time.Second * 3):
+				require.Failf(t, "timeout waiting for RA", "interface %s", names[i])
+			}
+		}
+
+		// The delays are 0ms, 100ms, 200ms, and 300ms in the order of
+		// the name
+		for i := 1; i < len(tstamps); i++ {
+			gap := tstamps[i].Sub(tstamps[i-1])
+			require.Greater(t, gap, time.Millisecond*50, "%s is sent too close to %s", names[i], names[i-1])
+		}
+		require.Less(t, tstamps[len(tstamps)-1].Sub(tstamps[0]), time.Millisecond*600)
+	})
+
+	t.Run("Ensure the time to the next RA includes the delay", func(t *testing.T) {
+		// net3 is delayed by 300ms. Right after its RA, the next one
+		// is a whole interval later, not 300ms earlier.
+		<-socks[3].txMulticastCh()
+		ttn, err := d.TimeToNextRA("net3")
+		require.NoError(t, err)
+		require.InDelta(t, time.Millisecond*1000, ttn, float64(time.Millisecond*100))
+	})
+
+	t.Run("Negative window", func(t *testing.T) {
+		_, err := NewDaemon(config, WithSendStagger(-time.Second))
+		require.Error(t, err)
+	})
+}
+
+func TestDaemonDecrementLifetimes(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:                   "2001:db8::/64",
+						PreferredLifetimeSeconds: ptr.To(100),
+						ValidLifetimeSeconds:     ptr.To(200),
+						DecrementLifetimes:       true,
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	clk := newFakeClock()
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		withClock(clk),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	prefixInfo := func(msg *ndp.RouterAdvertisement) *ndp.PrefixInformation {
+		for _, option := range msg.Options {
+			if opt, ok := option.(*ndp.PrefixInformation); ok {
+				return opt
+			}
+		}
+		return nil
+	}
+
+	t.Run("Ensure the configured lifetimes are advertised first", func(t *testing.T) {
+		pi := prefixInfo((<-sock.txMulticastCh()).msg)
+		require.NotNil(t, pi)
+		require.Equal(t, time.Second*100, pi.PreferredLifetime)
+		require.Equal(t, time.Second*200, pi.ValidLifetime)
+	})
+
+	t.Run("Ensure the lifetimes are decremented over time", func(t *testing.T) {
+		clk.advance(time.Second * 150)
+
+		require.Eventually(t, func() bool {
+			pi := prefixInfo((<-sock.txMulticastCh()).msg)
+			return pi.PreferredLifetime == 0 && pi.ValidLifetime == time.Second*50
+		}, time.Second*3, time.Millisecond*10)
+	})
+
+	t.Run("Ensure the RS reply carries the decremented lifetimes", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%net0")
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		select {
+		case ra := <-sock.txLLUnicastCh():
+			pi := prefixInfo(ra.msg)
+			require.NotNil(t, pi)
+			require.Zero(t, pi.PreferredLifetime)
+			require.Equal(t, time.Second*50, pi.ValidLifetime)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+}
+
+func TestDaemonStatusNotifyInterval(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		WithFlapGrace(time.Minute),
+		WithStatusNotifyInterval(time.Millisecond*200),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	eventully(t, func() bool {
+		s := d.Status()
+		return len(s.Interfaces) == 1 && s.Interfaces[0].State == Running
+	})
+
+	statusCh, unsubscribe := d.Subscribe()
+	t.Cleanup(unsubscribe)
+
+	t.Run("Ensure the flaps coalesce into the bounded snapshots", func(t *testing.T) {
+		start := time.Now()
+		for i := 0; i < 20; i++ {
+			devWatcher.update("net0", deviceState{isUp: false, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+			devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+		}
+		devWatcher.update("net0", deviceState{isUp: false, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+		// Collect the snapshots until they settle
+		var snapshots []Status
+		for {
+			select {
+			case status := <-statusCh:
+				snapshots = append(snapshots, status)
+				continue
+			case <-time.After(time.Millisecond * 500):
+			}
+			break
+		}
+
+		// One snapshot right away and at most one per interval after
+		// that
+		maxSnapshots := int(time.Since(start)/(time.Millisecond*200)) + 1
+		require.NotEmpty(t, snapshots)
+		require.LessOrEqual(t, len(snapshots), maxSnapshots)
+
+		last := snapshots[len(snapshots)-1]
+		require.Len(t, last.Interfaces, 1)
+		require.Equal(t, Failing, last.Interfaces[0].State)
+		require.Equal(t, d.Status().Interfaces[0].State, last.Interfaces[0].State)
+	})
+
+	t.Run("Negative interval", func(t *testing.T) {
+		_, err := NewDaemon(config, WithStatusNotifyInterval(-time.Second))
+		require.Error(t, err)
+	})
+}
+
+func TestDaemonFailFast(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 1000,
+			},
+		},
+	}
+
+	// The socket never gets created
+	failingSock := func(iface string, opts socketOpts) (socket, error) {
+		return nil, fmt.Errorf("socket creation failed")
+	}
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}})
+
+	t.Run("Enabled", func(t *testing.T) {
+		d, err := NewDaemon(
+			config,
+			withSocketConstructor(failingSock),
+			withDeviceWatcher(devWatcher),
+			WithFailFast(300*time.Millisecond),
+		)
+		require.NoError(t, err)
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- d.Run(context.Background())
+		}()
+
+		select {
+		case err := <-errCh:
+			require.ErrorIs(t, err, ErrStartupFailed)
+			require.ErrorContains(t, err, "net0")
+		case <-time.After(3 * time.Second):
+			t.Fatal("Run didn't return within the deadline")
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		d, err := NewDaemon(
+			config,
+			withSocketConstructor(failingSock),
+			withDeviceWatcher(devWatcher),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- d.Run(ctx)
+		}()
+
+		// Run keeps running while the interface is failing
+		select {
+		case err := <-errCh:
+			t.Fatalf("Run returned unexpectedly: %v", err)
+		case <-time.After(500 * time.Millisecond):
+		}
+
+		cancel()
+		require.NoError(t, <-errCh)
+	})
+
+	t.Run("Negative", func(t *testing.T) {
+		_, err := NewDaemon(config, WithFailFast(-time.Second))
+		require.Error(t, err)
+	})
+}
+
+func TestDaemonWithdrawalRepeat(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				WithdrawalRepeat:       3,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:     "2001:db8:0:1::/64",
+						OnLink:     true,
+						Autonomous: true,
+					},
+					{
+						Prefix:     "2001:db8:0:2::/64",
+						OnLink:     true,
+						Autonomous: true,
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	// Returns the Prefix Information of the removed prefix
+	removed := netip.MustParseAddr("2001:db8:0:2::")
+	findRemoved := func(msg *ndp.RouterAdvertisement) *ndp.PrefixInformation {
+		for _, opt := range msg.Options {
+			if pi, ok := opt.(*ndp.PrefixInformation); ok && pi.Prefix == removed {
+				return pi
+			}
+		}
+		return nil
+	}
+
+	// Wait for the RA before reload
+	ra := <-sock.txMulticastCh()
+	require.NotNil(t, findRemoved(ra.msg))
+
+	newConfig := config.deepCopy()
+	newConfig.Interfaces[0].Prefixes = newConfig.Interfaces[0].Prefixes[:1]
+	require.NoError(t, d.Reload(ctx, newConfig))
+
+	// Count the zero-lifetime advertisements until the prefix disappears
+	// for a while. The RAs before the reload may still be in the queue.
+	withdrawals, absent := 0, 0
+	for absent < 5 {
+		select {
+		case ra := <-sock.txMulticastCh():
+			pi := findRemoved(ra.msg)
+			switch {
+			case pi == nil:
+				absent++
+			case pi.ValidLifetime == 0:
+				require.Zero(t, absent, "withdrawn prefix reappeared")
+				require.Zero(t, pi.PreferredLifetime)
+				require.True(t, pi.OnLink)
+				require.True(t, pi.AutonomousAddressConfiguration)
+				withdrawals++
+			default:
+				require.Zero(t, withdrawals, "prefix advertised with non-zero lifetime after withdrawal")
+			}
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+	}
+	require.Equal(t, 3, withdrawals)
+}
+
+func TestDaemonMTUFromInterfaces(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				MTUFromInterfaces:      []string{"net1", "net2"},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1", "net2")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}, mtu: 9000})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	// Returns the advertised MTU. Zero means no MTU option.
+	advertisedMTU := func(msg *ndp.RouterAdvertisement) int {
+		for _, opt := range msg.Options {
+			if mtu, ok := opt.(*ndp.MTU); ok {
+				return int(mtu.MTU)
+			}
+		}
+		return 0
+	}
+
+	// Waits for the RA with the MTU
+	waitMTU := func(t *testing.T, expected int) {
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case ra := <-sock.txMulticastCh():
+				if advertisedMTU(ra.msg) == expected {
+					return
+				}
+			case <-timeout:
+				require.Fail(t, fmt.Sprintf("timeout waiting for RA with MTU %d", expected))
+				return
+			}
+		}
+	}
+
+	t.Run("Ensure the MTU is not advertised until known", func(t *testing.T) {
+		ra := <-sock.txMulticastCh()
+		require.Zero(t, advertisedMTU(ra.msg))
+	})
+
+	t.Run("Ensure the smaller MTU is advertised", func(t *testing.T) {
+		devWatcher.update("net1", deviceState{isUp: true, mtu: 1500})
+		devWatcher.update("net2", deviceState{isUp: true, mtu: 1420})
+		waitMTU(t, 1420)
+	})
+
+	t.Run("Ensure the MTU follows the change", func(t *testing.T) {
+		devWatcher.update("net1", deviceState{isUp: true, mtu: 1400})
+		waitMTU(t, 1400)
+	})
+
+	t.Run("Ensure the MTU smaller than 1280 is raised", func(t *testing.T) {
+		devWatcher.update("net2", deviceState{isUp: true, mtu: 1000})
+		waitMTU(t, 1280)
+	})
+
+	t.Run("Ensure the MTU of the removed interface is forgotten", func(t *testing.T) {
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].MTUFromInterfaces = []string{"net1"}
+		require.NoError(t, d.Reload(ctx, newConfig))
+
+		// The MTUs are unknown until the interfaces are watched again
+		waitMTU(t, 0)
+
+		devWatcher.update("net1", deviceState{isUp: true, mtu: 1450})
+		waitMTU(t, 1450)
+	})
+}
+
+func TestDaemonBridgeMACChange(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "br0",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	bridgeMAC := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+	newBridgeMAC := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x02}
+
+	devWatcher := newFakeDeviceWatcher("br0", "veth0")
+	devWatcher.update("br0", deviceState{isUp: true, addr: bridgeMAC})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("br0")
+		return err == nil
+	})
+
+	sllaOf := func(ra fakeRA) net.HardwareAddr {
+		for _, option := range ra.msg.Options {
+			if opt, ok := option.(*ndp.LinkLayerAddress); ok && opt.Direction == ndp.Source {
+				return opt.Addr
+			}
+		}
+		return nil
+	}
+
+	t.Run("Ensure SLLA is the bridge MAC", func(t *testing.T) {
+		require.Equal(t, bridgeMAC, sllaOf(<-sock.txMulticastCh()))
+	})
+
+	t.Run("Ensure SLLA follows the bridge, not the member", func(t *testing.T) {
+		// The member joins and the bridge inherits its MAC
+		devWatcher.update("veth0", deviceState{isUp: true, addr: net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x03}})
+		devWatcher.update("br0", deviceState{isUp: true, addr: newBridgeMAC})
+
+		eventully(t, func() bool {
+			return slices.Equal(newBridgeMAC, sllaOf(<-sock.txMulticastCh()))
+		})
+
+		// Sample a few more RAs
+		for i := 0; i < 3; i++ {
+			require.Equal(t, newBridgeMAC, sllaOf(<-sock.txMulticastCh()))
+		}
+	})
+}
+
+func TestDaemonDeviceWatcherRestart(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	mac := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+	newMAC := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x02}
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: mac})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	sllaOf := func(ra fakeRA) net.HardwareAddr {
+		for _, option := range ra.msg.Options {
+			if opt, ok := option.(*ndp.LinkLayerAddress); ok && opt.Direction == ndp.Source {
+				return opt.Addr
+			}
+		}
+		return nil
+	}
+
+	require.Equal(t, mac, sllaOf(<-sock.txMulticastCh()))
+
+	t.Run("Ensure the watcher failure is reported", func(t *testing.T) {
+		// Fail the first restart as well to keep it failing for a
+		// while
+		devWatcher.fail("net0", fmt.Errorf("netlink socket died"), fmt.Errorf("netlink socket unavailable"))
+
+		eventully(t, func() bool {
+			return d.Status().Interfaces[0].DeviceWatcherError != ""
+		})
+
+		// The advertisement continues with the last known state
+		require.Equal(t, mac, sllaOf(<-sock.txMulticastCh()))
+	})
+
+	t.Run("Ensure the watcher recovers", func(t *testing.T) {
+		eventully(t, func() bool {
+			status := d.Status().Interfaces[0]
+			return status.DeviceWatcherError == "" && status.DeviceWatcherRestarts == 1
+		})
+
+		// The device updates resume
+		devWatcher.update("net0", deviceState{isUp: true, addr: newMAC})
+		eventully(t, func() bool {
+			return slices.Equal(newMAC, sllaOf(<-sock.txMulticastCh()))
+		})
+	})
+}
+
+func TestDaemonDeviceDiscoveryRestart(t *testing.T) {
+	config := &Config{
+		AllInterfaces: true,
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0", "net1")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+	devWatcher.update("net1", deviceState{isUp: true, addr: net.HardwareAddr{0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	devWatcher.add(InterfaceInfo{Name: "net0", Up: true})
+	eventully(t, func() bool {
+		_, err := reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure the discovery failure is reported", func(t *testing.T) {
+		devWatcher.failAll(fmt.Errorf("netlink socket died"))
+
+		eventully(t, func() bool {
+			return d.Status().DeviceDiscoveryError != ""
+		})
+	})
+
+	t.Run("Ensure the discovery recovers", func(t *testing.T) {
+		eventully(t, func() bool {
+			status := d.Status()
+			return status.DeviceDiscoveryError == "" && status.DeviceDiscoveryRestarts == 1
+		})
+
+		// The restarted watcher discovers the new device
+		devWatcher.add(InterfaceInfo{Name: "net1", Up: true})
+		eventully(t, func() bool {
+			_, err := reg.getSock("net1")
+			return err == nil
+		})
+	})
+}
+
+func TestDaemonShadowMode(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				ShadowMode:             true,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure nothing is transmitted while counters update", func(t *testing.T) {
+		from := netip.MustParseAddr("fe80::1%net0")
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: from}
+
+		eventully(t, func() bool {
+			status := d.Status()
+			return status.Interfaces[0].TxUnsolicitedRA >= 3 && status.Interfaces[0].TxSolicitedRA >= 1
+		})
+
+		select {
+		case <-sock.txMulticastCh():
+			require.Fail(t, "unsolicited RA is transmitted in the shadow mode")
+		case <-sock.txLLUnicastCh():
+			require.Fail(t, "solicited RA is transmitted in the shadow mode")
+		default:
+		}
+	})
+
+	t.Run("Ensure RAs are transmitted after the cutover", func(t *testing.T) {
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].ShadowMode = false
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		require.NoError(t, d.Reload(ctx, newConfig))
+
+		select {
+		case <-sock.txMulticastCh():
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for RA")
+		}
+	})
+}
+
+func TestDaemonPrefixChangeHandler(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:                   "2001:db8:0:1::/64",
+						OnLink:                   true,
+						Autonomous:               true,
+						ValidLifetimeSeconds:     ptr.To(86400),
+						PreferredLifetimeSeconds: ptr.To(14400),
+					},
+				},
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	eventCh := make(chan PrefixChangeEvent, 16)
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		WithPrefixChangeHandler(func(event PrefixChangeEvent) {
+			eventCh <- event
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure the initial prefixes are not a change", func(t *testing.T) {
+		// Wait for a few RAs
+		for i := 0; i < 3; i++ {
+			<-sock.txMulticastCh()
+		}
+		require.Empty(t, eventCh)
+		require.Zero(t, d.Status().Interfaces[0].PrefixChanges)
+	})
+
+	t.Run("Ensure the reload which doesn't change the prefixes is not a change", func(t *testing.T) {
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].CurrentHopLimit = 32
+		require.NoError(t, d.Reload(ctx, newConfig))
+
+		for i := 0; i < 3; i++ {
+			<-sock.txMulticastCh()
+		}
+		require.Empty(t, eventCh)
+	})
+
+	t.Run("Ensure the renumbering is reported with before and after", func(t *testing.T) {
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].Prefixes[0].Prefix = "2001:db8:0:2::/64"
+		newConfig.Interfaces[0].Prefixes[0].PreferredLifetimeSeconds = ptr.To(0)
+		require.NoError(t, d.Reload(ctx, newConfig))
+
+		select {
+		case event := <-eventCh:
+			require.Equal(t, PrefixChangeEvent{
+				Interface: "net0",
+				Before: []AdvertisedPrefix{
+					{
+						Prefix:                   "2001:db8:0:1::/64",
+						OnLink:                   true,
+						Autonomous:               true,
+						ValidLifetimeSeconds:     86400,
+						PreferredLifetimeSeconds: 14400,
+					},
+				},
+				After: []AdvertisedPrefix{
+					{
+						Prefix:                   "2001:db8:0:2::/64",
+						OnLink:                   true,
+						Autonomous:               true,
+						ValidLifetimeSeconds:     86400,
+						PreferredLifetimeSeconds: 0,
+					},
+				},
+			}, event)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for prefix change event")
+		}
+
+		require.Equal(t, 1, d.Status().Interfaces[0].PrefixChanges)
+
+		var b bytes.Buffer
+		require.NoError(t, d.WriteMetrics(&b))
+		require.Contains(t, b.String(), `go_ra_prefix_changes_total{interface="net0"} 1`)
+	})
+}
+
+func TestDaemonCurrentHopLimitUnspecified(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				CurrentHopLimit:        64,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	ra := <-sock.txMulticastCh()
+	require.Equal(t, uint8(64), ra.msg.CurrentHopLimit)
+
+	t.Run("Ensure zero is advertised as unspecified after reload", func(t *testing.T) {
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].CurrentHopLimit = 0
+		require.NoError(t, d.Reload(ctx, newConfig))
+
+		eventully(t, func() bool {
+			return (<-sock.txMulticastCh()).msg.CurrentHopLimit == 0
+		})
+
+		// Not defaulted to anything else afterwards
+		for i := 0; i < 3; i++ {
+			require.Equal(t, uint8(0), (<-sock.txMulticastCh()).msg.CurrentHopLimit)
+		}
+	})
+
+	t.Run("Ensure zero can be set with the patch", func(t *testing.T) {
+		require.NoError(t, d.Patch(ctx, &ConfigPatch{
+			Interfaces: []*InterfaceConfigPatch{
+				{
+					Name:   "net0",
+					Values: &InterfaceConfig{CurrentHopLimit: 32},
+					Set:    []string{"currentHopLimit"},
+				},
+			},
+		}))
+		eventully(t, func() bool {
+			return (<-sock.txMulticastCh()).msg.CurrentHopLimit == 32
+		})
+
+		require.NoError(t, d.Patch(ctx, &ConfigPatch{
+			Interfaces: []*InterfaceConfigPatch{
+				{
+					Name:   "net0",
+					Values: &InterfaceConfig{},
+					Set:    []string{"currentHopLimit"},
+				},
+			},
+		}))
+		eventully(t, func() bool {
+			return (<-sock.txMulticastCh()).msg.CurrentHopLimit == 0
+		})
+	})
+}
+
+func TestDaemonValidationHook(t *testing.T) {
+	allowed := netip.MustParsePrefix("2001:db8::/32")
+	errDisallowed := errors.New("prefix is not allowed by the policy")
+
+	// Allows only the prefixes within 2001:db8::/32
+	hook := func(c *Config) error {
+		for _, iface := range c.Interfaces {
+			for _, prefix := range iface.Prefixes {
+				p := netip.MustParsePrefix(prefix.Prefix)
+				if p.Bits() < allowed.Bits() || !allowed.Contains(p.Addr()) {
+					return fmt.Errorf("%w: %s", errDisallowed, p)
+				}
+			}
+		}
+		return nil
+	}
+
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+				Prefixes: []*PrefixConfig{
+					{
+						Prefix:     "2001:db8::/64",
+						OnLink:     true,
+						Autonomous: true,
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("Ensure NewDaemon fails with the rejected configuration", func(t *testing.T) {
+		c := config.deepCopy()
+		c.Interfaces[0].Prefixes[0].Prefix = "2001:db9::/64"
+		_, err := NewDaemon(c, WithValidationHook(hook))
+		require.ErrorIs(t, err, ErrValidationHook)
+		require.ErrorIs(t, err, errDisallowed)
+	})
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		WithValidationHook(hook),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	prefixesOf := func(msg *ndp.RouterAdvertisement) []string {
+		prefixes := []string{}
+		for _, opt := range msg.Options {
+			if pi, ok := opt.(*ndp.PrefixInformation); ok {
+				prefixes = append(prefixes, netip.PrefixFrom(pi.Prefix, int(pi.PrefixLength)).String())
+			}
+		}
+		return prefixes
+	}
+
+	require.Equal(t, []string{"2001:db8::/64"}, prefixesOf((<-sock.txMulticastCh()).msg))
+
+	t.Run("Ensure Reload fails with the rejected configuration", func(t *testing.T) {
+		generation := d.Status().Generation
+
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].Prefixes[0].Prefix = "2001:db9::/64"
+		err := d.Reload(ctx, newConfig)
+		require.ErrorIs(t, err, ErrValidationHook)
+		require.ErrorIs(t, err, errDisallowed)
+		require.Equal(t, 1, d.ReloadMetrics().Failures[ReloadFailureValidate])
+
+		// The running configuration is unchanged
+		require.Equal(t, generation, d.Status().Generation)
+		d.configLock.Lock()
+		require.Equal(t, "2001:db8::/64", d.config.Interfaces[0].Prefixes[0].Prefix)
+		d.configLock.Unlock()
+		for i := 0; i < 3; i++ {
+			require.Equal(t, []string{"2001:db8::/64"}, prefixesOf((<-sock.txMulticastCh()).msg))
+		}
+	})
+
+	t.Run("Ensure Reload succeeds with the accepted configuration", func(t *testing.T) {
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].Prefixes[0].Prefix = "2001:db8:1::/64"
+		require.NoError(t, d.Reload(ctx, newConfig))
+
+		eventully(t, func() bool {
+			return slices.Equal([]string{"2001:db8:1::/64"}, prefixesOf((<-sock.txMulticastCh()).msg))
+		})
+	})
+}
+
+func TestDaemonRateLimiterState(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name: "net0",
+				// Set this to super long to avoid sending
+				// unsolicited RAs.
+				RAIntervalMilliseconds:         1800000,
+				MinDelayBetweenRAsMilliseconds: 1000,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	clk := newFakeClock()
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+		withClock(clk),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure unknown interface is rejected", func(t *testing.T) {
+		_, err := d.RateLimiterState("net1")
+		require.Error(t, err)
+	})
+
+	t.Run("Ensure the first RS is replied immediately", func(t *testing.T) {
+		state, err := d.RateLimiterState("net0")
+		require.NoError(t, err)
+		require.Equal(t, RLState{}, *state)
+
+		sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.IPv6Unspecified()}
+
+		select {
+		case <-sock.txMulticastCh():
+		case <-time.After(time.Millisecond * 300):
+			require.Fail(t, "timeout waiting for the reply")
+		}
+	})
+
+	t.Run("Ensure the RS within the delay is replied later", func(t *testing.T) {
+		var state *RLState
+		eventully(t, func() bool {
+			state, err = d.RateLimiterState("net0")
+			require.NoError(t, err)
+			return !state.LastMulticastRA.IsZero()
+		})
+		require.Equal(t, clk.Now(), state.LastMulticastRA)
+		require.Equal(t, state.LastMulticastRA.Add(time.Second), state.NextMulticastAllowed)
+		require.True(t, state.PendingReplyAt.IsZero())
+
+		// Multiple RSes share the delayed reply
+		for i := 0; i < 3; i++ {
+			sock.rxCh() <- fakeRS{msg: &ndp.RouterSolicitation{}, from: netip.IPv6Unspecified()}
+		}
+
+		eventully(t, func() bool {
+			state, err = d.RateLimiterState("net0")
+			require.NoError(t, err)
+			return !state.PendingReplyAt.IsZero()
+		})
+		require.Equal(t, state.NextMulticastAllowed, state.PendingReplyAt)
+
+		// Nothing is sent until the delay passes
+		clk.advance(time.Millisecond * 999)
+		select {
+		case <-sock.txMulticastCh():
+			require.Fail(t, "RS is replied before the delay")
+		case <-time.After(time.Millisecond * 300):
+		}
+
+		clk.advance(time.Millisecond)
+		select {
+		case <-sock.txMulticastCh():
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for the delayed reply")
+		}
+
+		eventully(t, func() bool {
+			state, err = d.RateLimiterState("net0")
+			require.NoError(t, err)
+			return state.PendingReplyAt.IsZero()
+		})
+		require.Equal(t, clk.Now(), state.LastMulticastRA)
+		eventully(t, func() bool {
+			return d.Status().Interfaces[0].TxSolicitedRA == 2
+		})
+
+		// No more replies for the rest of the RSes
+		select {
+		case <-sock.txMulticastCh():
+			require.Fail(t, "RSes within the delay are replied multiple times")
+		case <-time.After(time.Millisecond * 300):
+		}
+	})
+}
+
+func TestDaemonNonLinkLocalSource(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                    "net0",
+				RAIntervalMilliseconds:  100,
+				SourceAddress:           "2001:db8::1",
+				AllowNonLinkLocalSource: true,
+			},
+		},
+	}
+
+	logs := &logBuffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		WithLogger(logger),
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	t.Run("Ensure the RA is sent from the global address", func(t *testing.T) {
+		ra := <-sock.txMulticastCh()
+		require.Equal(t, netip.MustParseAddr("2001:db8::1"), ra.from)
+	})
+
+	t.Run("Ensure the warning is logged", func(t *testing.T) {
+		var configWarned, socketWarned bool
+		for _, r := range logs.records(t) {
+			if r["level"] != "WARN" {
+				continue
+			}
+			if r["msg"] == "Configuration warning" && r["field"] == "SourceAddress" {
+				configWarned = true
+			}
+			if r["source"] == "2001:db8::1" {
+				socketWarned = true
+			}
+		}
+		require.True(t, configWarned)
+		require.True(t, socketWarned)
+	})
+
+	t.Run("Ensure the reload back to the link-local source recreates the socket", func(t *testing.T) {
+		newConfig := config.deepCopy()
+		newConfig.Interfaces[0].SourceAddress = ""
+		newConfig.Interfaces[0].AllowNonLinkLocalSource = false
+		require.NoError(t, d.Reload(ctx, newConfig))
+
+		eventully(t, func() bool {
+			return sock.isClosed()
+		})
+
+		eventully(t, func() bool {
+			sock, err = reg.getSock("net0")
+			return err == nil && !sock.isClosed()
+		})
+
+		ra := <-sock.txMulticastCh()
+		require.False(t, ra.from.IsValid())
+	})
+}
@@ -2,19 +2,89 @@ package ra
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
 )
 
 type deviceState struct {
 	isUp             bool
 	v6LLAddrAssigned bool
 	addr             net.HardwareAddr
+
+	// The IPv6 link-local address assigned to the device. Valid only
+	// when v6LLAddrAssigned is true.
+	v6LLAddr netip.Addr
+
+	// The IPv6 global unicast addresses assigned to the device in
+	// ascending order
+	v6GlobalAddrs []netip.Addr
+
+	// Point-to-point devices (e.g. PPP or tunnel) don't have the
+	// link-layer address to advertise
+	isPointToPoint bool
+
+	// MTU of the device
+	mtu int
+}
+
+// InterfaceInfo is the information of the network interface discovered on
+// the system. It is passed to the InterfaceSelector.
+type InterfaceInfo struct {
+	// Interface name
+	Name string
+
+	// Labels attached to the interface. Currently, "kind" (e.g. "veth")
+	// and "alias" (the interface alias, if set) are populated.
+	Labels map[string]string
+
+	// Whether the interface is administratively up
+	Up bool
+
+	// Whether the interface is a loopback interface
+	Loopback bool
+
+	// MAC address of the interface. Empty if the interface doesn't have
+	// one.
+	HardwareAddr net.HardwareAddr
+}
+
+// An internal structure to represent the appearance or disappearance of the
+// device
+type deviceEvent struct {
+	info    InterfaceInfo
+	deleted bool
+}
+
+// deviceOpts is the options to identify the device to watch
+type deviceOpts struct {
+	// Index of the device. Zero means identifying the device by name.
+	index int
+
+	// Path to the network namespace the device belongs to. Empty means
+	// the current network namespace.
+	netnsPath string
 }
 
 type deviceWatcher interface {
-	watch(ctx context.Context, name string) (<-chan deviceState, error)
+	// watch watches the state of the device with the given name. If the
+	// index is non-zero, the device is identified by the index instead.
+	// The error channel receives the error when the watcher fails at
+	// runtime (e.g. the netlink socket dies). No state is sent after that,
+	// so the caller must watch again to recover (see watchWithRestart).
+	watch(ctx context.Context, name string, opts deviceOpts) (<-chan deviceState, <-chan error, error)
+
+	// watchAll watches the appearance and disappearance of all devices.
+	// The existing devices are notified first. The error channel works in
+	// the same way as watch (see watchAllWithRestart).
+	watchAll(ctx context.Context) (<-chan deviceEvent, <-chan error, error)
 }
 
 type netlinkDeviceWatcher struct{}
@@ -25,53 +95,165 @@ func newDeviceWatcher() deviceWatcher {
 	return &netlinkDeviceWatcher{}
 }
 
-func (w *netlinkDeviceWatcher) watch(ctx context.Context, name string) (<-chan deviceState, error) {
+// subscriptionError keeps the last error of the netlink subscriptions. The
+// subscriptions close the channel on the fatal error. The error callback is
+// also called on the non-fatal ones (e.g. the message which cannot be
+// parsed), so the last one is reported with the closure.
+type subscriptionError struct {
+	err  error
+	lock sync.Mutex
+}
+
+func (e *subscriptionError) set(err error) {
+	e.lock.Lock()
+	e.err = err
+	e.lock.Unlock()
+}
+
+// closed returns the error reported when the subscription is closed
+func (e *subscriptionError) closed(what string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.err != nil {
+		return fmt.Errorf("%s subscription is closed: %w", what, e.err)
+	}
+	return fmt.Errorf("%s subscription is closed", what)
+}
+
+func (w *netlinkDeviceWatcher) watch(ctx context.Context, name string, opts deviceOpts) (<-chan deviceState, <-chan error, error) {
 	linkCh := make(chan netlink.LinkUpdate)
 	addrCh := make(chan netlink.AddrUpdate)
 
+	subErr := &subscriptionError{}
+
+	// Resolves the device name from the index within the namespace
+	linkName := func(index int) (string, error) {
+		iface, err := net.InterfaceByIndex(index)
+		if err != nil {
+			return "", err
+		}
+		return iface.Name, nil
+	}
+
+	var ns *netns.NsHandle
+	if opts.netnsPath != "" {
+		h, err := netns.GetFromPath(opts.netnsPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot open netns %s: %w", opts.netnsPath, err)
+		}
+		ns = &h
+
+		nlh, err := netlink.NewHandleAt(h)
+		if err != nil {
+			h.Close()
+			return nil, nil, err
+		}
+
+		linkName = func(index int) (string, error) {
+			link, err := nlh.LinkByIndex(index)
+			if err != nil {
+				return "", err
+			}
+			return link.Attrs().Name, nil
+		}
+
+		go func() {
+			<-ctx.Done()
+			nlh.Close()
+			h.Close()
+		}()
+	}
+
 	if err := netlink.LinkSubscribeWithOptions(
 		linkCh,
 		ctx.Done(),
 		netlink.LinkSubscribeOptions{
-			ErrorCallback: func(err error) {},
+			Namespace:     ns,
+			ErrorCallback: subErr.set,
 			ListExisting:  true,
 		},
 	); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if err := netlink.AddrSubscribeWithOptions(
 		addrCh,
 		ctx.Done(),
 		netlink.AddrSubscribeOptions{
-			ErrorCallback: func(err error) {},
+			Namespace:     ns,
+			ErrorCallback: subErr.set,
 			ListExisting:  true,
 		},
 	); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	index := opts.index
+
 	devCh := make(chan deviceState)
+	errCh := make(chan error, 1)
 
 	go func() {
 		currentState := deviceState{}
+		globalAddrs := map[netip.Addr]bool{}
+
+		send := func() bool {
+			select {
+			case devCh <- currentState:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case link := <-linkCh:
-				if link.Attrs().Name != name {
+			case link, ok := <-linkCh:
+				if !ok {
+					if ctx.Err() == nil {
+						errCh <- subErr.closed("link")
+					}
+					return
+				}
+				if !linkMatches(link, name, index) {
 					continue
 				}
 				currentState.isUp = link.Flags&uint32(net.FlagUp) != 0
+				currentState.isPointToPoint = link.Flags&uint32(net.FlagPointToPoint) != 0
 				currentState.addr = link.Attrs().HardwareAddr
-				devCh <- currentState
-			case addr := <-addrCh:
-				iface, err := net.InterfaceByIndex(addr.LinkIndex)
-				if err != nil {
+				currentState.mtu = link.Attrs().MTU
+				if !send() {
+					return
+				}
+			case addr, ok := <-addrCh:
+				if !ok {
+					if ctx.Err() == nil {
+						errCh <- subErr.closed("address")
+					}
+					return
+				}
+				if index > 0 && addr.LinkIndex != index {
 					continue
 				}
-				if iface.Name != name {
+				if index == 0 {
+					n, err := linkName(addr.LinkIndex)
+					if err != nil || n != name {
+						continue
+					}
+				}
+				if a, ok := netip.AddrFromSlice(addr.LinkAddress.IP); ok && a.Is6() && a.IsGlobalUnicast() {
+					if addr.NewAddr {
+						globalAddrs[a] = true
+					} else {
+						delete(globalAddrs, a)
+					}
+					// Don't share the slice with the receiver
+					currentState.v6GlobalAddrs = sortedAddrs(globalAddrs)
+					if !send() {
+						return
+					}
 					continue
 				}
 				if !addr.LinkAddress.IP.IsLinkLocalUnicast() {
@@ -79,13 +261,216 @@ func (w *netlinkDeviceWatcher) watch(ctx context.Context, name string) (<-chan d
 				}
 				if addr.NewAddr {
 					currentState.v6LLAddrAssigned = true
+					currentState.v6LLAddr, _ = netip.AddrFromSlice(addr.LinkAddress.IP)
 				} else {
 					currentState.v6LLAddrAssigned = false
+					currentState.v6LLAddr = netip.Addr{}
+				}
+				if !send() {
+					return
+				}
+			}
+		}
+	}()
+
+	return devCh, errCh, nil
+}
+
+// Initial and maximum backoff of the device watcher restart. The backoff is
+// doubled on each failed restart.
+const (
+	deviceWatchRestartBackoff    = 100 * time.Millisecond
+	deviceWatchRestartMaxBackoff = 30 * time.Second
+)
+
+// watchWithRestart is same as deviceWatcher.watch, but restarts the watcher
+// with the backoff when it fails at runtime. The error of the initial watch is
+// returned as is. onError is called with the error when the watcher fails
+// (including the failed restarts) and with nil when the watcher is restarted.
+// The returned channel is never closed.
+func watchWithRestart(ctx context.Context, w deviceWatcher, name string, opts deviceOpts, onError func(error)) (<-chan deviceState, error) {
+	return restartWatch(ctx, func(ctx context.Context) (<-chan deviceState, <-chan error, error) {
+		return w.watch(ctx, name, opts)
+	}, onError)
+}
+
+// watchAllWithRestart is same as watchWithRestart, but for
+// deviceWatcher.watchAll. The restarted watcher notifies the existing devices
+// again, but the devices deleted while the watcher was down are not notified.
+func watchAllWithRestart(ctx context.Context, w deviceWatcher, onError func(error)) (<-chan deviceEvent, error) {
+	return restartWatch(ctx, w.watchAll, onError)
+}
+
+// restartWatch implements watchWithRestart and watchAllWithRestart. start
+// starts the watcher which is stopped by cancelling the context passed to it.
+func restartWatch[T any](ctx context.Context, start func(context.Context) (<-chan T, <-chan error, error), onError func(error)) (<-chan T, error) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	inCh, errCh, err := start(watchCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	outCh := make(chan T)
+
+	go func() {
+		for {
+			// Forward the values until the watcher fails
+			var err error
+		forward:
+			for {
+				select {
+				case <-ctx.Done():
+					cancel()
+					return
+				case v, ok := <-inCh:
+					if !ok {
+						// Prefer the error sent before the
+						// closure
+						select {
+						case err = <-errCh:
+						default:
+							err = fmt.Errorf("device watcher stopped unexpectedly")
+						}
+						break forward
+					}
+					select {
+					case outCh <- v:
+					case <-ctx.Done():
+						cancel()
+						return
+					}
+				case err = <-errCh:
+					break forward
+				}
+			}
+
+			// Clean up whatever left in the failed watcher
+			cancel()
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			onError(err)
+
+			for backoff := deviceWatchRestartBackoff; ; backoff = min(backoff*2, deviceWatchRestartMaxBackoff) {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+
+				watchCtx, cancel = context.WithCancel(ctx)
+				inCh, errCh, err = start(watchCtx)
+				if err == nil {
+					break
+				}
+				cancel()
+				onError(err)
+			}
+
+			onError(nil)
+		}
+	}()
+
+	return outCh, nil
+}
+
+// sortedAddrs returns the addresses in the set in ascending order
+func sortedAddrs(set map[netip.Addr]bool) []netip.Addr {
+	addrs := []netip.Addr{}
+	for a := range set {
+		addrs = append(addrs, a)
+	}
+	slices.SortFunc(addrs, netip.Addr.Compare)
+	return addrs
+}
+
+// linkMatches returns true if the link update is about the device itself. For
+// the bridge, the SLLA must be the MAC address of the bridge device, which
+// may change as the members join or leave. The kernel notifies it as an
+// update of the bridge device. The AF_BRIDGE updates are about the bridge
+// ports and carry the address of the port, so they are ignored.
+func linkMatches(link netlink.LinkUpdate, name string, index int) bool {
+	if link.Family == unix.AF_BRIDGE {
+		return false
+	}
+	if index > 0 {
+		return link.Attrs().Index == index
+	}
+	return link.Attrs().Name == name
+}
+
+func (w *netlinkDeviceWatcher) watchAll(ctx context.Context) (<-chan deviceEvent, <-chan error, error) {
+	linkCh := make(chan netlink.LinkUpdate)
+	subErr := &subscriptionError{}
+
+	if err := netlink.LinkSubscribeWithOptions(
+		linkCh,
+		ctx.Done(),
+		netlink.LinkSubscribeOptions{
+			ErrorCallback: subErr.set,
+			ListExisting:  true,
+		},
+	); err != nil {
+		return nil, nil, err
+	}
+
+	evCh, errCh := linkEvents(ctx, linkCh, subErr)
+	return evCh, errCh, nil
+}
+
+// linkEvents converts the link updates to the device events. The event
+// channel is closed when the link subscription is closed. The error of the
+// subscription is sent to the error channel before that.
+func linkEvents(ctx context.Context, linkCh <-chan netlink.LinkUpdate, subErr *subscriptionError) (<-chan deviceEvent, <-chan error) {
+	evCh := make(chan deviceEvent)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(evCh)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case link, ok := <-linkCh:
+				// The subscription closes the channel when
+				// the receive fails
+				if !ok {
+					if ctx.Err() == nil {
+						errCh <- subErr.closed("link")
+					}
+					return
+				}
+				// The AF_BRIDGE updates are about the bridge
+				// port. RTM_DELLINK of them means leaving the
+				// bridge, not the deletion of the device.
+				if link.Family == unix.AF_BRIDGE {
+					continue
+				}
+				labels := map[string]string{"kind": link.Type()}
+				if alias := link.Attrs().Alias; alias != "" {
+					labels["alias"] = alias
+				}
+				ev := deviceEvent{
+					info: InterfaceInfo{
+						Name:         link.Attrs().Name,
+						Labels:       labels,
+						Up:           link.Attrs().Flags&net.FlagUp != 0,
+						Loopback:     link.Attrs().Flags&net.FlagLoopback != 0,
+						HardwareAddr: link.Attrs().HardwareAddr,
+					},
+					deleted: link.Header.Type == unix.RTM_DELLINK,
+				}
+				select {
+				case evCh <- ev:
+				case <-ctx.Done():
+					return
 				}
-				devCh <- currentState
 			}
 		}
 	}()
 
-	return devCh, nil
+	return evCh, errCh
 }
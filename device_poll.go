@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+// deviceReader reads the current state of the devices. It is a backend of
+// the pollingDeviceWatcher.
+type deviceReader interface {
+	// readState reads the current state of the device with the given
+	// name. If the index is non-zero, the device is identified by the
+	// index instead.
+	readState(name string, opts deviceOpts) (deviceState, error)
+
+	// list lists all devices on the system
+	list() ([]InterfaceInfo, error)
+}
+
+// pollingDeviceWatcher is a deviceWatcher which periodically reads the
+// device state and notifies the changes. It is useful in the environment
+// where the netlink events are not available.
+type pollingDeviceWatcher struct {
+	interval time.Duration
+	reader   deviceReader
+}
+
+var _ deviceWatcher = &pollingDeviceWatcher{}
+
+func newPollingDeviceWatcher(interval time.Duration, reader deviceReader) *pollingDeviceWatcher {
+	return &pollingDeviceWatcher{
+		interval: interval,
+		reader:   reader,
+	}
+}
+
+func (w *pollingDeviceWatcher) watch(ctx context.Context, name string, opts deviceOpts) (<-chan deviceState, <-chan error, error) {
+	devCh := make(chan deviceState)
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		var lastState *deviceState
+		for {
+			// Treat the missing device as down
+			state, err := w.reader.readState(name, opts)
+			if err != nil {
+				state = deviceState{}
+			}
+
+			if lastState == nil || !state.equal(lastState) {
+				select {
+				case devCh <- state:
+				case <-ctx.Done():
+					return
+				}
+				lastState = &state
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	// Reading the state never fails as the watcher. The error is
+	// treated as the missing device.
+	return devCh, nil, nil
+}
+
+func (w *pollingDeviceWatcher) watchAll(ctx context.Context) (<-chan deviceEvent, <-chan error, error) {
+	evCh := make(chan deviceEvent)
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		known := map[string]InterfaceInfo{}
+		for {
+			// Skip this round on error. The devices we know are
+			// not deleted.
+			infos, err := w.reader.list()
+			if err == nil {
+				events := []deviceEvent{}
+
+				current := map[string]InterfaceInfo{}
+				for _, info := range infos {
+					current[info.Name] = info
+					if old, ok := known[info.Name]; !ok || !maps.Equal(old.Labels, info.Labels) || old.Up != info.Up || old.Loopback != info.Loopback || !bytes.Equal(old.HardwareAddr, info.HardwareAddr) {
+						events = append(events, deviceEvent{info: info})
+					}
+				}
+				for name, info := range known {
+					if _, ok := current[name]; !ok {
+						events = append(events, deviceEvent{info: info, deleted: true})
+					}
+				}
+				known = current
+
+				for _, ev := range events {
+					select {
+					case evCh <- ev:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	// Same as watch. Listing never fails as the watcher.
+	return evCh, nil, nil
+}
+
+func (s *deviceState) equal(other *deviceState) bool {
+	return s.isUp == other.isUp &&
+		s.v6LLAddrAssigned == other.v6LLAddrAssigned &&
+		s.isPointToPoint == other.isPointToPoint &&
+		s.v6LLAddr == other.v6LLAddr &&
+		slices.Equal(s.v6GlobalAddrs, other.v6GlobalAddrs) &&
+		slices.Equal(s.addr, other.addr) &&
+		s.mtu == other.mtu
+}
+
+// netDeviceReader is a deviceReader based on the net package
+type netDeviceReader struct{}
+
+var _ deviceReader = &netDeviceReader{}
+
+func (r *netDeviceReader) readState(name string, opts deviceOpts) (deviceState, error) {
+	var (
+		iface *net.Interface
+		addrs []net.Addr
+	)
+	if err := runInNetns(opts.netnsPath, func() error {
+		var err error
+		if opts.index > 0 {
+			iface, err = net.InterfaceByIndex(opts.index)
+		} else {
+			iface, err = net.InterfaceByName(name)
+		}
+		if err != nil {
+			return err
+		}
+		addrs, err = iface.Addrs()
+		return err
+	}); err != nil {
+		return deviceState{}, err
+	}
+
+	state := deviceState{
+		isUp:           iface.Flags&net.FlagUp != 0,
+		isPointToPoint: iface.Flags&net.FlagPointToPoint != 0,
+		addr:           iface.HardwareAddr,
+		mtu:            iface.MTU,
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			continue
+		}
+		a, _ := netip.AddrFromSlice(ipNet.IP)
+		if a.IsGlobalUnicast() {
+			state.v6GlobalAddrs = append(state.v6GlobalAddrs, a)
+		} else if a.IsLinkLocalUnicast() && !state.v6LLAddrAssigned {
+			state.v6LLAddrAssigned = true
+			state.v6LLAddr = a
+		}
+	}
+
+	slices.SortFunc(state.v6GlobalAddrs, netip.Addr.Compare)
+
+	return state, nil
+}
+
+// list lists the devices. Since the net package doesn't expose the device
+// kind and alias, the labels are always empty.
+func (r *netDeviceReader) list() ([]InterfaceInfo, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []InterfaceInfo{}
+	for _, iface := range ifaces {
+		infos = append(infos, InterfaceInfo{
+			Name:         iface.Name,
+			Labels:       map[string]string{},
+			Up:           iface.Flags&net.FlagUp != 0,
+			Loopback:     iface.Flags&net.FlagLoopback != 0,
+			HardwareAddr: iface.HardwareAddr,
+		})
+	}
+
+	return infos, nil
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestLinkMatches(t *testing.T) {
+	linkUpdate := func(family uint8, name string, index int) netlink.LinkUpdate {
+		u := netlink.LinkUpdate{
+			Link: &netlink.Bridge{
+				LinkAttrs: netlink.LinkAttrs{Name: name, Index: index},
+			},
+		}
+		u.Family = family
+		return u
+	}
+
+	tests := []struct {
+		name    string
+		update  netlink.LinkUpdate
+		matches bool
+	}{
+		{
+			name:    "Bridge device",
+			update:  linkUpdate(unix.AF_UNSPEC, "br0", 10),
+			matches: true,
+		},
+		{
+			name:    "Bridge member",
+			update:  linkUpdate(unix.AF_UNSPEC, "veth0", 11),
+			matches: false,
+		},
+		{
+			name:    "Bridge port notification of the bridge",
+			update:  linkUpdate(unix.AF_BRIDGE, "br0", 10),
+			matches: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.matches, linkMatches(tt.update, "br0", 0), "by name")
+			require.Equal(t, tt.matches, linkMatches(tt.update, "", 10), "by index")
+		})
+	}
+}
+
+func TestLinkEventsClosed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	linkCh := make(chan netlink.LinkUpdate)
+	evCh, errCh := linkEvents(ctx, linkCh, &subscriptionError{})
+
+	linkCh <- netlink.LinkUpdate{
+		Link: &netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Name: "veth0", Index: 10},
+		},
+	}
+	ev := <-evCh
+	require.Equal(t, "veth0", ev.info.Name)
+
+	// The subscription closes the channel on the receive failure. It
+	// must report the error and close the event channel instead of
+	// sending the zero update.
+	close(linkCh)
+	require.ErrorContains(t, <-errCh, "link subscription is closed")
+	_, ok := <-evCh
+	require.False(t, ok)
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"sync"
+	"time"
+)
+
+type fakeClock struct {
+	now     time.Time
+	waiters []*fakeWaiter
+	nowLock sync.Mutex
+}
+
+// fakeWaiter is a channel returned by After waiting for the deadline
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+var _ clock = &fakeClock{}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.nowLock.Lock()
+	defer c.nowLock.Unlock()
+	return c.now
+}
+
+// After fires when the clock is advanced beyond the duration
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.nowLock.Lock()
+	defer c.nowLock.Unlock()
+	w := &fakeWaiter{deadline: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.ch <- c.now
+		return w.ch
+	}
+	c.waiters = append(c.waiters, w)
+	return w.ch
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.nowLock.Lock()
+	defer c.nowLock.Unlock()
+	c.now = c.now.Add(d)
+
+	waiters := []*fakeWaiter{}
+	for _, w := range c.waiters {
+		if c.now.Before(w.deadline) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
@@ -1,25 +1,65 @@
 package ra
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"sync"
+)
 
 type fakeDeviceWatcher struct {
 	watchers map[string]chan deviceState
+	events   chan deviceEvent
+
+	// Interface index => name mapping
+	indexes map[int]string
+
+	// Errors to fail the running watcher of the device with
+	failures map[string]chan error
+
+	// Errors to fail the running watcher of all devices with
+	allFailures chan error
+
+	// Errors returned by the next watch of the device
+	watchErrs     map[string]error
+	watchErrsLock sync.Mutex
 }
 
 var _ deviceWatcher = &fakeDeviceWatcher{}
 
 func newFakeDeviceWatcher(devs ...string) *fakeDeviceWatcher {
 	fdw := &fakeDeviceWatcher{
-		watchers: make(map[string]chan deviceState),
+		watchers:    make(map[string]chan deviceState),
+		events:      make(chan deviceEvent, 16),
+		indexes:     make(map[int]string),
+		failures:    make(map[string]chan error),
+		allFailures: make(chan error, 1),
+		watchErrs:   make(map[string]error),
 	}
 	for _, dev := range devs {
 		fdw.watchers[dev] = make(chan deviceState, 1)
+		fdw.failures[dev] = make(chan error, 1)
 	}
 	return fdw
 }
 
-func (w *fakeDeviceWatcher) watch(ctx context.Context, name string) (<-chan deviceState, error) {
+func (w *fakeDeviceWatcher) watch(ctx context.Context, name string, opts deviceOpts) (<-chan deviceState, <-chan error, error) {
+	if opts.index > 0 {
+		var ok bool
+		if name, ok = w.indexes[opts.index]; !ok {
+			return nil, nil, fmt.Errorf("device with index %d not found", opts.index)
+		}
+	}
+
+	w.watchErrsLock.Lock()
+	err := w.watchErrs[name]
+	delete(w.watchErrs, name)
+	w.watchErrsLock.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	devCh := make(chan deviceState)
+	errCh := make(chan error, 1)
 
 	go func() {
 		defer close(devCh)
@@ -27,15 +67,138 @@ func (w *fakeDeviceWatcher) watch(ctx context.Context, name string) (<-chan devi
 			select {
 			case <-ctx.Done():
 				return
+			case err := <-w.failures[name]:
+				errCh <- err
+				return
 			case dev := <-w.watchers[name]:
-				devCh <- dev
+				select {
+				case devCh <- dev:
+				case <-ctx.Done():
+					// Leave the state to the next watcher
+					select {
+					case w.watchers[name] <- dev:
+					default:
+					}
+					return
+				}
 			}
 		}
 	}()
 
-	return devCh, nil
+	return devCh, errCh, nil
+}
+
+// fail fails the running watcher of the device with the error. If
+// watchErr is not nil, the next watch of the device returns it.
+func (w *fakeDeviceWatcher) fail(name string, err, watchErr error) {
+	if watchErr != nil {
+		w.watchErrsLock.Lock()
+		w.watchErrs[name] = watchErr
+		w.watchErrsLock.Unlock()
+	}
+	w.failures[name] <- err
+}
+
+// setIndex assigns the index to the device. Must be called before watching.
+func (w *fakeDeviceWatcher) setIndex(name string, index int) {
+	w.indexes[index] = name
 }
 
 func (w *fakeDeviceWatcher) update(name string, dev deviceState) {
 	w.watchers[name] <- dev
 }
+
+func (w *fakeDeviceWatcher) watchAll(ctx context.Context) (<-chan deviceEvent, <-chan error, error) {
+	evCh := make(chan deviceEvent)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(evCh)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-w.allFailures:
+				errCh <- err
+				return
+			case ev := <-w.events:
+				select {
+				case evCh <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return evCh, errCh, nil
+}
+
+// failAll fails the running watcher of all devices with the error
+func (w *fakeDeviceWatcher) failAll(err error) {
+	w.allFailures <- err
+}
+
+func (w *fakeDeviceWatcher) add(info InterfaceInfo) {
+	w.events <- deviceEvent{info: info}
+}
+
+func (w *fakeDeviceWatcher) delete(name string) {
+	w.events <- deviceEvent{info: InterfaceInfo{Name: name}, deleted: true}
+}
+
+type fakeDeviceReader struct {
+	states     map[string]deviceState
+	statesLock sync.Mutex
+
+	// Notified on every readState
+	polledCh chan any
+}
+
+var _ deviceReader = &fakeDeviceReader{}
+
+func newFakeDeviceReader() *fakeDeviceReader {
+	return &fakeDeviceReader{
+		states:   map[string]deviceState{},
+		polledCh: make(chan any, 1),
+	}
+}
+
+func (r *fakeDeviceReader) readState(name string, _ deviceOpts) (deviceState, error) {
+	r.statesLock.Lock()
+	state, ok := r.states[name]
+	r.statesLock.Unlock()
+
+	select {
+	case r.polledCh <- struct{}{}:
+	default:
+	}
+
+	if !ok {
+		return deviceState{}, fmt.Errorf("device %s not found", name)
+	}
+
+	return state, nil
+}
+
+func (r *fakeDeviceReader) list() ([]InterfaceInfo, error) {
+	r.statesLock.Lock()
+	defer r.statesLock.Unlock()
+
+	infos := []InterfaceInfo{}
+	for name, state := range r.states {
+		infos = append(infos, InterfaceInfo{Name: name, Labels: map[string]string{}, Up: state.isUp, HardwareAddr: state.addr})
+	}
+
+	return infos, nil
+}
+
+func (r *fakeDeviceReader) set(name string, state deviceState) {
+	r.statesLock.Lock()
+	defer r.statesLock.Unlock()
+	r.states[name] = state
+}
+
+func (r *fakeDeviceReader) polled() <-chan any {
+	return r.polledCh
+}
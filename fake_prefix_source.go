@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// fakePrefixSource is the http.RoundTripper which serves the JSON list of
+// the prefixes for any request
+type fakePrefixSource struct {
+	prefixes     []string
+	prefixesLock sync.Mutex
+}
+
+var _ http.RoundTripper = &fakePrefixSource{}
+
+func newFakePrefixSource(prefixes ...string) *fakePrefixSource {
+	return &fakePrefixSource{prefixes: prefixes}
+}
+
+func (s *fakePrefixSource) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.prefixesLock.Lock()
+	b, err := json.Marshal(s.prefixes)
+	s.prefixesLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(b)),
+		Request:    req,
+	}, nil
+}
+
+func (s *fakePrefixSource) set(prefixes ...string) {
+	s.prefixesLock.Lock()
+	defer s.prefixesLock.Unlock()
+	s.prefixes = prefixes
+}
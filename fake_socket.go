@@ -26,11 +26,12 @@ func newFakeSockRegistry() *fakeSockRegistry {
 	}
 }
 
-func (r *fakeSockRegistry) newSock(iface string) (socket, error) {
+func (r *fakeSockRegistry) newSock(iface string, opts socketOpts) (socket, error) {
 	r.regLock.Lock()
 	defer r.regLock.Unlock()
 
-	if _, ok := r.reg[iface]; ok {
+	// Allow recreating the socket after closing the old one
+	if fs, ok := r.reg[iface]; ok && !fs.isClosed() {
 		return nil, fmt.Errorf("duplicate interface name")
 	}
 
@@ -38,6 +39,8 @@ func (r *fakeSockRegistry) newSock(iface string) (socket, error) {
 		txMulticast: make(chan fakeRA, 128),
 		txLLUnicast: make(chan fakeRA, 128),
 		rx:          make(chan fakeRS, 128),
+		rxRA:        make(chan fakeForeignRA, 128),
+		opts:        opts,
 	}
 	r.reg[iface] = fs
 
@@ -61,18 +64,41 @@ type fakeSock struct {
 	txMulticast chan fakeRA
 	txLLUnicast chan fakeRA
 	rx          chan fakeRS
+	rxRA        chan fakeForeignRA
 	closed      atomic.Bool
+
+	// Options passed to the constructor
+	opts socketOpts
+
+	// Errors to return from the subsequent sendRA calls in order
+	txErrs     []error
+	txErrsLock sync.Mutex
 }
 
 type fakeRA struct {
 	tstamp time.Time
 	msg    *ndp.RouterAdvertisement
 	to     netip.Addr
+
+	// Source address of the RA. Invalid means the link-local address
+	// of the interface.
+	from netip.Addr
+
+	// The marshaled RA when it's sent with sendRawRA
+	raw []byte
 }
 
 type fakeRS struct {
 	msg  *ndp.RouterSolicitation
 	from netip.Addr
+
+	// Pretend the RS cannot be parsed
+	malformed bool
+}
+
+type fakeForeignRA struct {
+	msg  *ndp.RouterAdvertisement
+	from netip.Addr
 }
 
 var _ socket = &fakeSock{}
@@ -89,12 +115,50 @@ func (s *fakeSock) rxCh() chan<- fakeRS {
 	return s.rx
 }
 
+func (s *fakeSock) rxRACh() chan<- fakeForeignRA {
+	return s.rxRA
+}
+
 func (s *fakeSock) hardwareAddr() net.HardwareAddr {
 	return net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
 }
 
+// injectTxErrors makes the subsequent sendRA calls fail with the errors in
+// order
+func (s *fakeSock) injectTxErrors(errs ...error) {
+	s.txErrsLock.Lock()
+	defer s.txErrsLock.Unlock()
+	s.txErrs = append(s.txErrs, errs...)
+}
+
 func (s *fakeSock) sendRA(_ context.Context, addr netip.Addr, msg *ndp.RouterAdvertisement) error {
-	ra := fakeRA{tstamp: time.Now(), msg: msg, to: addr}
+	return s.tx(fakeRA{tstamp: time.Now(), msg: msg, to: addr, from: s.opts.srcAddr})
+}
+
+func (s *fakeSock) sendRawRA(_ context.Context, addr netip.Addr, b []byte) error {
+	m, err := ndp.ParseMessage(b)
+	if err != nil {
+		return err
+	}
+	msg, ok := m.(*ndp.RouterAdvertisement)
+	if !ok {
+		return fmt.Errorf("not an RA")
+	}
+	return s.tx(fakeRA{tstamp: time.Now(), msg: msg, to: addr, from: s.opts.srcAddr, raw: b})
+}
+
+func (s *fakeSock) tx(ra fakeRA) error {
+	addr := ra.to
+
+	s.txErrsLock.Lock()
+	if len(s.txErrs) > 0 {
+		err := s.txErrs[0]
+		s.txErrs = s.txErrs[1:]
+		s.txErrsLock.Unlock()
+		return err
+	}
+	s.txErrsLock.Unlock()
+
 	if addr.IsMulticast() {
 		select {
 		case s.txMulticast <- ra:
@@ -114,18 +178,24 @@ func (s *fakeSock) sendRA(_ context.Context, addr netip.Addr, msg *ndp.RouterAdv
 	}
 }
 
-func (s *fakeSock) recvRS(ctx context.Context) (*ndp.RouterSolicitation, netip.Addr, error) {
+func (s *fakeSock) recv(ctx context.Context) (ndp.Message, netip.Addr, error) {
 	select {
 	case <-ctx.Done():
 		return nil, netip.Addr{}, ctx.Err()
 	case rs := <-s.rx:
+		if rs.malformed {
+			return nil, rs.from, errMalformedRS
+		}
 		return rs.msg, rs.from, nil
+	case ra := <-s.rxRA:
+		return ra.msg, ra.from, nil
 	}
 }
 
 func (s *fakeSock) close() {
 	close(s.txMulticast)
 	close(s.rx)
+	close(s.rxRA)
 	s.closed.Store(true)
 }
 
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type fakeSysctl struct {
+	values     map[string]string
+	valuesLock sync.Mutex
+}
+
+var _ sysctl = &fakeSysctl{}
+
+func newFakeSysctl() *fakeSysctl {
+	return &fakeSysctl{values: map[string]string{}}
+}
+
+func (s *fakeSysctl) get(_ string, key ...string) (string, error) {
+	s.valuesLock.Lock()
+	defer s.valuesLock.Unlock()
+	value, ok := s.values[strings.Join(key, "/")]
+	if !ok {
+		return "", fmt.Errorf("no such parameter %s", strings.Join(key, "/"))
+	}
+	return value, nil
+}
+
+func (s *fakeSysctl) set(_ string, value string, key ...string) error {
+	s.valuesLock.Lock()
+	defer s.valuesLock.Unlock()
+	s.values[strings.Join(key, "/")] = value
+	return nil
+}
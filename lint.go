@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Lint is an advisory finding of LintConfig. It has the same shape as the
+// Warning.
+type Lint = Warning
+
+// LintConfig returns the advisory findings of the configuration to help
+// spotting the common mistakes. The findings include the validation errors,
+// the warnings reported by the daemon, and the heuristics which are too noisy
+// to be the warnings (e.g. MTU smaller than 1500). The configuration passed
+// to this function is not modified.
+func LintConfig(config *Config) []Lint {
+	c := config.deepCopy()
+
+	warnings, err := c.defaultAndValidateWithWarnings()
+	if err != nil {
+		var verrs ValidationErrors
+		if !errors.As(err, &verrs) {
+			return []Lint{{Message: err.Error()}}
+		}
+
+		// The rest of the checks requires the valid configuration
+		lints := []Lint{}
+		for _, verr := range verrs {
+			lints = append(lints, Lint{
+				Field:   strings.TrimPrefix(verr.Namespace(), "Config."),
+				Message: fmt.Sprintf("failed on the %q validation", verr.Tag()),
+			})
+		}
+		return lints
+	}
+
+	lints := warnings
+	for _, iface := range c.Interfaces {
+		lints = append(lints, iface.lint()...)
+	}
+
+	return lints
+}
+
+func (c *InterfaceConfig) lint() []Lint {
+	lints := []Lint{}
+
+	add := func(field, format string, args ...any) {
+		lints = append(lints, Lint{
+			Interface: c.Name,
+			Field:     field,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	if c.MTU > 0 && c.MTU < 1500 {
+		add("MTU", "MTU %d is smaller than the common link MTU 1500, make sure it matches the link MTU", c.MTU)
+	}
+
+	// AutoULA generates the prefix on load
+	if len(c.RDNSSes) > 0 && len(c.Prefixes) == 0 && !c.AutoULA && !c.Managed {
+		add("RDNSSes", "RDNSS is advertised without any prefix, the hosts may not have an address to reach the resolvers")
+	}
+
+	for i, prefix := range c.Prefixes {
+		field := fmt.Sprintf("Prefixes[%d]", i)
+
+		if !prefix.OnLink && !prefix.Autonomous {
+			add(field, "neither OnLink nor Autonomous is set, the prefix has no effect on the hosts")
+		}
+
+		// SLAAC (RFC4862) forms the address from the prefix and the 64-bit
+		// interface identifier, including the stable-privacy one of
+		// RFC7217, on the Ethernet-like links (RFC2464). The hosts silently
+		// ignore the A flag of the prefix with the other length.
+		if p := prefix.advertisedPrefix(); prefix.Autonomous && p.Bits() != 64 {
+			add(field, "Autonomous is set on %s, but SLAAC (including RFC7217 stable-privacy addresses) requires a /64 prefix, so the hosts won't configure any address from it. Advertise a /64 (e.g. with SubnetID) or clear Autonomous.", p)
+		}
+	}
+
+	return lints
+}
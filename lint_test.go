@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestLintConfig(t *testing.T) {
+	t.Run("Ensure seeded mistakes produce the lint findings", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 10000,
+					RouterLifetimeSeconds:  5,
+					MTU:                    1400,
+					RDNSSes: []*RDNSSConfig{
+						{
+							LifetimeSeconds: 100,
+							Addresses:       []string{"2001:db8::1"},
+						},
+					},
+				},
+				{
+					Name:                   "net1",
+					RAIntervalMilliseconds: 1000,
+					Prefixes: []*PrefixConfig{
+						{
+							Prefix: "2001:db8::/64",
+						},
+						{
+							Prefix:     "2001:db8:1::/56",
+							OnLink:     true,
+							Autonomous: true,
+						},
+					},
+				},
+			},
+		}
+
+		lints := LintConfig(config)
+
+		fields := []string{}
+		for _, l := range lints {
+			fields = append(fields, l.Interface+" "+l.Field)
+		}
+		require.ElementsMatch(t, []string{
+			"net0 RouterLifetimeSeconds",
+			"net0 MTU",
+			"net0 RDNSSes",
+			"net1 Prefixes[0]",
+			"net1 Prefixes[1]",
+		}, fields)
+
+		// The configuration is not modified
+		require.Nil(t, config.Interfaces[0].Prefixes)
+	})
+
+	t.Run("Ensure validation error is reported as a lint", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					Prefixes: []*PrefixConfig{
+						{
+							Prefix:                   "2001:db8::/64",
+							OnLink:                   true,
+							ValidLifetimeSeconds:     ptr.To(100),
+							PreferredLifetimeSeconds: ptr.To(200),
+						},
+					},
+				},
+			},
+		}
+
+		lints := LintConfig(config)
+		require.Len(t, lints, 1)
+		require.Equal(t, "Interfaces[0].Prefixes[0].PreferredLifetimeSeconds", lints[0].Field)
+	})
+
+	t.Run("Ensure non-/64 autonomous prefix is explained", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					Prefixes: []*PrefixConfig{
+						{
+							Prefix:     "2001:db8:0:100::/56",
+							OnLink:     true,
+							Autonomous: true,
+						},
+					},
+				},
+			},
+		}
+
+		lints := LintConfig(config)
+		require.Len(t, lints, 1)
+		require.Equal(t, Lint{
+			Interface: "net0",
+			Field:     "Prefixes[0]",
+			Message:   "Autonomous is set on 2001:db8:0:100::/56, but SLAAC (including RFC7217 stable-privacy addresses) requires a /64 prefix, so the hosts won't configure any address from it. Advertise a /64 (e.g. with SubnetID) or clear Autonomous.",
+		}, lints[0])
+	})
+
+	t.Run("Ensure good configuration produces no lint", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					RouterLifetimeSeconds:  1800,
+					MTU:                    1500,
+					Prefixes: []*PrefixConfig{
+						{
+							Prefix:     "2001:db8::/64",
+							OnLink:     true,
+							Autonomous: true,
+						},
+					},
+					RDNSSes: []*RDNSSConfig{
+						{
+							LifetimeSeconds: 1800,
+							Addresses:       []string{"2001:db8::1"},
+						},
+					},
+				},
+			},
+		}
+
+		require.Empty(t, LintConfig(config))
+	})
+}
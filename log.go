@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// levelHandler is a slog.Handler that overrides the minimum level of the
+// wrapped handler. When the level is not set, it defers to the wrapped
+// handler.
+type levelHandler struct {
+	level   *atomic.Pointer[slog.Level]
+	handler slog.Handler
+}
+
+var _ slog.Handler = &levelHandler{}
+
+func newLevelHandler(h slog.Handler) *levelHandler {
+	return &levelHandler{
+		level:   &atomic.Pointer[slog.Level]{},
+		handler: h,
+	}
+}
+
+// setLevel sets the level from the string representation. Empty string
+// clears the override. The string must be validated beforehand.
+func (h *levelHandler) setLevel(s string) {
+	if s == "" {
+		h.level.Store(nil)
+		return
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		panic("BUG (Please report 🙏): Invalid log level: " + err.Error())
+	}
+	h.level.Store(&l)
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	if level := h.level.Load(); level != nil {
+		return l >= *level
+	}
+	return h.handler.Enabled(ctx, l)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, handler: h.handler.WithGroup(name)}
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Coarse reasons of the reload failure
+const (
+	// The configuration cannot be parsed
+	ReloadFailureParse = "parse"
+
+	// The configuration (or the patch) is invalid
+	ReloadFailureValidate = "validate"
+
+	// The valid configuration cannot be applied to the daemon (e.g. the
+	// context is cancelled)
+	ReloadFailureApply = "apply"
+)
+
+// Upper bounds of the reload duration histogram buckets in seconds. Same as
+// the default buckets of the Prometheus client.
+var reloadDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// ReloadMetrics is a snapshot of the metrics about the configuration reloads
+// done through Reload, ReloadYAML, ReloadJSON, and Patch
+type ReloadMetrics struct {
+	// Total number of the reloads including the failed ones
+	Total int
+
+	// Number of the failed reloads by the reason (e.g. ReloadFailureParse)
+	Failures map[string]int
+
+	// Upper bounds of the duration histogram buckets in seconds
+	DurationBuckets []float64
+
+	// Cumulative number of the reloads which took less than or equal to
+	// the corresponding DurationBuckets
+	DurationCounts []int
+
+	// Total duration of the reloads in seconds
+	DurationSum float64
+}
+
+// reloadMetrics records the metrics about the reloads. Safe for concurrent
+// use.
+type reloadMetrics struct {
+	lock           sync.Mutex
+	total          int
+	failures       map[string]int
+	durationCounts []int
+	durationSum    float64
+}
+
+func newReloadMetrics() *reloadMetrics {
+	return &reloadMetrics{
+		failures: map[string]int{
+			ReloadFailureParse:    0,
+			ReloadFailureValidate: 0,
+			ReloadFailureApply:    0,
+		},
+		durationCounts: make([]int, len(reloadDurationBuckets)),
+	}
+}
+
+// observe records the reload started at the start. Empty reason means
+// success.
+func (m *reloadMetrics) observe(start time.Time, reason string) {
+	seconds := time.Since(start).Seconds()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.total++
+	if reason != "" {
+		m.failures[reason]++
+	}
+	for i, le := range reloadDurationBuckets {
+		if seconds <= le {
+			m.durationCounts[i]++
+		}
+	}
+	m.durationSum += seconds
+}
+
+func (m *reloadMetrics) snapshot() ReloadMetrics {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return ReloadMetrics{
+		Total:           m.total,
+		Failures:        maps.Clone(m.failures),
+		DurationBuckets: slices.Clone(reloadDurationBuckets),
+		DurationCounts:  slices.Clone(m.durationCounts),
+		DurationSum:     m.durationSum,
+	}
+}
+
+// reloadFailureReason classifies the error returned from the reload
+func reloadFailureReason(err error) string {
+	var verrs ValidationErrors
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &verrs), errors.Is(err, ErrSelfTest), errors.Is(err, ErrInvalidPatch), errors.Is(err, ErrValidationHook):
+		return ReloadFailureValidate
+	default:
+		return ReloadFailureApply
+	}
+}
+
+// ReloadMetrics returns the snapshot of the metrics about the reloads
+func (d *Daemon) ReloadMetrics() ReloadMetrics {
+	return d.reloadMetrics.snapshot()
+}
+
+// WriteMetrics writes the metrics in the Prometheus text exposition format.
+// The metrics are go_ra_reload_total, go_ra_reload_failures_total (labeled by
+// the reason), go_ra_reload_duration_seconds (histogram), go_ra_preempted and
+// go_ra_rs_silent (labeled by the interface, 1 when InterfaceStatus.Preempted
+// and InterfaceStatus.RSSilent are set respectively), and
+// go_ra_prefix_changes_total (labeled by the interface, the counter of
+// InterfaceStatus.PrefixChanges).
+func (d *Daemon) WriteMetrics(w io.Writer) error {
+	m := d.ReloadMetrics()
+
+	lines := []string{
+		"# HELP go_ra_reload_total Total number of the configuration reloads.",
+		"# TYPE go_ra_reload_total counter",
+		fmt.Sprintf("go_ra_reload_total %d", m.Total),
+		"# HELP go_ra_reload_failures_total Total number of the failed configuration reloads.",
+		"# TYPE go_ra_reload_failures_total counter",
+	}
+
+	reasons := []string{}
+	for reason := range m.Failures {
+		reasons = append(reasons, reason)
+	}
+	slices.Sort(reasons)
+
+	for _, reason := range reasons {
+		lines = append(lines, fmt.Sprintf("go_ra_reload_failures_total{reason=%q} %d", reason, m.Failures[reason]))
+	}
+
+	lines = append(lines,
+		"# HELP go_ra_reload_duration_seconds Duration of the configuration reloads.",
+		"# TYPE go_ra_reload_duration_seconds histogram",
+	)
+
+	for i, le := range m.DurationBuckets {
+		lines = append(lines, fmt.Sprintf("go_ra_reload_duration_seconds_bucket{le=%q} %d", strconv.FormatFloat(le, 'g', -1, 64), m.DurationCounts[i]))
+	}
+
+	lines = append(lines,
+		fmt.Sprintf("go_ra_reload_duration_seconds_bucket{le=\"+Inf\"} %d", m.Total),
+		fmt.Sprintf("go_ra_reload_duration_seconds_sum %s", strconv.FormatFloat(m.DurationSum, 'g', -1, 64)),
+		fmt.Sprintf("go_ra_reload_duration_seconds_count %d", m.Total),
+		"# HELP go_ra_preempted Whether another router advertises a higher router preference on the interface.",
+		"# TYPE go_ra_preempted gauge",
+	)
+
+	ifaces := d.Status().Interfaces
+
+	for _, iface := range ifaces {
+		lines = append(lines, fmt.Sprintf("go_ra_preempted{interface=%q} %d", iface.Name, boolToInt(iface.Preempted)))
+	}
+
+	lines = append(lines,
+		"# HELP go_ra_rs_silent Whether no RS is received within the configured window on the interface.",
+		"# TYPE go_ra_rs_silent gauge",
+	)
+
+	for _, iface := range ifaces {
+		lines = append(lines, fmt.Sprintf("go_ra_rs_silent{interface=%q} %d", iface.Name, boolToInt(iface.RSSilent)))
+	}
+
+	lines = append(lines,
+		"# HELP go_ra_prefix_changes_total Total number of the changes of the advertised prefixes on the interface.",
+		"# TYPE go_ra_prefix_changes_total counter",
+	)
+
+	for _, iface := range ifaces {
+		lines = append(lines, fmt.Sprintf("go_ra_prefix_changes_total{interface=%q} %d", iface.Name, iface.PrefixChanges))
+	}
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadMetrics(t *testing.T) {
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name:                   "net0",
+				RAIntervalMilliseconds: 100,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(newFakeDeviceWatcher("net0")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	reload := func(c *Config) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		return d.Reload(ctx, c)
+	}
+
+	t.Run("Ensure the successful reload is counted", func(t *testing.T) {
+		require.NoError(t, reload(config))
+
+		m := d.ReloadMetrics()
+		require.Equal(t, 1, m.Total)
+		require.Equal(t, map[string]int{"parse": 0, "validate": 0, "apply": 0}, m.Failures)
+		require.Equal(t, 1, m.DurationCounts[len(m.DurationCounts)-1])
+	})
+
+	t.Run("Ensure the failed reloads are counted by the reason", func(t *testing.T) {
+		invalid := config.deepCopy()
+		invalid.Interfaces[0].RAIntervalMilliseconds = 1
+		require.Error(t, reload(invalid))
+
+		require.Error(t, d.ReloadYAML(context.Background(), strings.NewReader("interfaces: {")))
+
+		cancelled, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.Error(t, d.Reload(cancelled, config))
+
+		m := d.ReloadMetrics()
+		require.Equal(t, 4, m.Total)
+		require.Equal(t, map[string]int{"parse": 1, "validate": 1, "apply": 1}, m.Failures)
+	})
+
+	t.Run("Ensure the metrics are written in the text format", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, d.WriteMetrics(&buf))
+
+		out := buf.String()
+		require.Contains(t, out, "go_ra_reload_total 4\n")
+		require.Contains(t, out, "go_ra_reload_failures_total{reason=\"parse\"} 1\n")
+		require.Contains(t, out, "go_ra_reload_failures_total{reason=\"validate\"} 1\n")
+		require.Contains(t, out, "go_ra_reload_failures_total{reason=\"apply\"} 1\n")
+		require.Contains(t, out, "# TYPE go_ra_reload_duration_seconds histogram\n")
+		require.Contains(t, out, "go_ra_reload_duration_seconds_bucket{le=\"+Inf\"} 4\n")
+		require.Contains(t, out, "go_ra_reload_duration_seconds_count 4\n")
+	})
+}
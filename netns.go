@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/vishvananda/netns"
+)
+
+// runInNetns runs the function within the network namespace at the path. The
+// empty path means the current network namespace. The sockets created in the
+// function stay in the namespace after it returns.
+func runInNetns(path string, fn func() error) error {
+	if path == "" {
+		return fn()
+	}
+
+	// The network namespace is a per-thread attribute. Pin the goroutine
+	// to the thread while switching the namespace.
+	runtime.LockOSThread()
+
+	orig, err := netns.Get()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("cannot get the current netns: %w", err)
+	}
+	defer orig.Close()
+
+	target, err := netns.GetFromPath(path)
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("cannot open netns %s: %w", path, err)
+	}
+	defer target.Close()
+
+	if err := netns.Set(target); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("cannot enter netns %s: %w", path, err)
+	}
+
+	defer func() {
+		// If we cannot restore the namespace, leave the thread locked,
+		// so that the runtime terminates it instead of reusing it.
+		if err := netns.Set(orig); err == nil {
+			runtime.UnlockOSThread()
+		}
+	}()
+
+	return fn()
+}
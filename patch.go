@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidPatch is returned by Daemon.Patch when the patch cannot be applied
+// to the running configuration
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// ConfigPatch is a partial update of the running configuration. See
+// Daemon.Patch.
+type ConfigPatch struct {
+	// Patches of the interfaces. They are applied in order.
+	Interfaces []*InterfaceConfigPatch `yaml:"interfaces" json:"interfaces"`
+}
+
+// InterfaceConfigPatch is a partial update of the InterfaceConfig. The fields
+// not listed in Set or Append are left as-is.
+type InterfaceConfigPatch struct {
+	// Required: Name of the interface to patch. If the interface doesn't
+	// exist in the running configuration, it is added with the patched
+	// fields and the defaults for the others.
+	Name string `yaml:"name" json:"name"`
+
+	// Remove the interface from the running configuration. Set and
+	// Append must be empty if set.
+	Delete bool `yaml:"delete" json:"delete"`
+
+	// Values of the fields to patch. Only the fields listed in Set or
+	// Append are taken. The Name field is ignored.
+	Values *InterfaceConfig `yaml:"values" json:"values"`
+
+	// YAML/JSON keys of the fields to override with Values (e.g. "mtu").
+	// The slice fields are replaced as a whole.
+	Set []string `yaml:"set" json:"set"`
+
+	// YAML/JSON keys of the slice fields to append the elements of
+	// Values to (e.g. "prefixes").
+	Append []string `yaml:"append" json:"append"`
+}
+
+// apply applies the patch to the configuration in place
+func (p *ConfigPatch) apply(c *Config) error {
+	for _, ip := range p.Interfaces {
+		if ip == nil || ip.Name == "" {
+			return fmt.Errorf("%w: interface name is required", ErrInvalidPatch)
+		}
+
+		i := slices.IndexFunc(c.Interfaces, func(iface *InterfaceConfig) bool {
+			return iface != nil && iface.Name == ip.Name
+		})
+
+		if ip.Delete {
+			if len(ip.Set) > 0 || len(ip.Append) > 0 {
+				return fmt.Errorf("%w: interface %s: cannot patch the fields of the deleted interface", ErrInvalidPatch, ip.Name)
+			}
+			if i < 0 {
+				return fmt.Errorf("%w: interface %s: not found", ErrInvalidPatch, ip.Name)
+			}
+			c.Interfaces = slices.Delete(c.Interfaces, i, i+1)
+			continue
+		}
+
+		if i < 0 {
+			c.Interfaces = append(c.Interfaces, &InterfaceConfig{Name: ip.Name})
+			i = len(c.Interfaces) - 1
+		}
+
+		if err := ip.apply(c.Interfaces[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *InterfaceConfigPatch) apply(c *InterfaceConfig) error {
+	if len(p.Set) == 0 && len(p.Append) == 0 {
+		return nil
+	}
+
+	if p.Values == nil {
+		return fmt.Errorf("%w: interface %s: values are required to patch the fields", ErrInvalidPatch, p.Name)
+	}
+
+	// Don't share anything with the patch
+	values := reflect.ValueOf(p.Values.deepCopy()).Elem()
+	dst := reflect.ValueOf(c).Elem()
+
+	for _, key := range p.Set {
+		if slices.Contains(p.Append, key) {
+			return fmt.Errorf("%w: interface %s: field %q cannot be both set and appended", ErrInvalidPatch, p.Name, key)
+		}
+		i, err := fieldIndexByKey(dst.Type(), key)
+		if err != nil {
+			return fmt.Errorf("%w: interface %s: %w", ErrInvalidPatch, p.Name, err)
+		}
+		dst.Field(i).Set(values.Field(i))
+	}
+
+	for _, key := range p.Append {
+		i, err := fieldIndexByKey(dst.Type(), key)
+		if err != nil {
+			return fmt.Errorf("%w: interface %s: %w", ErrInvalidPatch, p.Name, err)
+		}
+		if dst.Field(i).Kind() != reflect.Slice {
+			return fmt.Errorf("%w: interface %s: field %q is not a list", ErrInvalidPatch, p.Name, key)
+		}
+		dst.Field(i).Set(reflect.AppendSlice(dst.Field(i), values.Field(i)))
+	}
+
+	return nil
+}
+
+// fieldIndexByKey returns the index of the patchable field with the YAML key
+func fieldIndexByKey(t reflect.Type, key string) (int, error) {
+	if key == "name" {
+		return 0, fmt.Errorf("field %q cannot be patched", key)
+	}
+	for i := 0; i < t.NumField(); i++ {
+		k, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if k == key {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown field %q", key)
+}
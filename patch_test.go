@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigPatch(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					MTU:                    1500,
+					Prefixes: []*PrefixConfig{
+						{
+							Prefix: "2001:db8:1::/64",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("Ensure the fields not listed are left as-is", func(t *testing.T) {
+		c := base()
+		err := (&ConfigPatch{
+			Interfaces: []*InterfaceConfigPatch{
+				{
+					Name:   "net0",
+					Values: &InterfaceConfig{MTU: 1400, RAIntervalMilliseconds: 2000},
+					Set:    []string{"mtu"},
+				},
+			},
+		}).apply(c)
+		require.NoError(t, err)
+
+		expected := base()
+		expected.Interfaces[0].MTU = 1400
+		require.Equal(t, expected, c)
+	})
+
+	t.Run("Ensure the slice is replaced or appended", func(t *testing.T) {
+		c := base()
+		err := (&ConfigPatch{
+			Interfaces: []*InterfaceConfigPatch{
+				{
+					Name: "net0",
+					Values: &InterfaceConfig{
+						Prefixes: []*PrefixConfig{{Prefix: "2001:db8:2::/64"}},
+					},
+					Append: []string{"prefixes"},
+				},
+			},
+		}).apply(c)
+		require.NoError(t, err)
+		require.Len(t, c.Interfaces[0].Prefixes, 2)
+		require.Equal(t, "2001:db8:1::/64", c.Interfaces[0].Prefixes[0].Prefix)
+		require.Equal(t, "2001:db8:2::/64", c.Interfaces[0].Prefixes[1].Prefix)
+
+		err = (&ConfigPatch{
+			Interfaces: []*InterfaceConfigPatch{
+				{
+					Name: "net0",
+					Values: &InterfaceConfig{
+						Prefixes: []*PrefixConfig{{Prefix: "2001:db8:3::/64"}},
+					},
+					Set: []string{"prefixes"},
+				},
+			},
+		}).apply(c)
+		require.NoError(t, err)
+		require.Len(t, c.Interfaces[0].Prefixes, 1)
+		require.Equal(t, "2001:db8:3::/64", c.Interfaces[0].Prefixes[0].Prefix)
+	})
+
+	t.Run("Ensure the interface is added and deleted", func(t *testing.T) {
+		c := base()
+		err := (&ConfigPatch{
+			Interfaces: []*InterfaceConfigPatch{
+				{
+					Name:   "net1",
+					Values: &InterfaceConfig{MTU: 9000},
+					Set:    []string{"mtu"},
+				},
+				{
+					Name:   "net0",
+					Delete: true,
+				},
+			},
+		}).apply(c)
+		require.NoError(t, err)
+		require.Len(t, c.Interfaces, 1)
+		require.Equal(t, &InterfaceConfig{Name: "net1", MTU: 9000}, c.Interfaces[0])
+	})
+
+	t.Run("Ensure the invalid patch is rejected", func(t *testing.T) {
+		for name, patch := range map[string]*InterfaceConfigPatch{
+			"unknown field":       {Name: "net0", Values: &InterfaceConfig{}, Set: []string{"foo"}},
+			"rename":              {Name: "net0", Values: &InterfaceConfig{Name: "net1"}, Set: []string{"name"}},
+			"append non-list":     {Name: "net0", Values: &InterfaceConfig{}, Append: []string{"mtu"}},
+			"set and append":      {Name: "net0", Values: &InterfaceConfig{}, Set: []string{"prefixes"}, Append: []string{"prefixes"}},
+			"no values":           {Name: "net0", Set: []string{"mtu"}},
+			"delete non-existing": {Name: "net1", Delete: true},
+		} {
+			t.Run(name, func(t *testing.T) {
+				err := (&ConfigPatch{Interfaces: []*InterfaceConfigPatch{patch}}).apply(base())
+				require.ErrorIs(t, err, ErrInvalidPatch)
+			})
+		}
+	})
+}
@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/ndp"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/ipv6"
+)
+
+// The link types of the pcap
+const (
+	pcapLinkTypeEthernet = 1
+	pcapLinkTypeRaw      = 101
+	pcapLinkTypeLinuxSLL = 113
+	pcapLinkTypeIPv6     = 229
+)
+
+// readPcapRS reads the RSes in the pcap file to replay them with the fake
+// socket. It supports the classic pcap format (not pcapng) of the Ethernet
+// (optionally VLAN tagged), Linux cooked, and raw IPv6 link types. The
+// packets other than RS are skipped and the RSes which cannot be parsed are
+// marked as malformed. The link-local source addresses get the zone.
+func readPcapRS(t *testing.T, path, zone string) []fakeRS {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(b), 24, "too short pcap")
+
+	// The magic tells the byte order. The nanosecond-resolution variant
+	// differs only in the timestamps which we don't use.
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(b[0:4]) {
+	case 0xa1b2c3d4, 0xa1b23c4d:
+		order = binary.LittleEndian
+	case 0xd4c3b2a1, 0x4d3cb2a1:
+		order = binary.BigEndian
+	default:
+		require.Fail(t, "unsupported pcap format")
+	}
+
+	linkType := order.Uint32(b[20:24])
+
+	rses := []fakeRS{}
+	for off := 24; off < len(b); {
+		require.GreaterOrEqual(t, len(b)-off, 16, "truncated record header")
+		capLen := int(order.Uint32(b[off+8 : off+12]))
+		off += 16
+		require.GreaterOrEqual(t, len(b)-off, capLen, "truncated record")
+		pkt := b[off : off+capLen]
+		off += capLen
+
+		var ip []byte
+		switch linkType {
+		case pcapLinkTypeEthernet:
+			// Skip the VLAN tags
+			etherType, l2 := 12, 14
+			for len(pkt) >= l2 && (binary.BigEndian.Uint16(pkt[etherType:]) == 0x8100 || binary.BigEndian.Uint16(pkt[etherType:]) == 0x88a8) {
+				etherType, l2 = etherType+4, l2+4
+			}
+			if len(pkt) >= l2 && binary.BigEndian.Uint16(pkt[etherType:]) == 0x86dd {
+				ip = pkt[l2:]
+			}
+		case pcapLinkTypeLinuxSLL:
+			if len(pkt) >= 16 && binary.BigEndian.Uint16(pkt[14:]) == 0x86dd {
+				ip = pkt[16:]
+			}
+		case pcapLinkTypeRaw, pcapLinkTypeIPv6:
+			ip = pkt
+		default:
+			require.Fail(t, "unsupported link type", "linkType", linkType)
+		}
+
+		// IPv6 without extension headers carrying ICMPv6 RS
+		if len(ip) < 40 || ip[0]>>4 != 6 || ip[6] != 58 {
+			continue
+		}
+		icmp := ip[40:min(len(ip), 40+int(binary.BigEndian.Uint16(ip[4:6])))]
+		if len(icmp) < 1 || icmp[0] != byte(ipv6.ICMPTypeRouterSolicitation) {
+			continue
+		}
+
+		from := netip.AddrFrom16([16]byte(ip[8:24]))
+		if from.IsLinkLocalUnicast() {
+			from = from.WithZone(zone)
+		}
+
+		m, err := ndp.ParseMessage(icmp)
+		rs, ok := m.(*ndp.RouterSolicitation)
+		if err != nil || !ok {
+			rses = append(rses, fakeRS{from: from, malformed: true})
+			continue
+		}
+		rses = append(rses, fakeRS{msg: rs, from: from})
+	}
+
+	return rses
+}
+
+func TestDaemonReplayPcapRS(t *testing.T) {
+	// testdata/rs.pcap has the following packets in order
+	//
+	// 1. RS from fe80::1 with SLLA
+	// 2. RS from the unspecified address
+	// 3. RS from fe80::3 with SLLA and Nonce
+	// 4. RS from fe80::4 with SLLA and an unknown option
+	// 5. Neighbor Solicitation (skipped)
+	// 6. RS from fe80::6 truncated in the reserved field
+	// 7. RS from fe80::7 with SLLA in the 802.1Q tagged frame
+	rses := readPcapRS(t, "testdata/rs.pcap", "net0")
+	froms := []string{}
+	for _, rs := range rses {
+		froms = append(froms, rs.from.String())
+	}
+	require.Equal(t, []string{"fe80::1%net0", "::", "fe80::3%net0", "fe80::4%net0", "fe80::6%net0", "fe80::7%net0"}, froms)
+	require.True(t, rses[4].malformed)
+
+	config := &Config{
+		Interfaces: []*InterfaceConfig{
+			{
+				Name: "net0",
+				// Set this to super long to avoid sending
+				// unsolicited RAs.
+				RAIntervalMilliseconds: 1800000,
+			},
+		},
+	}
+
+	reg := newFakeSockRegistry()
+
+	devWatcher := newFakeDeviceWatcher("net0")
+	devWatcher.update("net0", deviceState{isUp: true, addr: net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}})
+
+	d, err := NewDaemon(
+		config,
+		withSocketConstructor(reg.newSock),
+		withDeviceWatcher(devWatcher),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	t.Cleanup(cancel)
+
+	var sock *fakeSock
+	eventully(t, func() bool {
+		sock, err = reg.getSock("net0")
+		return err == nil
+	})
+
+	for _, rs := range rses {
+		t.Run("Ensure the daemon responds to RS from "+rs.from.String(), func(t *testing.T) {
+			before := d.Status().Interfaces[0]
+
+			sock.rxCh() <- rs
+
+			switch {
+			case rs.malformed:
+				// Counted, but not replied
+				eventully(t, func() bool {
+					return d.Status().Interfaces[0].RxMalformedRS == before.RxMalformedRS+1
+				})
+				require.Equal(t, before.TxSolicitedRA, d.Status().Interfaces[0].TxSolicitedRA)
+			case rs.from.IsUnspecified():
+				// Replied with the multicast RA
+				eventully(t, func() bool {
+					return d.Status().Interfaces[0].TxSolicitedRA == before.TxSolicitedRA+1
+				})
+			default:
+				select {
+				case ra := <-sock.txLLUnicastCh():
+					require.Equal(t, rs.from, ra.to)
+				case <-time.After(time.Second):
+					require.Fail(t, "timeout waiting for RA")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/creasty/defaults"
+)
+
+// Upper bound of the prefix source response body
+const maxPrefixSourceResponseBytes = 1 << 20
+
+// fetchPrefixes fetches the JSON list of the prefixes from the URL. The
+// returned prefixes are masked, sorted, and deduplicated, so that they can be
+// compared with the previous result as is.
+func fetchPrefixes(ctx context.Context, client *http.Client, url string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+
+	var raw []string
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxPrefixSourceResponseBytes)).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("cannot decode prefixes: %w", err)
+	}
+
+	prefixes := []netip.Prefix{}
+	for _, r := range raw {
+		p, err := netip.ParsePrefix(r)
+		if err != nil || !p.Addr().Is6() || p.Addr().Is4In6() {
+			return nil, fmt.Errorf("invalid prefix %q", r)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+
+	slices.SortFunc(prefixes, func(a, b netip.Prefix) int {
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c
+		}
+		return a.Bits() - b.Bits()
+	})
+	prefixes = slices.Compact(prefixes)
+
+	ret := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		ret = append(ret, p.String())
+	}
+	return ret, nil
+}
+
+// pollPrefixSource polls the prefix source of the interface until the
+// context is canceled. The prefixes removed from the source are kept as
+// withdrawn until the next successful poll, so that they're advertised with
+// zero lifetimes in the meantime. Changing the source forgets the prefixes of
+// the previous source.
+func (s *advertiser) pollPrefixSource(ctx context.Context) {
+	var source *PrefixSourceConfig
+	for {
+		if newSource := s.getPrefixSource(); !reflect.DeepEqual(source, newSource) {
+			source = newSource
+			s.setSourcedPrefixes(nil, nil)
+		}
+
+		var nextPoll <-chan time.Time
+		if source != nil {
+			interval := time.Duration(source.PollIntervalSeconds) * time.Second
+			nextPoll = time.After(interval)
+
+			fetchCtx, cancel := context.WithTimeout(ctx, interval)
+			prefixes, err := fetchPrefixes(fetchCtx, s.httpClient, source.URL)
+			cancel()
+			if err != nil {
+				s.logger.Warn("Failed to fetch prefixes from the source. Keep advertising the previous ones.", "url", source.URL, "error", err.Error())
+			} else {
+				current, withdrawn := s.getSourcedPrefixes()
+				if !slices.Equal(current, prefixes) {
+					s.logger.Info("Prefixes from the source changed", "url", source.URL, "prefixes", prefixes)
+					withdrawn = slices.DeleteFunc(slices.Clone(current), func(p string) bool {
+						return slices.Contains(prefixes, p)
+					})
+					s.setSourcedPrefixes(prefixes, withdrawn)
+				} else if len(withdrawn) > 0 {
+					s.setSourcedPrefixes(current, nil)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.prefixSourceCh:
+		case <-nextPoll:
+		}
+	}
+}
+
+// sourcedConfig returns a copy of the configuration with the prefixes from
+// the source appended to the Prefixes. The withdrawn prefixes are appended
+// with zero lifetimes. The prefixes which are already in the Prefixes are
+// skipped.
+func sourcedConfig(config *InterfaceConfig, current, withdrawn []string) *InterfaceConfig {
+	if len(current) == 0 && len(withdrawn) == 0 {
+		return config
+	}
+
+	c := config.deepCopy()
+
+	configured := func(prefix string) bool {
+		p := netip.MustParsePrefix(prefix)
+		return slices.ContainsFunc(config.Prefixes, func(pc *PrefixConfig) bool {
+			return pc.advertisedPrefix().Masked() == p
+		})
+	}
+
+	for _, prefix := range current {
+		if configured(prefix) {
+			continue
+		}
+		pc := &PrefixConfig{
+			Prefix:     prefix,
+			OnLink:     true,
+			Autonomous: true,
+		}
+		if err := defaults.Set(pc); err != nil {
+			panic("BUG (Please report 🙏): Defaulting failed: " + err.Error())
+		}
+		c.Prefixes = append(c.Prefixes, pc)
+	}
+
+	for _, prefix := range withdrawn {
+		if configured(prefix) {
+			continue
+		}
+		validLifetime, preferredLifetime := 0, 0
+		c.Prefixes = append(c.Prefixes, &PrefixConfig{
+			Prefix:                   prefix,
+			OnLink:                   true,
+			Autonomous:               true,
+			ValidLifetimeSeconds:     &validLifetime,
+			PreferredLifetimeSeconds: &preferredLifetime,
+		})
+	}
+
+	return c
+}
+
+func (s *advertiser) getPrefixSource() *PrefixSourceConfig {
+	s.prefixSourceLock.Lock()
+	defer s.prefixSourceLock.Unlock()
+	return s.prefixSource
+}
+
+// setPrefixSource updates the prefix source and notifies the poller when it
+// changes
+func (s *advertiser) setPrefixSource(source *PrefixSourceConfig) {
+	s.prefixSourceLock.Lock()
+	if reflect.DeepEqual(s.prefixSource, source) {
+		s.prefixSourceLock.Unlock()
+		return
+	}
+	s.prefixSource = source
+	s.prefixSourceLock.Unlock()
+
+	select {
+	case s.prefixSourceCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *advertiser) getSourcedPrefixes() ([]string, []string) {
+	s.prefixSourceLock.Lock()
+	defer s.prefixSourceLock.Unlock()
+	return s.sourcedPrefixes, s.withdrawnSourcedPrefixes
+}
+
+// setSourcedPrefixes updates the prefixes from the source and notifies the
+// main loop
+func (s *advertiser) setSourcedPrefixes(current, withdrawn []string) {
+	s.prefixSourceLock.Lock()
+	s.sourcedPrefixes = current
+	s.withdrawnSourcedPrefixes = withdrawn
+	s.prefixSourceLock.Unlock()
+
+	select {
+	case s.sourcedPrefixesCh <- struct{}{}:
+	default:
+	}
+}
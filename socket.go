@@ -5,43 +5,257 @@ package ra
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net"
 	"net/netip"
 	"os"
 	"time"
 
 	"github.com/mdlayher/ndp"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/net/icmp"
 	"golang.org/x/net/ipv6"
+	"golang.org/x/sys/unix"
 )
 
-// socket is a raw socket for sending RA and receiving RS
+// socket is a raw socket for sending RA and receiving RS and foreign RA
 type socket interface {
 	hardwareAddr() net.HardwareAddr
 	sendRA(ctx context.Context, dst netip.Addr, msg *ndp.RouterAdvertisement) error
-	recvRS(ctx context.Context) (*ndp.RouterSolicitation, netip.Addr, error)
+	// sendRawRA sends the marshaled RA as is. The checksum is computed by
+	// the kernel.
+	sendRawRA(ctx context.Context, dst netip.Addr, b []byte) error
+	// recv returns either *ndp.RouterSolicitation or
+	// *ndp.RouterAdvertisement
+	recv(ctx context.Context) (ndp.Message, netip.Addr, error)
 	close()
 }
 
-type socketCtor func(string) (socket, error)
+// errMalformedRS is returned by socket.recv when it receives the RS which
+// cannot be parsed. The source address is returned along with it.
+var errMalformedRS = errors.New("malformed RS")
+
+// socketOpts is the options of the socket
+type socketOpts struct {
+	// Index of the interface to bind the socket to. Zero means resolving
+	// the interface by name.
+	index int
+
+	// Name of the VRF device the interface is enslaved to. Empty means
+	// the default VRF.
+	vrf string
+
+	// Path to the network namespace to create the socket in. Empty means
+	// the current network namespace.
+	netnsPath string
+
+	// Address to bind the socket to and send the RAs from. Invalid means
+	// the link-local address of the interface.
+	srcAddr netip.Addr
+
+	// Size of the kernel receive buffer (SO_RCVBUF) in bytes. Zero means
+	// the kernel default.
+	recvBufferSize int
+
+	// Read the socket continuously in the background and queue the
+	// received messages, so that the kernel buffer is drained while the
+	// caller is busy with the previous message.
+	nonBlockingRecv bool
+}
+
+// recvQueueLen is the number of the received messages queued by the
+// non-blocking socket
+const recvQueueLen = 256
+
+type socketCtor func(string, socketOpts) (socket, error)
+
+// shadowSocket is a socket which silently drops the RAs instead of sending
+// them while shadow is set
+type shadowSocket struct {
+	socket
+	shadow bool
+}
+
+func (s *shadowSocket) sendRA(ctx context.Context, dst netip.Addr, msg *ndp.RouterAdvertisement) error {
+	if s.shadow {
+		return nil
+	}
+	return s.socket.sendRA(ctx, dst, msg)
+}
+
+// Mask of the reserved bits in the flags octet of the RA header. The upper
+// bits are M, O, H, Prf, and P flags.
+const raReservedBitsMask = 0x03
+
+// Offset of the flags octet in the marshaled RA (ICMPv6 type, code,
+// checksum, and Cur Hop Limit come first)
+const raFlagsOffset = 5
+
+// reservedBitsSocket is a socket which sets the reserved bits of the RA
+// header. The RA is sent as is while reserved is zero.
+type reservedBitsSocket struct {
+	socket
+	reserved uint8
+}
+
+func (s *reservedBitsSocket) sendRA(ctx context.Context, dst netip.Addr, msg *ndp.RouterAdvertisement) error {
+	if s.reserved == 0 {
+		return s.socket.sendRA(ctx, dst, msg)
+	}
+	b, err := ndp.MarshalMessage(msg)
+	if err != nil {
+		return err
+	}
+	b[raFlagsOffset] |= s.reserved & raReservedBitsMask
+	return s.socket.sendRawRA(ctx, dst, b)
+}
+
+// Initial backoff of the send retries. Doubled on each retry.
+const sendRetryBackoff = 10 * time.Millisecond
+
+// retrySocket is a socket which retries sending the RA up to retries times
+// when it fails with the transient error
+type retrySocket struct {
+	socket
+	retries int
+
+	// Called on each retry and when the send finally fails. Optional.
+	onRetry   func(err error)
+	onFailure func(err error)
+}
+
+func (s *retrySocket) sendRA(ctx context.Context, dst netip.Addr, msg *ndp.RouterAdvertisement) error {
+	backoff := sendRetryBackoff
+	for i := 0; ; i++ {
+		err := s.socket.sendRA(ctx, dst, msg)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		if i >= s.retries || !isTransientSendError(err) {
+			if s.onFailure != nil {
+				s.onFailure(err)
+			}
+			return err
+		}
+		if s.onRetry != nil {
+			s.onRetry(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isTransientSendError returns true when the send may succeed on retry
+func isTransientSendError(err error) bool {
+	return errors.Is(err, unix.ENOBUFS) || errors.Is(err, unix.EAGAIN)
+}
 
 // A real socket
 type sock struct {
 	conn  *ndp.Conn
 	iface *net.Interface
+
+	// The address the socket is bound to. Link-local unless the source
+	// address is configured.
+	addr netip.Addr
+
+	// Send-only socket for the raw RAs. Created on the first use in the
+	// namespace of netnsPath since ndp.Conn cannot send the raw bytes.
+	rawConn   *icmp.PacketConn
+	netnsPath string
+
+	// Receive-only socket with the tuned receive buffer. ndp.Conn doesn't
+	// expose the buffer size, so it's used only for sending when this is
+	// set.
+	recvConn *ipv6.PacketConn
+
+	// Messages read in the background in the non-blocking mode. closeCh
+	// stops the reader.
+	recvCh  chan recvResult
+	closeCh chan any
+}
+
+type recvResult struct {
+	m    ndp.Message
+	from netip.Addr
+	err  error
 }
 
 var _ socket = &sock{}
 
-func newSocket(ifaceName string) (socket, error) {
-	iface, err := net.InterfaceByName(ifaceName)
-	if err != nil {
+func newSocket(ifaceName string, opts socketOpts) (socket, error) {
+	var s *sock
+	// Everything including the interface lookup must happen within the
+	// namespace. The socket keeps belonging to it afterwards.
+	if err := runInNetns(opts.netnsPath, func() error {
+		var (
+			iface *net.Interface
+			err   error
+		)
+		if opts.index > 0 {
+			iface, err = net.InterfaceByIndex(opts.index)
+		} else {
+			iface, err = net.InterfaceByName(ifaceName)
+		}
+		if err != nil {
+			return err
+		}
+		// The socket is bound to the interface through the scope of
+		// the link-local address, so it always belongs to the VRF of
+		// the interface. Make sure it's the expected one.
+		if opts.vrf != "" {
+			if err := checkVRF(iface.Name, opts.vrf); err != nil {
+				return err
+			}
+		}
+		bindAddr := ndp.LinkLocal
+		if opts.srcAddr.IsValid() {
+			bindAddr = ndp.Addr(opts.srcAddr.String())
+		}
+		conn, addr, err := ndp.Listen(iface, bindAddr)
+		if err != nil {
+			return err
+		}
+		s = &sock{conn: conn, iface: iface, addr: addr, netnsPath: opts.netnsPath}
+		if opts.recvBufferSize > 0 {
+			if err := s.openRecvConn(opts.recvBufferSize); err != nil {
+				conn.Close()
+				return fmt.Errorf("cannot open receive socket: %w", err)
+			}
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-	conn, _, err := ndp.Listen(iface, ndp.LinkLocal)
+	if opts.nonBlockingRecv {
+		s.recvCh = make(chan recvResult, recvQueueLen)
+		s.closeCh = make(chan any)
+		go s.reader()
+	}
+	return s, nil
+}
+
+func checkVRF(ifaceName, vrfName string) error {
+	link, err := netlink.LinkByName(ifaceName)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return &sock{conn: conn, iface: iface}, nil
+	vrf, err := netlink.LinkByName(vrfName)
+	if err != nil {
+		return err
+	}
+	if _, ok := vrf.(*netlink.Vrf); !ok {
+		return fmt.Errorf("%s is not a VRF device", vrfName)
+	}
+	if link.Attrs().MasterIndex != vrf.Attrs().Index {
+		return fmt.Errorf("interface %s is not enslaved to VRF %s", ifaceName, vrfName)
+	}
+	return nil
 }
 
 func (s *sock) hardwareAddr() net.HardwareAddr {
@@ -70,52 +284,226 @@ func (s *sock) sendRA(ctx context.Context, addr netip.Addr, msg *ndp.RouterAdver
 	return err
 }
 
-func (s *sock) recvRS(ctx context.Context) (*ndp.RouterSolicitation, netip.Addr, error) {
-	var (
-		m    ndp.Message
-		from netip.Addr
-		err  error
-	)
+func (s *sock) sendRawRA(ctx context.Context, addr netip.Addr, b []byte) error {
+	if s.rawConn == nil {
+		if err := runInNetns(s.netnsPath, s.openRawConn); err != nil {
+			return fmt.Errorf("cannot open raw socket: %w", err)
+		}
+	}
+
+	var err error
 
 	ch := make(chan any)
 
 	go func() {
 		defer close(ch)
-		for {
-			// Set read deadline to avoid blocking forever. If there's any way
-			// to cancel the read operation, it would be better.
-			s.conn.SetReadDeadline(time.Now().Add(time.Millisecond * 500))
-
-			m, _, from, err = s.conn.ReadFrom()
-			if err != nil {
-				if os.IsTimeout(err) {
-					continue
-				}
-				return
-			}
+		s.rawConn.SetWriteDeadline(time.Now().Add(time.Second * 2))
+		_, err = s.rawConn.WriteTo(b, &net.IPAddr{IP: addr.AsSlice(), Zone: addr.Zone()})
+	}()
 
-			if m.Type() != ipv6.ICMPTypeRouterSolicitation {
-				// Ignore non-RS message and retry
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-ch:
+	}
+
+	return err
+}
+
+// openRawConn opens the send-only ICMPv6 socket with the same parameters as
+// ndp.Conn
+func (s *sock) openRawConn() error {
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", s.addr.String())
+	if err != nil {
+		return err
+	}
+
+	pc := conn.IPv6PacketConn()
+
+	// NDP messages must be sent with the hop limit 255 (RFC4861 Section
+	// 6.1.2)
+	if err := pc.SetHopLimit(255); err != nil {
+		conn.Close()
+		return err
+	}
+	if err := pc.SetMulticastHopLimit(255); err != nil {
+		conn.Close()
+		return err
+	}
+	if err := pc.SetMulticastInterface(s.iface); err != nil {
+		conn.Close()
+		return err
+	}
+
+	// Never receive anything
+	var f ipv6.ICMPFilter
+	f.SetAll(true)
+	if err := pc.SetICMPFilter(&f); err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.rawConn = conn
+	return nil
+}
+
+// openRecvConn opens the receive-only ICMPv6 socket with the given receive
+// buffer size and stops receiving on ndp.Conn
+func (s *sock) openRecvConn(bufferSize int) error {
+	conn, err := net.ListenIP("ip6:ipv6-icmp", &net.IPAddr{IP: s.addr.AsSlice(), Zone: s.addr.Zone()})
+	if err != nil {
+		return err
+	}
+
+	if err := conn.SetReadBuffer(bufferSize); err != nil {
+		conn.Close()
+		return err
+	}
+
+	pc := ipv6.NewPacketConn(conn)
+
+	// Only RS and RA are interesting
+	var f ipv6.ICMPFilter
+	f.SetAll(true)
+	f.Accept(ipv6.ICMPTypeRouterSolicitation)
+	f.Accept(ipv6.ICMPTypeRouterAdvertisement)
+	if err := pc.SetICMPFilter(&f); err != nil {
+		conn.Close()
+		return err
+	}
+
+	// Otherwise, the messages pile up in the buffer of ndp.Conn
+	var none ipv6.ICMPFilter
+	none.SetAll(true)
+	if err := s.conn.SetICMPFilter(&none); err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.recvConn = pc
+	return nil
+}
+
+// readRaw reads the ICMPv6 message from the receive socket
+func (s *sock) readRaw(b []byte) (int, netip.Addr, error) {
+	// Set read deadline to avoid blocking forever. If there's any way to
+	// cancel the read operation, it would be better.
+	deadline := time.Now().Add(time.Millisecond * 500)
+
+	if s.recvConn == nil {
+		s.conn.SetReadDeadline(deadline)
+		n, _, from, err := s.conn.ReadRaw(b)
+		return n, from, err
+	}
+
+	s.recvConn.SetReadDeadline(deadline)
+	n, _, src, err := s.recvConn.ReadFrom(b)
+	if err != nil {
+		return n, netip.Addr{}, err
+	}
+	from, ok := netip.AddrFromSlice(src.(*net.IPAddr).IP)
+	if !ok {
+		return n, netip.Addr{}, fmt.Errorf("invalid source address %s", src)
+	}
+	return n, from.WithZone(s.iface.Name), nil
+}
+
+// readMessage reads the socket until it receives RS or RA
+func (s *sock) readMessage(b []byte) (ndp.Message, netip.Addr, error) {
+	for {
+		// Parse the message by ourselves instead of using
+		// ReadFrom which silently drops the malformed ones
+		n, from, err := s.readRaw(b)
+		if err != nil {
+			if os.IsTimeout(err) {
 				continue
 			}
+			return nil, netip.Addr{}, err
+		}
 
+		// Ignore the messages sent by ourselves
+		if from == s.addr {
+			continue
+		}
+
+		m, err := ndp.ParseMessage(b[:n])
+		if err != nil {
+			if n > 0 && b[0] == byte(ipv6.ICMPTypeRouterSolicitation) {
+				return nil, from, fmt.Errorf("%w: %w", errMalformedRS, err)
+			}
+			// Ignore the other malformed messages
+			continue
+		}
+
+		if m.Type() != ipv6.ICMPTypeRouterSolicitation && m.Type() != ipv6.ICMPTypeRouterAdvertisement {
+			// Ignore non-RS/RA message and retry
+			continue
+		}
+
+		return m, from, nil
+	}
+}
+
+// reader reads the socket in the background in the non-blocking mode
+func (s *sock) reader() {
+	b := make([]byte, s.iface.MTU)
+	for {
+		m, from, err := s.readMessage(b)
+		select {
+		case s.recvCh <- recvResult{m: m, from: from, err: err}:
+		case <-s.closeCh:
 			return
 		}
-	}()
+		if err != nil && !errors.Is(err, errMalformedRS) {
+			return
+		}
+	}
+}
 
-	select {
-	case <-ctx.Done():
-		return nil, netip.Addr{}, ctx.Err()
-	case <-ch:
+func (s *sock) recv(ctx context.Context) (ndp.Message, netip.Addr, error) {
+	var r recvResult
+
+	if s.recvCh != nil {
+		select {
+		case <-ctx.Done():
+			return nil, netip.Addr{}, ctx.Err()
+		case r = <-s.recvCh:
+		}
+	} else {
+		ch := make(chan any)
+
+		go func() {
+			defer close(ch)
+			r.m, r.from, r.err = s.readMessage(make([]byte, s.iface.MTU))
+		}()
+
+		select {
+		case <-ctx.Done():
+			return nil, netip.Addr{}, ctx.Err()
+		case <-ch:
+		}
 	}
 
-	if err != nil {
-		return nil, netip.Addr{}, err
+	if errors.Is(r.err, errMalformedRS) {
+		return nil, r.from, r.err
+	}
+
+	if r.err != nil {
+		return nil, netip.Addr{}, r.err
 	}
 
-	return m.(*ndp.RouterSolicitation), from, nil
+	return r.m, r.from, nil
 }
 
 func (s *sock) close() {
+	if s.closeCh != nil {
+		close(s.closeCh)
+	}
 	s.conn.Close()
+	if s.recvConn != nil {
+		s.recvConn.Close()
+	}
+	if s.rawConn != nil {
+		s.rawConn.Close()
+	}
 }
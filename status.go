@@ -5,6 +5,29 @@ package ra
 
 // Status is the status of the Daemon
 type Status struct {
+	// Generation of the latest configuration accepted by the daemon. It
+	// starts from 1 for the initial configuration and is incremented on
+	// each successful reload. The rollback of the failed reload also
+	// increments it, so the generation is never reused.
+	Generation int `yaml:"generation" json:"generation"`
+
+	// Whether the advertisement is paused on all interfaces by
+	// Daemon.PauseAll
+	Paused bool `yaml:"paused" json:"paused"`
+
+	// Whether the advertisement is held until Daemon.Release is called
+	// (see WithStartHeld)
+	Held bool `yaml:"held" json:"held"`
+
+	// Error of the device watcher discovering the interfaces for
+	// AllInterfaces, InterfaceMACPrefixes, or the InterfaceSelector. Set
+	// while the watcher is failing and being restarted. Empty when the
+	// watcher is healthy or not running.
+	DeviceDiscoveryError string `yaml:"deviceDiscoveryError,omitempty" json:"deviceDiscoveryError,omitempty"`
+
+	// Number of the device discovery watcher restarts after the failure
+	DeviceDiscoveryRestarts int `yaml:"deviceDiscoveryRestarts" json:"deviceDiscoveryRestarts"`
+
 	// Interfaces-specific status
 	Interfaces []*InterfaceStatus `yaml:"interfaces" json:"interfaces"`
 }
@@ -32,12 +55,66 @@ type InterfaceStatus struct {
 	// Error message maybe set when the state is Failing or Stopped
 	Message string `yaml:"message,omitempty" json:"message,omitempty"`
 
+	// Generation of the configuration that is currently applied to the
+	// interface. When it matches the Generation of the Status, the latest
+	// configuration is applied to the interface.
+	AppliedGeneration int `yaml:"appliedGeneration" json:"appliedGeneration"`
+
+	// Whether the interface has an IPv6 link-local address. The RA is
+	// sourced from the link-local address, so the advertisement may stall
+	// without it.
+	HasLinkLocal bool `yaml:"hasLinkLocal" json:"hasLinkLocal"`
+
+	// The IPv6 link-local address of the interface. Empty when
+	// HasLinkLocal is false.
+	LinkLocalAddress string `yaml:"linkLocalAddress,omitempty" json:"linkLocalAddress,omitempty"`
+
+	// Name of the alias activated by Daemon.ActivateAlias. Empty when
+	// the options of the interface itself are advertised.
+	ActiveAlias string `yaml:"activeAlias,omitempty" json:"activeAlias,omitempty"`
+
 	// Last configuration update time in Unix time
 	LastUpdate int64 `yaml:"lastUpdate" json:"lastUpdate"`
 
+	// Whether another router on the link advertises a higher router
+	// preference than ours. Learned from the RAs sent by the other
+	// routers and cleared when their router lifetime expires.
+	Preempted bool `yaml:"preempted" json:"preempted"`
+
+	// Whether no RS is received within the RSSilenceWindowSeconds. Always
+	// false when the window is not configured.
+	RSSilent bool `yaml:"rsSilent" json:"rsSilent"`
+
+	// Number of received RSes which couldn't be parsed. Not counted with
+	// the MalformedRSDrop policy.
+	RxMalformedRS int `yaml:"rxMalformedRS" json:"rxMalformedRS"`
+
+	// Number of received RSes which weren't replied because the source
+	// exceeded SolicitedRateLimit
+	RxRateLimitedRS int `yaml:"rxRateLimitedRS" json:"rxRateLimitedRS"`
+
+	// Number of the changes of the advertised prefixes. See
+	// PrefixChangeHandler for what the change is.
+	PrefixChanges int `yaml:"prefixChanges" json:"prefixChanges"`
+
+	// Error of the device watcher of the interface. Set while the watcher
+	// is failing and being restarted. Empty when the watcher is healthy.
+	DeviceWatcherError string `yaml:"deviceWatcherError,omitempty" json:"deviceWatcherError,omitempty"`
+
+	// Number of the device watcher restarts after the failure
+	DeviceWatcherRestarts int `yaml:"deviceWatcherRestarts" json:"deviceWatcherRestarts"`
+
 	// Number of sent solicited router advertisements
 	TxSolicitedRA int `yaml:"txSolicitedRA" json:"txSolicitedRA"`
 
 	// Number of sent unsolicited router advertisements
 	TxUnsolicitedRA int `yaml:"txUnsolicitedRA" json:"txUnsolicitedRA"`
+
+	// Number of retries of sending router advertisements on the
+	// transient errors
+	TxRetries int `yaml:"txRetries" json:"txRetries"`
+
+	// Number of router advertisements which couldn't be sent even after
+	// the retries
+	TxFailures int `yaml:"txFailures" json:"txFailures"`
 }
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sysctl reads and writes the kernel parameters
+type sysctl interface {
+	// get returns the value of the parameter. The key is the list of the
+	// path components (e.g. ["net", "ipv6", "conf", "eth0", "accept_ra"]).
+	// The components are not split by dots as the interface name may
+	// contain them.
+	get(netnsPath string, key ...string) (string, error)
+
+	// set updates the value of the parameter
+	set(netnsPath string, value string, key ...string) error
+}
+
+// procSysctl is the sysctl backed by /proc/sys
+type procSysctl struct{}
+
+var _ sysctl = procSysctl{}
+
+func (procSysctl) path(key []string) string {
+	return filepath.Join(append([]string{"/proc/sys"}, key...)...)
+}
+
+func (s procSysctl) get(netnsPath string, key ...string) (string, error) {
+	var value string
+	// /proc/sys/net reflects the network namespace of the reader
+	if err := runInNetns(netnsPath, func() error {
+		b, err := os.ReadFile(s.path(key))
+		if err != nil {
+			return err
+		}
+		value = strings.TrimSpace(string(b))
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (s procSysctl) set(netnsPath string, value string, key ...string) error {
+	return runInNetns(netnsPath, func() error {
+		return os.WriteFile(s.path(key), []byte(value), 0644)
+	})
+}
+
+func acceptRAKey(iface string) []string {
+	return []string{"net", "ipv6", "conf", iface, "accept_ra"}
+}
+
+// getAcceptRA returns the accept_ra value of the interface
+func getAcceptRA(s sysctl, netnsPath, iface string) (int, error) {
+	value, err := s.get(netnsPath, acceptRAKey(iface)...)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid accept_ra value %q: %w", value, err)
+	}
+	return v, nil
+}
+
+// setAcceptRA sets the accept_ra value of the interface
+func setAcceptRA(s sysctl, netnsPath, iface string, v int) error {
+	return s.set(netnsPath, strconv.Itoa(v), acceptRAKey(iface)...)
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"os"
+
+	"github.com/creasty/defaults"
+)
+
+// Path to the machine-id used as a default seed of the ULA generation
+var machineIDPath = "/etc/machine-id"
+
+// GenerateULAPrefix generates an RFC4193 Unique Local IPv6 Unicast Address
+// /48 prefix from the seed. The Global ID is the least significant 40 bits of
+// the SHA-1 digest of the seed, so the same seed always generates the same
+// prefix.
+func GenerateULAPrefix(seed []byte) netip.Prefix {
+	digest := sha1.Sum(seed)
+
+	var addr [16]byte
+	addr[0] = 0xfd
+	copy(addr[1:6], digest[15:20])
+
+	return netip.PrefixFrom(netip.AddrFrom16(addr), 48)
+}
+
+// ulaSubnetPrefix derives a /64 prefix with the given Subnet ID from the ULA
+// /48 prefix
+func ulaSubnetPrefix(ula netip.Prefix, subnetID uint16) netip.Prefix {
+	addr := ula.Addr().As16()
+	binary.BigEndian.PutUint16(addr[6:8], subnetID)
+	return netip.PrefixFrom(netip.AddrFrom16(addr), 64)
+}
+
+// applyAutoULA adds the auto-generated ULA prefix to the interfaces with
+// AutoULA enabled and no prefix configured. The config must be validated
+// beforehand.
+func (c *Config) applyAutoULA() error {
+	for _, iface := range c.Interfaces {
+		if err := iface.applyAutoULA(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *InterfaceConfig) applyAutoULA() error {
+	if !c.AutoULA || len(c.Prefixes) != 0 {
+		return nil
+	}
+
+	seed := []byte(c.AutoULASeed)
+	if len(seed) == 0 {
+		machineID, err := os.ReadFile(machineIDPath)
+		if err != nil {
+			return fmt.Errorf("interface %s: cannot read machine-id for AutoULA: %w", c.Name, err)
+		}
+		seed = bytes.TrimSpace(machineID)
+	}
+
+	// Derive the Subnet ID from the interface name, so that each
+	// interface gets a different /64 from the same /48.
+	digest := sha1.Sum([]byte(c.Name))
+	subnetID := binary.BigEndian.Uint16(digest[18:20])
+
+	prefix := &PrefixConfig{
+		Prefix:     ulaSubnetPrefix(GenerateULAPrefix(seed), subnetID).String(),
+		OnLink:     true,
+		Autonomous: true,
+	}
+
+	if err := defaults.Set(prefix); err != nil {
+		panic("BUG (Please report 🙏): Defaulting failed: " + err.Error())
+	}
+
+	c.Prefixes = []*PrefixConfig{prefix}
+
+	return nil
+}
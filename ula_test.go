@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/mdlayher/ndp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateULAPrefix(t *testing.T) {
+	p := GenerateULAPrefix([]byte("test-seed"))
+	require.Equal(t, netip.MustParsePrefix("fd3e:1fa8:8d73::/48"), p)
+	require.Equal(t, p, GenerateULAPrefix([]byte("test-seed")), "ULA prefix must be deterministic")
+	require.NotEqual(t, p, GenerateULAPrefix([]byte("another-seed")))
+}
+
+func TestAutoULA(t *testing.T) {
+	newConfig := func() *InterfaceConfig {
+		return &InterfaceConfig{
+			Name:                   "net0",
+			RAIntervalMilliseconds: 1000,
+			AutoULA:                true,
+			AutoULASeed:            "test-seed",
+		}
+	}
+
+	t.Run("Ensure deterministic ULA prefix is advertised", func(t *testing.T) {
+		c := newConfig()
+		msg := newTestRAMsg(t, c)
+		require.Empty(t, msg.Options[1:], "ULA must not be advertised before applying")
+
+		require.NoError(t, c.applyAutoULA())
+		msg = newTestRAMsg(t, c)
+
+		var pi *ndp.PrefixInformation
+		for _, option := range msg.Options {
+			if opt, ok := option.(*ndp.PrefixInformation); ok {
+				pi = opt
+			}
+		}
+		require.NotNil(t, pi, "Prefix Information option is not advertised")
+		require.Equal(t, netip.MustParseAddr("fd3e:1fa8:8d73:2b84::"), pi.Prefix)
+		require.Equal(t, uint8(64), pi.PrefixLength)
+		require.True(t, pi.OnLink)
+		require.True(t, pi.AutonomousAddressConfiguration)
+	})
+
+	t.Run("Ensure configured prefix takes precedence", func(t *testing.T) {
+		c := newConfig()
+		c.Prefixes = []*PrefixConfig{{Prefix: "2001:db8::/64"}}
+		require.NoError(t, c.applyAutoULA())
+		require.Len(t, c.Prefixes, 1)
+		require.Equal(t, "2001:db8::/64", c.Prefixes[0].Prefix)
+	})
+
+	t.Run("Ensure machine-id is used without seed", func(t *testing.T) {
+		machineIDPath = t.TempDir() + "/machine-id"
+		t.Cleanup(func() { machineIDPath = "/etc/machine-id" })
+
+		c := newConfig()
+		c.AutoULASeed = ""
+		require.Error(t, c.applyAutoULA(), "missing machine-id must be an error")
+
+		require.NoError(t, os.WriteFile(machineIDPath, []byte("test-seed\n"), 0o644))
+		require.NoError(t, c.applyAutoULA())
+		require.Equal(t, "fd3e:1fa8:8d73:2b84::/64", c.Prefixes[0].Prefix)
+	})
+}
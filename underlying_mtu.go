@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"context"
+	"slices"
+)
+
+// An internal structure to represent the MTU update of the interface in
+// MTUFromInterfaces
+type underlyingMTU struct {
+	name string
+	mtu  int
+}
+
+// watchUnderlyingMTUs watches the MTUs of the interfaces in
+// MTUFromInterfaces until the context is canceled. Changing the interfaces
+// forgets the MTUs of the previous ones.
+func (s *advertiser) watchUnderlyingMTUs(ctx context.Context) {
+	for {
+		names, netnsPath := s.getMTUInterfaces()
+		s.resetUnderlyingMTUs()
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		mtuCh := make(chan underlyingMTU)
+		for _, name := range names {
+			devCh, err := watchWithRestart(watchCtx, s.deviceWatcher, name, deviceOpts{netnsPath: netnsPath}, func(err error) {
+				if err != nil {
+					s.logger.Warn("Watcher of the interface to derive the MTU from failed. Restarting.", "mtuInterface", name, "error", err.Error())
+				}
+			})
+			if err != nil {
+				s.logger.Warn("Failed to watch the interface to derive the MTU from", "mtuInterface", name, "error", err.Error())
+				continue
+			}
+			go func() {
+				for {
+					select {
+					case <-watchCtx.Done():
+						return
+					case dev, ok := <-devCh:
+						if !ok {
+							return
+						}
+						select {
+						case mtuCh <- underlyingMTU{name: name, mtu: dev.mtu}:
+						case <-watchCtx.Done():
+							return
+						}
+					}
+				}
+			}()
+		}
+
+	watch:
+		for {
+			select {
+			case <-ctx.Done():
+				cancel()
+				return
+			case <-s.mtuInterfacesCh:
+				break watch
+			case u := <-mtuCh:
+				s.setUnderlyingMTU(u.name, u.mtu)
+			}
+		}
+		cancel()
+	}
+}
+
+func (s *advertiser) getMTUInterfaces() ([]string, string) {
+	s.underlyingMTULock.Lock()
+	defer s.underlyingMTULock.Unlock()
+	return s.mtuInterfaces, s.mtuNetnsPath
+}
+
+// setMTUInterfaces updates the interfaces to derive the MTU from and
+// notifies the watcher when they change
+func (s *advertiser) setMTUInterfaces(names []string, netnsPath string) {
+	s.underlyingMTULock.Lock()
+	if slices.Equal(s.mtuInterfaces, names) && s.mtuNetnsPath == netnsPath {
+		s.underlyingMTULock.Unlock()
+		return
+	}
+	s.mtuInterfaces = names
+	s.mtuNetnsPath = netnsPath
+	s.underlyingMTULock.Unlock()
+
+	select {
+	case s.mtuInterfacesCh <- struct{}{}:
+	default:
+	}
+}
+
+// getUnderlyingMTU returns the smallest MTU of the interfaces in
+// MTUFromInterfaces. The MTU smaller than the IPv6 minimum MTU is raised to
+// it. It returns zero when no MTU is known yet.
+func (s *advertiser) getUnderlyingMTU() int {
+	s.underlyingMTULock.Lock()
+	defer s.underlyingMTULock.Unlock()
+
+	mtu := 0
+	for _, m := range s.underlyingMTUs {
+		if m > 0 && (mtu == 0 || m < mtu) {
+			mtu = m
+		}
+	}
+	if mtu == 0 {
+		return 0
+	}
+	return max(mtu, ipv6MinMTU)
+}
+
+// setUnderlyingMTU updates the MTU of the interface and notifies the main
+// loop
+func (s *advertiser) setUnderlyingMTU(name string, mtu int) {
+	s.underlyingMTULock.Lock()
+	if s.underlyingMTUs[name] == mtu {
+		s.underlyingMTULock.Unlock()
+		return
+	}
+	s.underlyingMTUs[name] = mtu
+	s.underlyingMTULock.Unlock()
+
+	s.notifyUnderlyingMTU()
+}
+
+func (s *advertiser) resetUnderlyingMTUs() {
+	s.underlyingMTULock.Lock()
+	s.underlyingMTUs = map[string]int{}
+	s.underlyingMTULock.Unlock()
+
+	s.notifyUnderlyingMTU()
+}
+
+func (s *advertiser) notifyUnderlyingMTU() {
+	select {
+	case s.underlyingMTUCh <- struct{}{}:
+	default:
+	}
+}
+
+// underlyingMTUConfig returns a copy of the configuration with the MTU
+// derived from the MTUFromInterfaces. It returns the configuration as is
+// when the MTU is unknown.
+func underlyingMTUConfig(config *InterfaceConfig, mtu int) *InterfaceConfig {
+	if mtu == 0 {
+		return config
+	}
+	c := config.deepCopy()
+	c.MTU = mtu
+	return c
+}
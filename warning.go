@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// The upper bound of the router lifetime in RFC4861. RFC8319 relaxed it to
+// 65535.
+const maxRFC4861RouterLifetime = 9000
+
+// The RA interval below this costs noticeable CPU when configured on many
+// interfaces. See WithMinEffectiveInterval to clamp it.
+const minRecommendedRAInterval = time.Second
+
+// Warning is a non-fatal issue found in the configuration. Unlike
+// ValidationErrors, the configuration with warnings is accepted, but it may
+// not work as the operator expects.
+type Warning struct {
+	// Name of the interface the warning is about
+	Interface string
+
+	// Path of the field within the interface configuration (e.g.
+	// "Prefixes[0].ValidLifetimeSeconds")
+	Field string
+
+	// Human-readable description of the issue
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("interface %s: %s: %s", w.Interface, w.Field, w.Message)
+}
+
+// ValidateConfig validates the configuration in the same way as the Daemon.
+// Unlike the error, the warnings don't prevent the Daemon from accepting the
+// configuration, so the callers can log them and proceed. The defaults are
+// applied to the copy of the configuration, so config is not modified. The
+// error is ValidationErrors when the configuration is invalid.
+func ValidateConfig(config *Config) ([]Warning, error) {
+	if config == nil {
+		return nil, errors.New("configuration must not be nil")
+	}
+	return config.deepCopy().defaultAndValidateWithWarnings()
+}
+
+// defaultAndValidateWithWarnings is same as defaultAndValidate, but also
+// returns the warnings found in the valid configuration
+func (c *Config) defaultAndValidateWithWarnings() ([]Warning, error) {
+	if err := c.defaultAndValidate(); err != nil {
+		return nil, err
+	}
+	return c.warnings(), nil
+}
+
+// warnings returns the warnings of the configuration. The config must be
+// validated beforehand.
+func (c *Config) warnings() []Warning {
+	warnings := []Warning{}
+	for _, iface := range c.Interfaces {
+		warnings = append(warnings, iface.warnings()...)
+	}
+	return warnings
+}
+
+func (c *InterfaceConfig) warnings() []Warning {
+	warnings := []Warning{}
+
+	interval := time.Duration(c.RAIntervalMilliseconds) * time.Millisecond
+
+	// Zero lifetime is intentional (e.g. deprecation), so don't warn
+	// about it. Otherwise, the information expires on the hosts before
+	// the next unsolicited RA refreshes it.
+	checkLifetime := func(field string, seconds int) {
+		lifetime := time.Duration(seconds) * time.Second
+		if lifetime > 0 && lifetime < interval {
+			warnings = append(warnings, Warning{
+				Interface: c.Name,
+				Field:     field,
+				Message:   fmt.Sprintf("lifetime %s is shorter than the RA interval %s", lifetime, interval),
+			})
+		}
+	}
+
+	// Valid, but close to the validation floor. Warn regardless of the
+	// clamp of the daemon as the configuration doesn't know about it.
+	if interval < minRecommendedRAInterval {
+		warnings = append(warnings, Warning{
+			Interface: c.Name,
+			Field:     "RAIntervalMilliseconds",
+			Message:   fmt.Sprintf("RA interval %s is shorter than %s. Sending RAs this often on many interfaces is CPU intensive.", interval, minRecommendedRAInterval),
+		})
+	}
+
+	checkLifetime("RouterLifetimeSeconds", c.RouterLifetimeSeconds)
+
+	if c.RouterLifetimeSeconds > maxRFC4861RouterLifetime && !c.AllowExtendedRouterLifetime {
+		warnings = append(warnings, Warning{
+			Interface: c.Name,
+			Field:     "RouterLifetimeSeconds",
+			Message:   fmt.Sprintf("router lifetime %ds exceeds %ds, the upper bound of RFC4861. Set AllowExtendedRouterLifetime to acknowledge it.", c.RouterLifetimeSeconds, maxRFC4861RouterLifetime),
+		})
+	}
+
+	for i, prefix := range c.Prefixes {
+		checkLifetime(fmt.Sprintf("Prefixes[%d].ValidLifetimeSeconds", i), *prefix.ValidLifetimeSeconds)
+	}
+
+	for i, route := range c.Routes {
+		checkLifetime(fmt.Sprintf("Routes[%d].LifetimeSeconds", i), route.LifetimeSeconds)
+	}
+
+	// Route Information for the prefix which is on-link is redundant or
+	// conflicting with the on-link determination.
+	for i, route := range c.Routes {
+		r := netip.MustParsePrefix(route.Prefix)
+		for j, prefix := range c.Prefixes {
+			if !prefix.OnLink || c.ForceOffLink {
+				continue
+			}
+			p := prefix.advertisedPrefix()
+			if r.Bits() >= p.Bits() && p.Contains(r.Addr()) {
+				warnings = append(warnings, Warning{
+					Interface: c.Name,
+					Field:     fmt.Sprintf("Routes[%d].Prefix", i),
+					Message:   fmt.Sprintf("route %s is contained in the on-link prefix %s (Prefixes[%d])", r, p, j),
+				})
+			}
+		}
+	}
+
+	// ForceOffLink silently overrides the explicit OnLink
+	if c.ForceOffLink {
+		for i, prefix := range c.Prefixes {
+			if prefix.OnLink {
+				warnings = append(warnings, Warning{
+					Interface: c.Name,
+					Field:     fmt.Sprintf("Prefixes[%d].OnLink", i),
+					Message:   fmt.Sprintf("prefix %s is on-link, but ForceOffLink clears the L flag", prefix.Prefix),
+				})
+			}
+		}
+	}
+
+	// With the M flag, the hosts obtain the addresses from DHCPv6. The
+	// autonomous prefix makes them configure the SLAAC addresses as well,
+	// which is usually not what the operator expects.
+	if c.Managed {
+		for i, prefix := range c.Prefixes {
+			if prefix.Autonomous {
+				warnings = append(warnings, Warning{
+					Interface: c.Name,
+					Field:     fmt.Sprintf("Prefixes[%d].Autonomous", i),
+					Message:   fmt.Sprintf("prefix %s is autonomous while Managed is set. The hosts configure both SLAAC and DHCPv6 addresses. Set Autonomous to false to use DHCPv6 only.", prefix.Prefix),
+				})
+			}
+		}
+	}
+
+	// The opt-in only makes the configuration valid. The RAs are still
+	// discarded by the compliant hosts, so always warn.
+	if a, err := netip.ParseAddr(c.SourceAddress); err == nil && !a.IsLinkLocalUnicast() {
+		warnings = append(warnings, Warning{
+			Interface: c.Name,
+			Field:     "SourceAddress",
+			Message:   fmt.Sprintf("RAs are sent from the non-link-local address %s. This violates RFC4861 Section 4.2 and the compliant hosts silently discard them.", a),
+		})
+	}
+
+	for i, rdnss := range c.RDNSSes {
+		checkLifetime(fmt.Sprintf("RDNSSes[%d].LifetimeSeconds", i), rdnss.LifetimeSeconds)
+	}
+
+	// RFC8106 allows the link-local RDNSS address, but it's usable only
+	// with the zone of the link the RA is received on. Many hosts don't
+	// keep the zone and fail to reach the resolver.
+	for i, rdnss := range c.RDNSSes {
+		for j, addr := range rdnss.Addresses {
+			if a, err := netip.ParseAddr(addr); err == nil && a.IsLinkLocalUnicast() {
+				warnings = append(warnings, Warning{
+					Interface: c.Name,
+					Field:     fmt.Sprintf("RDNSSes[%d].Addresses[%d]", i, j),
+					Message:   fmt.Sprintf("link-local RDNSS address %s is unusable on the hosts which don't keep the zone. Consider a global or ULA address.", addr),
+				})
+			}
+		}
+	}
+
+	for i, dnssl := range c.DNSSLs {
+		checkLifetime(fmt.Sprintf("DNSSLs[%d].LifetimeSeconds", i), dnssl.LifetimeSeconds)
+	}
+
+	for i, nat64prefix := range c.NAT64Prefixes {
+		checkLifetime(fmt.Sprintf("NAT64Prefixes[%d].LifetimeSeconds", i), *nat64prefix.LifetimeSeconds)
+	}
+
+	// The hosts using the NAT64 prefix (RFC8781) typically rely on the
+	// DNS64 resolver to synthesize the AAAA records. Without RDNSS, the
+	// hosts may not be able to find it.
+	if len(c.NAT64Prefixes) > 0 && len(c.RDNSSes) == 0 {
+		warnings = append(warnings, Warning{
+			Interface: c.Name,
+			Field:     "NAT64Prefixes",
+			Message:   "NAT64 prefix is advertised without RDNSS. DNS64 resolver is typically required.",
+		})
+	}
+
+	return warnings
+}
@@ -0,0 +1,345 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of go-ra
+
+package ra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestValidateConfig(t *testing.T) {
+	t.Run("Ensure warnings are returned without error", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 10000,
+					RouterLifetimeSeconds:  5,
+				},
+			},
+		}
+
+		warnings, err := ValidateConfig(config)
+		require.NoError(t, err)
+		require.Equal(t, []Warning{
+			{
+				Interface: "net0",
+				Field:     "RouterLifetimeSeconds",
+				Message:   "lifetime 5s is shorter than the RA interval 10s",
+			},
+		}, warnings)
+
+		// The defaults are not applied to the original
+		require.Nil(t, config.Interfaces[0].Prefixes)
+	})
+
+	t.Run("Ensure invalid configuration returns ValidationErrors", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 10,
+				},
+			},
+		}
+
+		warnings, err := ValidateConfig(config)
+		require.Nil(t, warnings)
+		var verrs ValidationErrors
+		require.ErrorAs(t, err, &verrs)
+	})
+
+	t.Run("Nil configuration", func(t *testing.T) {
+		_, err := ValidateConfig(nil)
+		require.Error(t, err)
+	})
+}
+
+func TestConfigWarnings(t *testing.T) {
+	t.Run("Ensure short lifetime yields a warning and no error", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 10000,
+					RouterLifetimeSeconds:  5,
+					Prefixes: []*PrefixConfig{
+						{
+							Prefix:                   "2001:db8::/64",
+							ValidLifetimeSeconds:     ptr.To(5),
+							PreferredLifetimeSeconds: ptr.To(5),
+						},
+					},
+				},
+			},
+		}
+
+		warnings, err := config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Len(t, warnings, 2)
+		require.Equal(t, "net0", warnings[0].Interface)
+		require.Equal(t, "RouterLifetimeSeconds", warnings[0].Field)
+		require.Equal(t, "net0", warnings[1].Interface)
+		require.Equal(t, "Prefixes[0].ValidLifetimeSeconds", warnings[1].Field)
+	})
+
+	t.Run("Ensure non-link-local source address yields a warning", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                    "net0",
+					RAIntervalMilliseconds:  1000,
+					SourceAddress:           "2001:db8::1",
+					AllowNonLinkLocalSource: true,
+				},
+			},
+		}
+
+		warnings, err := config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Equal(t, "net0", warnings[0].Interface)
+		require.Equal(t, "SourceAddress", warnings[0].Field)
+	})
+
+	t.Run("Ensure link-local RDNSS address yields a warning", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					RDNSSes: []*RDNSSConfig{
+						{
+							LifetimeSeconds: 1800,
+							Addresses:       []string{"2001:db8::53", "fe80::53"},
+						},
+					},
+				},
+			},
+		}
+
+		warnings, err := config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Equal(t, "net0", warnings[0].Interface)
+		require.Equal(t, "RDNSSes[0].Addresses[1]", warnings[0].Field)
+		require.Contains(t, warnings[0].Message, "fe80::53")
+	})
+
+	t.Run("Ensure autonomous prefix with Managed yields a warning", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					Managed:                true,
+					Prefixes: []*PrefixConfig{
+						{
+							Prefix: "2001:db8::/64",
+						},
+						{
+							Prefix:     "2001:db8:1::/64",
+							Autonomous: true,
+						},
+					},
+				},
+			},
+		}
+
+		warnings, err := config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Equal(t, "net0", warnings[0].Interface)
+		require.Equal(t, "Prefixes[1].Autonomous", warnings[0].Field)
+		require.Contains(t, warnings[0].Message, "2001:db8:1::/64")
+
+		// No warning without Managed
+		config.Interfaces[0].Managed = false
+		require.Empty(t, config.warnings())
+	})
+
+	t.Run("Ensure route contained in the on-link prefix yields a warning", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					Prefixes: []*PrefixConfig{
+						{
+							Prefix: "fd00::/64",
+							OnLink: true,
+						},
+						{
+							Prefix: "fd00:1::/64",
+						},
+					},
+					Routes: []*RouteConfig{
+						{
+							Prefix:          "fd00::/96",
+							LifetimeSeconds: 1800,
+						},
+						{
+							// Not on-link
+							Prefix:          "fd00:1::/96",
+							LifetimeSeconds: 1800,
+						},
+						{
+							// Not contained
+							Prefix:          "fd00::/48",
+							LifetimeSeconds: 1800,
+						},
+					},
+				},
+			},
+		}
+
+		warnings, err := config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Equal(t, "net0", warnings[0].Interface)
+		require.Equal(t, "Routes[0].Prefix", warnings[0].Field)
+	})
+
+	t.Run("Ensure zero lifetime doesn't yield a warning", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 10000,
+					RouterLifetimeSeconds:  0,
+				},
+			},
+		}
+
+		warnings, err := config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+	})
+
+	t.Run("Ensure invalid config yields an error", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 69,
+				},
+			},
+		}
+
+		_, err := config.defaultAndValidateWithWarnings()
+		var verrs ValidationErrors
+		require.ErrorAs(t, err, &verrs)
+	})
+
+	t.Run("Ensure NAT64 prefix without RDNSS yields a warning", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					NAT64Prefixes: []*NAT64PrefixConfig{
+						{
+							Prefix: "64:ff9b::/96",
+						},
+					},
+				},
+			},
+		}
+
+		warnings, err := config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Equal(t, "net0", warnings[0].Interface)
+		require.Equal(t, "NAT64Prefixes", warnings[0].Field)
+
+		// RDNSS silences the warning
+		config.Interfaces[0].RDNSSes = []*RDNSSConfig{
+			{
+				LifetimeSeconds: 1800,
+				Addresses:       []string{"2001:db8::53"},
+			},
+		}
+
+		warnings, err = config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+	})
+
+	t.Run("Ensure router lifetime longer than 9000 yields a warning unless allowed", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					RouterLifetimeSeconds:  9001,
+				},
+			},
+		}
+
+		warnings, err := config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Equal(t, "net0", warnings[0].Interface)
+		require.Equal(t, "RouterLifetimeSeconds", warnings[0].Field)
+
+		config.Interfaces[0].AllowExtendedRouterLifetime = true
+
+		warnings, err = config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+	})
+
+	t.Run("Ensure ForceOffLink with on-link prefix yields a warning", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 1000,
+					ForceOffLink:           true,
+					Prefixes: []*PrefixConfig{
+						{
+							Prefix:     "2001:db8:0:1::/64",
+							Autonomous: true,
+						},
+						{
+							Prefix:     "2001:db8:0:2::/64",
+							OnLink:     true,
+							Autonomous: true,
+						},
+					},
+				},
+			},
+		}
+
+		warnings, err := config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Equal(t, "net0", warnings[0].Interface)
+		require.Equal(t, "Prefixes[1].OnLink", warnings[0].Field)
+	})
+
+	t.Run("Ensure short RA interval yields a warning", func(t *testing.T) {
+		config := &Config{
+			Interfaces: []*InterfaceConfig{
+				{
+					Name:                   "net0",
+					RAIntervalMilliseconds: 70,
+				},
+			},
+		}
+
+		warnings, err := config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Equal(t, "net0", warnings[0].Interface)
+		require.Equal(t, "RAIntervalMilliseconds", warnings[0].Field)
+
+		config.Interfaces[0].RAIntervalMilliseconds = 1000
+		warnings, err = config.defaultAndValidateWithWarnings()
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+	})
+}
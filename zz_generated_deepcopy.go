@@ -14,6 +14,14 @@ func (o *Config) deepCopy() *Config {
 			}
 		}
 	}
+	if o.InterfaceMACPrefixes != nil {
+		cp.InterfaceMACPrefixes = make([]string, len(o.InterfaceMACPrefixes))
+		copy(cp.InterfaceMACPrefixes, o.InterfaceMACPrefixes)
+	}
+	if o.ExcludeInterfaces != nil {
+		cp.ExcludeInterfaces = make([]string, len(o.ExcludeInterfaces))
+		copy(cp.ExcludeInterfaces, o.ExcludeInterfaces)
+	}
 	return &cp
 }
 
@@ -35,6 +43,106 @@ func (o *Status) deepCopy() *Status {
 // deepCopy generates a deep copy of *InterfaceConfig
 func (o *InterfaceConfig) deepCopy() *InterfaceConfig {
 	var cp InterfaceConfig = *o
+	if o.SendRetries != nil {
+		cp.SendRetries = new(int)
+		*cp.SendRetries = *o.SendRetries
+	}
+	if o.DisabledOptions != nil {
+		cp.DisabledOptions = make([]string, len(o.DisabledOptions))
+		copy(cp.DisabledOptions, o.DisabledOptions)
+	}
+	if o.IncludePrefixesInSolicited != nil {
+		cp.IncludePrefixesInSolicited = new(bool)
+		*cp.IncludePrefixesInSolicited = *o.IncludePrefixesInSolicited
+	}
+	if o.SolicitorOverrides != nil {
+		cp.SolicitorOverrides = make(map[string]*SolicitorOverrideConfig, len(o.SolicitorOverrides))
+		for k2, v2 := range o.SolicitorOverrides {
+			var cp_SolicitorOverrides_v2 *SolicitorOverrideConfig
+			if v2 != nil {
+				cp_SolicitorOverrides_v2 = new(SolicitorOverrideConfig)
+				*cp_SolicitorOverrides_v2 = *v2
+				if v2.Prefixes != nil {
+					cp_SolicitorOverrides_v2.Prefixes = make([]*PrefixConfig, len(v2.Prefixes))
+					copy(cp_SolicitorOverrides_v2.Prefixes, v2.Prefixes)
+					for i5 := range v2.Prefixes {
+						if v2.Prefixes[i5] != nil {
+							cp_SolicitorOverrides_v2.Prefixes[i5] = v2.Prefixes[i5].deepCopy()
+						}
+					}
+				}
+				if v2.RDNSSes != nil {
+					cp_SolicitorOverrides_v2.RDNSSes = make([]*RDNSSConfig, len(v2.RDNSSes))
+					copy(cp_SolicitorOverrides_v2.RDNSSes, v2.RDNSSes)
+					for i5 := range v2.RDNSSes {
+						if v2.RDNSSes[i5] != nil {
+							cp_SolicitorOverrides_v2.RDNSSes[i5] = v2.RDNSSes[i5].deepCopy()
+						}
+					}
+				}
+			}
+			cp.SolicitorOverrides[k2] = cp_SolicitorOverrides_v2
+		}
+	}
+	if o.Aliases != nil {
+		cp.Aliases = make(map[string]*AliasConfig, len(o.Aliases))
+		for k2, v2 := range o.Aliases {
+			var cp_Aliases_v2 *AliasConfig
+			if v2 != nil {
+				cp_Aliases_v2 = new(AliasConfig)
+				*cp_Aliases_v2 = *v2
+				if v2.Prefixes != nil {
+					cp_Aliases_v2.Prefixes = make([]*PrefixConfig, len(v2.Prefixes))
+					copy(cp_Aliases_v2.Prefixes, v2.Prefixes)
+					for i5 := range v2.Prefixes {
+						if v2.Prefixes[i5] != nil {
+							cp_Aliases_v2.Prefixes[i5] = v2.Prefixes[i5].deepCopy()
+						}
+					}
+				}
+				if v2.Routes != nil {
+					cp_Aliases_v2.Routes = make([]*RouteConfig, len(v2.Routes))
+					copy(cp_Aliases_v2.Routes, v2.Routes)
+					for i5 := range v2.Routes {
+						if v2.Routes[i5] != nil {
+							cp_Aliases_v2.Routes[i5] = v2.Routes[i5].deepCopy()
+						}
+					}
+				}
+				if v2.RDNSSes != nil {
+					cp_Aliases_v2.RDNSSes = make([]*RDNSSConfig, len(v2.RDNSSes))
+					copy(cp_Aliases_v2.RDNSSes, v2.RDNSSes)
+					for i5 := range v2.RDNSSes {
+						if v2.RDNSSes[i5] != nil {
+							cp_Aliases_v2.RDNSSes[i5] = v2.RDNSSes[i5].deepCopy()
+						}
+					}
+				}
+				if v2.DNSSLs != nil {
+					cp_Aliases_v2.DNSSLs = make([]*DNSSLConfig, len(v2.DNSSLs))
+					copy(cp_Aliases_v2.DNSSLs, v2.DNSSLs)
+					for i5 := range v2.DNSSLs {
+						if v2.DNSSLs[i5] != nil {
+							cp_Aliases_v2.DNSSLs[i5] = v2.DNSSLs[i5].deepCopy()
+						}
+					}
+				}
+			}
+			cp.Aliases[k2] = cp_Aliases_v2
+		}
+	}
+	if o.SolicitedReachableTimeMilliseconds != nil {
+		cp.SolicitedReachableTimeMilliseconds = new(int)
+		*cp.SolicitedReachableTimeMilliseconds = *o.SolicitedReachableTimeMilliseconds
+	}
+	if o.SolicitedRetransmitTimeMilliseconds != nil {
+		cp.SolicitedRetransmitTimeMilliseconds = new(int)
+		*cp.SolicitedRetransmitTimeMilliseconds = *o.SolicitedRetransmitTimeMilliseconds
+	}
+	if o.MTUFromInterfaces != nil {
+		cp.MTUFromInterfaces = make([]string, len(o.MTUFromInterfaces))
+		copy(cp.MTUFromInterfaces, o.MTUFromInterfaces)
+	}
 	if o.Prefixes != nil {
 		cp.Prefixes = make([]*PrefixConfig, len(o.Prefixes))
 		copy(cp.Prefixes, o.Prefixes)
@@ -71,6 +179,52 @@ func (o *InterfaceConfig) deepCopy() *InterfaceConfig {
 			}
 		}
 	}
+	if o.NAT64Prefixes != nil {
+		cp.NAT64Prefixes = make([]*NAT64PrefixConfig, len(o.NAT64Prefixes))
+		copy(cp.NAT64Prefixes, o.NAT64Prefixes)
+		for i2 := range o.NAT64Prefixes {
+			if o.NAT64Prefixes[i2] != nil {
+				cp.NAT64Prefixes[i2] = new(NAT64PrefixConfig)
+				*cp.NAT64Prefixes[i2] = *o.NAT64Prefixes[i2]
+				if o.NAT64Prefixes[i2].LifetimeSeconds != nil {
+					cp.NAT64Prefixes[i2].LifetimeSeconds = new(int)
+					*cp.NAT64Prefixes[i2].LifetimeSeconds = *o.NAT64Prefixes[i2].LifetimeSeconds
+				}
+			}
+		}
+	}
+	if o.VendorOptions != nil {
+		cp.VendorOptions = make([]*VendorOptionConfig, len(o.VendorOptions))
+		copy(cp.VendorOptions, o.VendorOptions)
+		for i2 := range o.VendorOptions {
+			if o.VendorOptions[i2] != nil {
+				cp.VendorOptions[i2] = new(VendorOptionConfig)
+				*cp.VendorOptions[i2] = *o.VendorOptions[i2]
+			}
+		}
+	}
+	if o.SENDOptions != nil {
+		cp.SENDOptions = make([]*SENDOptionConfig, len(o.SENDOptions))
+		copy(cp.SENDOptions, o.SENDOptions)
+		for i2 := range o.SENDOptions {
+			if o.SENDOptions[i2] != nil {
+				cp.SENDOptions[i2] = new(SENDOptionConfig)
+				*cp.SENDOptions[i2] = *o.SENDOptions[i2]
+			}
+		}
+	}
+	if o.PrefixRotation != nil {
+		cp.PrefixRotation = new(PrefixRotationConfig)
+		*cp.PrefixRotation = *o.PrefixRotation
+		if o.PrefixRotation.Prefixes != nil {
+			cp.PrefixRotation.Prefixes = make([]string, len(o.PrefixRotation.Prefixes))
+			copy(cp.PrefixRotation.Prefixes, o.PrefixRotation.Prefixes)
+		}
+	}
+	if o.PrefixSource != nil {
+		cp.PrefixSource = new(PrefixSourceConfig)
+		*cp.PrefixSource = *o.PrefixSource
+	}
 	return &cp
 }
 
@@ -91,6 +245,10 @@ func (o *PrefixConfig) deepCopy() *PrefixConfig {
 		cp.PreferredLifetimeSeconds = new(int)
 		*cp.PreferredLifetimeSeconds = *o.PreferredLifetimeSeconds
 	}
+	if o.SubnetID != nil {
+		cp.SubnetID = new(int)
+		*cp.SubnetID = *o.SubnetID
+	}
 	return &cp
 }
 